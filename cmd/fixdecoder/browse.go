@@ -0,0 +1,143 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+	"github.com/stephenlclarke/fixdecoder/fix"
+	"golang.org/x/term"
+)
+
+var (
+	stdinIsTerminal = func() bool { return term.IsTerminal(int(os.Stdin.Fd())) }
+	runBrowser      = browseTerminal
+)
+
+// handleBrowse implements "fixdecoder browse FILE...".
+func handleBrowse(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("browse", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	var colour colourFlag
+
+	secret := fs.Bool("secret", false, "Obfuscate sensitive FIX tag values")
+	themeName := fs.String("theme", "", "Colour theme ("+strings.Join(decoder.ThemeNames(), ",")+") or path to a theme file")
+	prefix := fs.String("prefix", "", "Prefix parser ("+strings.Join(decoder.PrefixParserNames(), ",")+") or prefix config file for log-line times and directions (default auto)")
+	xmlPath := fs.String("xml", "", "Path to alternative FIX XML file")
+	fs.Var(&colour, "colour", "Force coloured output (yes|no). Default: auto-detect based on stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if fs.NArg() == 0 {
+		fmt.Fprintln(errOut, "Usage: fixdecoder browse [--xml=FIX44.xml] [--prefix=NAME|FILE] [--colour=yes|no] [--theme=NAME|FILE] [--secret] FILE...")
+		return 1
+	}
+
+	if !stdinIsTerminal() {
+		fmt.Fprintln(errOut, "browse needs an interactive terminal")
+		return 1
+	}
+
+	theme, err := selectTheme(*themeName, colour)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	opts := decoder.LogOptions{
+		DecodeOptions: decoder.DecodeOptions{Validate: true},
+		Obfuscator:    fix.CreateObfuscator(fix.SensitiveTagNames, *secret),
+	}
+
+	if opts.Dictionary, err = loadCustomDictionary(*xmlPath); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if opts.Prefix, err = prefixParserFromOpts(*prefix); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	entries, ok := decoder.LoadBrowseEntries(fs.Args(), opts, errOut)
+	if len(entries) == 0 {
+		fmt.Fprintln(errOut, "No FIX messages found in", strings.Join(fs.Args(), ", "))
+		return 1
+	}
+
+	if err := runBrowser(decoder.NewBrowser(entries, theme), out); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if !ok {
+		return 1
+	}
+
+	return 0
+}
+
+// browseTerminal runs b full screen on the terminal attached to stdin until
+// the user quits.
+func browseTerminal(b *decoder.Browser, out io.Writer) error {
+	fd := int(os.Stdin.Fd())
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 256)
+
+	for !b.Done() {
+		width, height, err := term.GetSize(fd)
+		if err != nil {
+			width, height = 80, 24
+		}
+
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+strings.Join(b.Render(width, height), "\r\n"))
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range decoder.ParseKeys(buf[:n]) {
+			b.HandleKey(key)
+		}
+	}
+
+	return nil
+}
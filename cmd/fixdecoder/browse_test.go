@@ -0,0 +1,133 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+)
+
+func stubBrowseTerminal(t *testing.T, isTerminal bool, run func(*decoder.Browser, io.Writer) error) {
+	t.Helper()
+
+	origTerminal, origRun := stdinIsTerminal, runBrowser
+	stdinIsTerminal = func() bool { return isTerminal }
+	runBrowser = run
+
+	t.Cleanup(func() {
+		stdinIsTerminal, runBrowser = origTerminal, origRun
+	})
+}
+
+func TestProcessBrowse(t *testing.T) {
+	tmp, _ := os.CreateTemp("", "browse*.log")
+	defer os.Remove(tmp.Name())
+	_ = os.WriteFile(tmp.Name(), []byte("IN 8=FIX.4.4|9=5|35=0|10=000|\n"), 0644)
+
+	var seen int
+	stubBrowseTerminal(t, true, func(b *decoder.Browser, _ io.Writer) error {
+		if cur, ok := b.Current(); ok && cur.Message.MsgType == "0" && cur.Direction == "IN" {
+			seen++
+		}
+		return nil
+	})
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"browse", tmp.Name()}, &out, &errOut); code != 0 || seen != 1 {
+		t.Errorf("expected browser to run on the heartbeat, got code=%d seen=%d err=%q", code, seen, errOut.String())
+	}
+}
+
+func TestProcessBrowseSharesDecodeFlags(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "gateway.prefix")
+	_ = os.WriteFile(config, []byte("pattern = ^(?P<time>\\S+ \\S+) (?P<dir>RX|TX) $\ntime = 02/01/2006 15:04:05.000\nin = RX\nout = TX\n"), 0644)
+	log := filepath.Join(dir, "gateway.log")
+	_ = os.WriteFile(log, []byte("01/01/2025 09:00:00.250 RX "+fix44Line("35=0|49=A|56=B|34=1|52=20250101-09:00:00|")+"\n"), 0644)
+
+	var got *decoder.Browser
+	stubBrowseTerminal(t, true, func(b *decoder.Browser, _ io.Writer) error {
+		got = b
+		return nil
+	})
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"browse", "--prefix=" + config, "--colour=no", log}, &out, &errOut); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, errOut.String())
+	}
+	if cur, ok := got.Current(); !ok || cur.Direction != decoder.DirectionIn || got.Theme != decoder.NoTheme {
+		t.Errorf("expected the prefix config direction without colour, got %+v theme=%+v", cur, got.Theme)
+	}
+
+	if code := Process([]string{"browse", "--colour=yes", "--theme=light", log}, &out, &errOut); code != 0 || got.Theme == decoder.NoTheme || got.Theme == decoder.DarkTheme {
+		t.Errorf("expected the light theme, got code=%d theme=%+v", code, got.Theme)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if code := Process([]string{"browse", log}, &out, &errOut); code != 0 || got.Theme != decoder.NoTheme {
+		t.Errorf("expected NO_COLOR to turn colour off, got code=%d theme=%+v", code, got.Theme)
+	}
+}
+
+func TestProcessBrowseErrors(t *testing.T) {
+	empty, _ := os.CreateTemp("", "empty*.log")
+	defer os.Remove(empty.Name())
+
+	valid, _ := os.CreateTemp("", "valid*.log")
+	defer os.Remove(valid.Name())
+	_ = os.WriteFile(valid.Name(), []byte("8=FIX.4.4|9=5|35=0|10=000|\n"), 0644)
+
+	stubBrowseTerminal(t, true, func(*decoder.Browser, io.Writer) error { return errors.New("boom") })
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"browse"}, "Usage: fixdecoder browse"},
+		{[]string{"browse", empty.Name()}, "No FIX messages found"},
+		{[]string{"browse", valid.Name()}, "boom"},
+		{[]string{"browse", "--theme=nope", valid.Name()}, "nope"},
+		{[]string{"browse", "--prefix=nope", valid.Name()}, "unknown prefix parser"},
+		{[]string{"browse", "--xml=/path/does/not/exist", valid.Name()}, "no such file"},
+	}
+
+	for _, c := range cases {
+		var out, errOut strings.Builder
+		if code := Process(c.args, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), c.want) {
+			t.Errorf("%v: expected %q, got code=%d err=%q", c.args, c.want, code, errOut.String())
+		}
+	}
+
+	stubBrowseTerminal(t, false, nil)
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"browse", empty.Name()}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "interactive terminal") {
+		t.Errorf("expected terminal requirement, got code=%d err=%q", code, errOut.String())
+	}
+}
@@ -0,0 +1,95 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+// handleDictDiff implements "fixdecoder dict-diff": the differences between
+// two dictionaries, each an embedded FIX version or an XML file.
+func handleDictDiff(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("dict-diff", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	fromVersion := fs.String("fix", "44", "FIX version to compare from ("+fix.SupportedFixVersions()+")")
+	fromXML := fs.String("xml", "", "Path to the FIX XML file to compare from")
+	toVersion := fs.String("to-fix", "", "FIX version to compare to ("+fix.SupportedFixVersions()+")")
+	toXML := fs.String("to-xml", "", "Path to the FIX XML file to compare to")
+	format := fs.String("format", "text", "Output format: text or json")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *toVersion == "" && *toXML == "" {
+		fmt.Fprintln(errOut, "Usage: fixdecoder dict-diff [--fix=42 | --xml=OLD.xml] [--to-fix=44 | --to-xml=NEW.xml] [--format=text|json]")
+		return 1
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(errOut, "invalid value for -format: %q\n", *format)
+		return 1
+	}
+
+	from, err := loadSchemaFromOpts(CLIOptions{FixVersion: *fromVersion, XMLPath: *fromXML})
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	to, err := loadSchemaFromOpts(CLIOptions{FixVersion: *toVersion, XMLPath: *toXML})
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	d := decoder.DiffDictionaries(from, to)
+	d.From = dictionaryLabel(*fromVersion, *fromXML)
+	d.To = dictionaryLabel(*toVersion, *toXML)
+
+	if *format == "json" {
+		if err := decoder.WriteDictDiffJSON(out, d); err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+		return 0
+	}
+
+	decoder.WriteDictDiff(out, d)
+	return 0
+}
+
+// dictionaryLabel names a dictionary by its file, or by its FIX version
+// when it is embedded.
+func dictionaryLabel(version, xmlPath string) string {
+	if xmlPath != "" {
+		return xmlPath
+	}
+	return "FIX " + version
+}
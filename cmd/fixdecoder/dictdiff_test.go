@@ -0,0 +1,94 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessDictDiff(t *testing.T) {
+	var out, errOut strings.Builder
+	code := Process([]string{"dict-diff", "-fix=42", "-to-fix=44"}, &out, &errOut)
+
+	if code != 0 || !strings.HasPrefix(out.String(), "Dictionary diff: FIX 42 -> FIX 44\n") {
+		t.Fatalf("unexpected result code=%d out=%.200q err=%q", code, out.String(), errOut.String())
+	}
+	if !strings.Contains(out.String(), "  ~ D NewOrderSingle\n") {
+		t.Error("expected NewOrderSingle to have changed")
+	}
+}
+
+func TestProcessDictDiffXMLAsJSON(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "from.xml")
+	to := filepath.Join(dir, "to.xml")
+
+	const dict = `<fix major="4" minor="4"><fields>%s</fields></fix>`
+	if err := os.WriteFile(from, []byte(strings.Replace(dict, "%s", `<field number="1" name="Account" type="STRING"/>`, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(to, []byte(strings.Replace(dict, "%s", `<field number="1" name="Account" type="STRING"/><field number="2" name="AdvId" type="STRING"/>`, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"dict-diff", "-xml=" + from, "-to-xml=" + to, "-format=json"}, &out, &errOut); code != 0 {
+		t.Fatalf("code=%d err=%q", code, errOut.String())
+	}
+
+	var d struct {
+		From, To string
+		Fields   struct {
+			Added []struct{ Tag int }
+		}
+	}
+	if err := json.Unmarshal([]byte(out.String()), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.From != from || d.To != to || len(d.Fields.Added) != 1 || d.Fields.Added[0].Tag != 2 {
+		t.Errorf("unexpected diff %+v", d)
+	}
+}
+
+func TestProcessDictDiffErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"dict-diff", "-fix=42"}, "Usage: fixdecoder dict-diff"},
+		{[]string{"dict-diff", "-to-fix=44", "-format=csv"}, `invalid value for -format: "csv"`},
+		{[]string{"dict-diff", "-to-xml=/does/not/exist.xml"}, "no such file"},
+		{[]string{"dict-diff", "-xml=/does/not/exist.xml", "-to-fix=44"}, "no such file"},
+	}
+
+	for _, c := range cases {
+		var out, errOut strings.Builder
+		if code := Process(c.args, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), c.want) {
+			t.Errorf("%v: expected %q, got code=%d err=%q", c.args, c.want, code, errOut.String())
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+)
+
+// handleExecCheck implements "fixdecoder exec-check": consistency checks
+// across the execution reports of each order in the logs.
+func handleExecCheck(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("exec-check", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	format := fs.String("format", "text", "Output format: text or json")
+	xmlPath := fs.String("xml", "", "Path to alternative FIX XML file")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(errOut, "invalid value for -format: %q\n", *format)
+		return 1
+	}
+
+	dict, err := loadCustomDictionary(*xmlPath)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	issues, ok := decoder.CheckExecutionReports(extractFileArgsOrStdin(fs.Args()), dict, errOut)
+
+	if *format == "json" {
+		if err := decoder.WriteExecIssuesJSON(out, issues); err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+	} else {
+		decoder.WriteExecIssues(out, issues)
+	}
+
+	switch {
+	case !ok:
+		return 1
+	case len(issues) > 0:
+		return exitValidationFailed
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,80 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// execReportLine frames a FIX 4.4 execution report with body fields.
+func execReportLine(body string) string {
+	body = strings.ReplaceAll("35=8|49=BROKER|56=CLIENT|34=1|52=20250101-09:00:00|"+body, "|", "\x01")
+	msg := fmt.Sprintf("8=FIX.4.4\x019=%d\x01%s", len(body), body)
+	sum := 0
+	for i := 0; i < len(msg); i++ {
+		sum += int(msg[i])
+	}
+	return fmt.Sprintf("%s10=%03d\x01", msg, sum%256)
+}
+
+func TestProcessExecCheck(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.log")
+	_ = os.WriteFile(good, []byte(execReportLine("37=O1|11=C1|17=E1|150=F|39=2|55=IBM|54=1|38=100|32=100|31=10|14=100|151=0|6=10|")+"\n"), 0644)
+	bad := filepath.Join(dir, "bad.log")
+	_ = os.WriteFile(bad, []byte(execReportLine("37=O1|11=C1|17=E1|150=F|39=0|55=IBM|54=1|38=100|32=100|31=10|14=100|151=0|6=10|")+"\n"), 0644)
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"exec-check", good}, &out, &errOut); code != 0 || out.String() != "No execution report issues\n" {
+		t.Errorf("expected no issues, got code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+
+	out.Reset()
+	if code := Process([]string{"exec-check", bad}, &out, &errOut); code != exitValidationFailed || !strings.Contains(out.String(), "EXEC_STATE OrderID O1 at "+bad+" message 1 (offset 0, ExecID E1)") {
+		t.Errorf("expected a state issue, got code=%d out=%q", code, out.String())
+	}
+
+	out.Reset()
+	if code := Process([]string{"exec-check", "-format=json", bad}, &out, &errOut); code != exitValidationFailed {
+		t.Errorf("expected exit code %d, got %d", exitValidationFailed, code)
+	}
+	var issues []struct{ Code string }
+	if err := json.Unmarshal([]byte(out.String()), &issues); err != nil || len(issues) != 1 || issues[0].Code != "EXEC_STATE" {
+		t.Errorf("unexpected JSON %q (%v)", out.String(), err)
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"exec-check", "-format=yaml", bad}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "invalid value for -format") {
+		t.Errorf("expected a format error, got code=%d err=%q", code, errOut.String())
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"exec-check", filepath.Join(dir, "missing.log")}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "Cannot open file") {
+		t.Errorf("expected an input error, got code=%d err=%q", code, errOut.String())
+	}
+}
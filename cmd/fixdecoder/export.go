@@ -0,0 +1,90 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+// handleExport implements "fixdecoder export": the selected dictionary as
+// JSON, JSON Schema or Orchestra XML, or a Markdown or HTML reference.
+func handleExport(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	fixVersion := fs.String("fix", "44", "FIX version to use ("+fix.SupportedFixVersions()+")")
+	xmlPath := fs.String("xml", "", "Path to alternative FIX XML file")
+	format := fs.String("format", "json", "Export format: "+strings.Join(decoder.ExportFormats, ", "))
+	outPath := fs.String("out", "", "File to write (default stdout); the directory for markdown and html")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	schema, err := loadSchemaFromOpts(CLIOptions{FixVersion: *fixVersion, XMLPath: *xmlPath})
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if err := exportSchema(schema, *format, *outPath, out); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	return 0
+}
+
+func exportSchema(schema decoder.SchemaTree, format, outPath string, out io.Writer) error {
+	switch format {
+	case "markdown", "html":
+		if outPath == "" {
+			return fmt.Errorf("-out is required for %s: the directory to write the pages to", format)
+		}
+		return decoder.WriteReference(outPath, schema, format)
+	}
+
+	if outPath == "" {
+		return decoder.ExportSchema(out, schema, format)
+	}
+
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+
+	if err := decoder.ExportSchema(f, schema, format); err != nil {
+		f.Close()
+		os.Remove(outPath)
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,97 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessExportJSONToStdout(t *testing.T) {
+	var out, errOut strings.Builder
+	if code := Process([]string{"export", "-fix=42"}, &out, &errOut); code != 0 {
+		t.Fatalf("code=%d err=%q", code, errOut.String())
+	}
+
+	var doc struct{ Version string }
+	if err := json.Unmarshal([]byte(out.String()), &doc); err != nil || doc.Version != "4.2" {
+		t.Errorf("unexpected document version %q: %v", doc.Version, err)
+	}
+}
+
+func TestProcessExportOrchestraToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "FIX44-orchestra.xml")
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"export", "-format=orchestra", "-out=" + path}, &out, &errOut); code != 0 || out.Len() != 0 {
+		t.Fatalf("code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+
+	// The export is itself a dictionary -xml can load.
+	if code := Process([]string{"-xml=" + path, "-message=D", "-skeleton"}, &out, &errOut); code != 0 {
+		t.Errorf("expected the exported repository to load, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessExportReference(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ref")
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"export", "-format=html", "-out=" + dir}, &out, &errOut); code != 0 {
+		t.Fatalf("code=%d err=%q", code, errOut.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "ExecutionReport.html")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProcessExportErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.json")
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"export", "-format=markdown"}, "-out is required for markdown"},
+		{[]string{"export", "-format=pdf"}, `invalid export format: "pdf"`},
+		{[]string{"export", "-format=pdf", "-out=" + file}, `invalid export format: "pdf"`},
+		{[]string{"export", "-out=/does/not/exist/out.json"}, "no such file"},
+		{[]string{"export", "-xml=/does/not/exist.xml"}, "no such file"},
+		{[]string{"export", "-bogus"}, "flag provided but not defined"},
+	}
+
+	for _, c := range cases {
+		var out, errOut strings.Builder
+		if code := Process(c.args, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), c.want) {
+			t.Errorf("%v: expected %q, got code=%d err=%q", c.args, c.want, code, errOut.String())
+		}
+	}
+
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Error("expected a failed export to leave no file behind")
+	}
+}
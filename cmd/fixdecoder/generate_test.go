@@ -0,0 +1,67 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessGenerate(t *testing.T) {
+	var out, errOut strings.Builder
+	code := Process([]string{"generate", "-fix=42", "-message=D,Heartbeat", "-count=5", "-seed=9", "-invalid=1", "-pipe", "-sender=ME"}, &out, &errOut)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if code != 0 || len(lines) != 5 {
+		t.Fatalf("expected five messages, got code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "8=FIX.4.2|9=") || !strings.Contains(line, "|49=ME|") {
+			t.Errorf("unexpected message %q", line)
+		}
+	}
+
+	if strings.Count(errOut.String(), "message ") != 5 {
+		t.Errorf("expected a defect report per message, got %q", errOut.String())
+	}
+}
+
+func TestProcessGenerateErrors(t *testing.T) {
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"generate", "-invalid=2"}, "between 0 and 1"},
+		{[]string{"generate", "-message=Nope"}, "message not found: Nope"},
+		{[]string{"generate", "-xml=/does/not/exist.xml"}, "no such file"},
+		{[]string{"generate", "-bogus"}, "flag provided but not defined"},
+	}
+
+	for _, c := range cases {
+		var out, errOut strings.Builder
+		if code := Process(c.args, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), c.want) {
+			t.Errorf("%v: expected %q, got code=%d err=%q", c.args, c.want, code, errOut.String())
+		}
+	}
+}
@@ -24,6 +24,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"sort"
 	"strconv"
 
@@ -31,20 +32,29 @@ import (
 	"github.com/stephenlclarke/fixdecoder/fix"
 )
 
+// rendererFor returns a stdout renderer for the schema handlers using the
+// theme resolved in Process.
+func rendererFor(opts CLIOptions) *decoder.Renderer {
+	r := decoder.StdoutRenderer()
+	r.Theme = opts.theme
+	return r
+}
+
 // handleXML is triggered when the user supplied -xml=FILE.
 // It prints a short description of the external dictionary that has just
 // been loaded, then returns true so runHandlers knows a handler fired.
 func handleXML(opts CLIOptions, schema decoder.SchemaTree) bool {
-	// Not our turn if -xml wasn’t given.
-	if opts.XMLPath == "" {
+	// Not our turn if -xml wasn’t given, or if it is only the dictionary
+	// for the log files being decoded.
+	if opts.XMLPath == "" || len(opts.Files) > 0 {
 		return false
 	}
 
 	// Re-use the same “info” formatter the other handlers use so the look
 	// & feel stays identical.
-	fmt.Printf("Dictionary loaded from: %s%s%s\n\n", decoder.ColourError, opts.XMLPath, decoder.ColourReset)
+	fmt.Printf("Dictionary loaded from: %s\n\n", opts.theme.Paint(opts.theme.Error, opts.XMLPath))
 
-	decoder.PrintSchemaSummary(schema)
+	rendererFor(opts).PrintSchemaSummary(schema)
 
 	return true
 }
@@ -84,9 +94,9 @@ func handleMessage(opts CLIOptions, schema decoder.SchemaTree) bool {
 
 			sort.Strings(msgs)
 
-			decoder.PrintStringColumns(msgs)
+			rendererFor(opts).PrintStringColumns(msgs)
 		} else {
-			decoder.ListAllMessages(schema)
+			rendererFor(opts).ListAllMessages(schema)
 		}
 
 	case "": // explicit -message=
@@ -95,7 +105,15 @@ func handleMessage(opts CLIOptions, schema decoder.SchemaTree) bool {
 		// specific message
 		for _, m := range schema.Messages {
 			if m.Name == opts.Message.value || m.MsgType == opts.Message.value {
-				decoder.DisplayMessageStructureWithOptions(schema, m, opts.Verbose, opts.IncludeHeader, opts.IncludeTrailer, opts.ColumnOutput, 4)
+				if opts.Skeleton {
+					skeletonOpts := decoder.SkeletonOptions{Format: opts.Format, Optional: opts.Optional}
+					if err := decoder.WriteSkeleton(os.Stdout, schema, m, skeletonOpts); err != nil {
+						fmt.Println(err)
+					}
+					return true
+				}
+
+				rendererFor(opts).DisplayMessageStructureWithOptions(schema, m, opts.Verbose, opts.IncludeHeader, opts.IncludeTrailer, opts.ColumnOutput, 4)
 				return true
 			}
 		}
@@ -128,9 +146,9 @@ func handleTag(opts CLIOptions, schema decoder.SchemaTree) bool {
 
 func handleBareTag(opts CLIOptions, schema decoder.SchemaTree) {
 	if opts.ColumnOutput {
-		decoder.PrintTagsInColumns(schema)
+		rendererFor(opts).PrintTagsInColumns(schema)
 	} else {
-		decoder.ListAllTags(schema)
+		rendererFor(opts).ListAllTags(schema)
 	}
 }
 
@@ -147,7 +165,7 @@ func handleSpecificTag(opts CLIOptions, schema decoder.SchemaTree) {
 		return
 	}
 
-	decoder.PrintTagDetails(field, opts.Verbose, opts.ColumnOutput)
+	rendererFor(opts).PrintTagDetails(field, opts.Verbose, opts.ColumnOutput)
 }
 
 // handleComponent processes the -component flag. Returns true if handled.
@@ -176,9 +194,9 @@ func handleBareComponent(opts CLIOptions, schema decoder.SchemaTree) {
 		}
 
 		sort.Strings(names)
-		decoder.PrintStringColumns(names)
+		rendererFor(opts).PrintStringColumns(names)
 	} else {
-		decoder.ListAllComponents(schema)
+		rendererFor(opts).ListAllComponents(schema)
 	}
 }
 
@@ -186,7 +204,7 @@ func handleSpecificComponent(opts CLIOptions, schema decoder.SchemaTree) {
 	name := opts.Component.value
 
 	if comp, ok := schema.Components[name]; ok {
-		decoder.DisplayComponent(schema, decoder.MessageNode{}, comp, opts.Verbose, opts.ColumnOutput, 0)
+		rendererFor(opts).DisplayComponent(schema, decoder.MessageNode{}, comp, opts.Verbose, opts.ColumnOutput, 0)
 	} else {
 		fmt.Printf("Component not found: %s\n", name)
 	}
@@ -323,3 +323,53 @@ func TestHandleTagListAllTags(t *testing.T) {
 		t.Error("Expected tag listing in output")
 	}
 }
+
+func TestRendererForTheme(t *testing.T) {
+	if rendererFor(CLIOptions{}).Theme != decoder.NoTheme {
+		t.Error("expected schema output uncoloured without a resolved theme")
+	}
+
+	opts := CLIOptions{theme: decoder.DarkTheme}
+	if rendererFor(opts).Theme != decoder.DarkTheme {
+		t.Error("expected resolved theme to be used for schema output")
+	}
+}
+
+func TestHandleMessageSkeleton(t *testing.T) {
+	schema := fullSchema
+	schema.Fields = map[string]decoder.Field{
+		"BeginString": {Name: "BeginString", Number: 8, Type: "STRING"},
+		"MsgType":     {Name: "MsgType", Number: 35, Type: "STRING"},
+		"HeartBtInt":  {Name: "HeartBtInt", Number: 108, Type: "INT"},
+	}
+	schema.Components = map[string]decoder.ComponentNode{
+		"Header": {Fields: []decoder.FieldNode{
+			{Ref: decoder.FieldRef{Name: "BeginString", Required: "Y"}, Field: schema.Fields["BeginString"]},
+			{Ref: decoder.FieldRef{Name: "MsgType", Required: "Y"}, Field: schema.Fields["MsgType"]},
+		}},
+	}
+	logon := schema.Messages["Logon"]
+	logon.Fields = []decoder.FieldNode{{Ref: decoder.FieldRef{Name: "HeartBtInt", Required: "Y"}, Field: schema.Fields["HeartBtInt"]}}
+	schema.Messages = map[string]decoder.MessageNode{"Logon": logon}
+
+	out := captureOutput(func() {
+		handleMessage(CLIOptions{Message: messageFlag{isSet: true, value: "A"}, Skeleton: true, Format: "yaml"}, schema)
+	})
+	if !strings.Contains(out, "  MsgType: \"A\"\n") || !strings.Contains(out, "  HeartBtInt: \"1\"\n") {
+		t.Errorf("expected YAML skeleton, got:\n%s", out)
+	}
+
+	out = captureOutput(func() {
+		handleMessage(CLIOptions{Message: messageFlag{isSet: true, value: "A"}, Skeleton: true, Format: "fix"}, schema)
+	})
+	if !strings.HasPrefix(out, "8=FIX.4.4|9=11|35=A|108=1|10=") {
+		t.Errorf("expected FIX skeleton, got %q", out)
+	}
+
+	out = captureOutput(func() {
+		handleMessage(CLIOptions{Message: messageFlag{isSet: true, value: "A"}, Skeleton: true, Format: "toml"}, schema)
+	})
+	if !strings.Contains(out, "invalid skeleton format") {
+		t.Errorf("expected format error, got %q", out)
+	}
+}
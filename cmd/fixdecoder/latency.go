@@ -0,0 +1,89 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+)
+
+// handleLatency implements "fixdecoder latency": request to response
+// latency per session and request MsgType.
+func handleLatency(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("latency", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	clock := fs.String("clock", decoder.ClockAuto, "Timestamps to time pairs by: auto, sending (SendingTime) or log (log-line timestamps)")
+	format := fs.String("format", "text", "Output format: text or json")
+	prefix := fs.String("prefix", "auto", "Prefix parser ("+strings.Join(decoder.PrefixParserNames(), ",")+") or prefix config file for log-line timestamps")
+	xmlPath := fs.String("xml", "", "Path to alternative FIX XML file")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	switch *clock {
+	case decoder.ClockAuto, decoder.ClockSending, decoder.ClockLog:
+	default:
+		fmt.Fprintf(errOut, "invalid value for -clock: %q\n", *clock)
+		return 1
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(errOut, "invalid value for -format: %q\n", *format)
+		return 1
+	}
+
+	var opts decoder.DecodeOptions
+	var err error
+
+	if opts.Dictionary, err = loadCustomDictionary(*xmlPath); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if opts.Prefix, err = prefixParserFromOpts(*prefix); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	stats, ok := decoder.AnalyzeLatency(extractFileArgsOrStdin(fs.Args()), *clock, opts, errOut)
+
+	if *format == "json" {
+		if err := decoder.WriteLatencyJSON(out, stats); err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+	} else {
+		decoder.WriteLatency(out, stats)
+	}
+
+	if !ok {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fix44Line frames a FIX 4.4 message from '|' delimited fields after 9.
+func fix44Line(body string) string {
+	body = strings.ReplaceAll(body, "|", "\x01")
+	msg := fmt.Sprintf("8=FIX.4.4\x019=%d\x01%s", len(body), body)
+	sum := 0
+	for i := 0; i < len(msg); i++ {
+		sum += int(msg[i])
+	}
+	return fmt.Sprintf("%s10=%03d\x01", msg, sum%256)
+}
+
+func TestProcessLatency(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "session.log")
+	_ = os.WriteFile(log, []byte(
+		"2025-01-01 09:00:00.000 OUT "+fix44Line("35=V|49=C|56=V|34=1|52=20250101-09:00:00.000|262=MD1|")+"\n"+
+			"2025-01-01 09:00:00.020 IN "+fix44Line("35=W|49=V|56=C|34=1|52=20250101-09:00:00.010|262=MD1|")+"\n"), 0644)
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"latency", log}, &out, &errOut); code != 0 || !strings.Contains(out.String(), "C->V V (MarketDataRequest): 1 answered, 0 unanswered\n  min 20ms") {
+		t.Errorf("expected log-line latency, got code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+
+	out.Reset()
+	if code := Process([]string{"latency", "-clock=sending", "-format=json", log}, &out, &errOut); code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	var stats []struct{ MinMs float64 }
+	if err := json.Unmarshal([]byte(out.String()), &stats); err != nil || len(stats) != 1 || stats[0].MinMs != 10 {
+		t.Errorf("expected a SendingTime latency of 10ms, got %q (%v)", out.String(), err)
+	}
+
+	out.Reset()
+	if code := Process([]string{"latency", "-prefix=quickfix", log}, &out, &errOut); code != 0 || !strings.Contains(out.String(), "min 10ms") {
+		t.Errorf("expected SendingTime when the prefix parser finds no time, got code=%d out=%q", code, out.String())
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"latency", "-clock=wall", log}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "invalid value for -clock") {
+		t.Errorf("expected a clock error, got code=%d err=%q", code, errOut.String())
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/stephenlclarke/fixdecoder/decoder"
 	"github.com/stephenlclarke/fixdecoder/fix"
@@ -113,12 +114,36 @@ type CLIOptions struct {
 	IncludeTrailer bool
 	ColumnOutput   bool
 	Message        messageFlag
+	Skeleton       bool
+	Format         string
+	Optional       bool
 	Tag            tagFlag
 	Info           bool
 	Validate       bool
 	Colour         colourFlag
+	Theme          string
+	Output         string
+	Layout         string
+	Expand         bool
+	Columns        string
+	EnumDesc       bool
+	Group          string
 	Secret         bool
+	Scrub          bool
+	ScrubRules     string
+	Rules          string
+	Profiles       string
+	Profile        string
+	FailOn         string
+	Report         string
+	ReportFormat   string
+	Reject         bool
+	Prefix         string
+	Merge          bool
+	MergeWindow    time.Duration
 	Version        bool
+	Files          []string      // positional arguments, in order
+	theme          decoder.Theme // resolved from -theme, -colour and the environment
 }
 
 // validateXMLFlag ensures the user supplied --xml=FILE syntax is correct.
@@ -136,11 +161,33 @@ func parseFlagsArgs(args []string) CLIOptions {
 	includeHeader := fs.Bool("header", false, "Include Header block")
 	info := fs.Bool("info", false, "Show XML schema summary (fields, components, messages, version counts)")
 	secret := fs.Bool("secret", false, "Obfuscate sensitive FIX tag values")
+	scrub := fs.Bool("scrub", false, "Scrub IPs, host names and emails from the non-FIX text of each line")
+	scrubRules := fs.String("scrub-rules", "", "Path to NAME=REGEX scrub rules (implies -scrub)")
 	includeTrailer := fs.Bool("trailer", false, "Include Trailer block")
 	validate := fs.Bool("validate", false, "Validate FIX messages during decoding")
+	rules := fs.String("rules", "", "Path to conditional-requirement rules checked by validation (implies -validate)")
+	profiles := fs.String("profiles", "", "Profile file or directory of *.profile files, selected by CompID (implies -validate)")
+	profile := fs.String("profile", "", "Validate every message against this profile name, or profile file (implies -validate)")
+	failOn := fs.String("fail-on", "error", "Comma-separated finding codes and severities that fail the run with exit code 2 (none to never fail)")
+	report := fs.String("report", "", "Write validation findings to FILE (implies -validate)")
+	reportFormat := fs.String("report-format", "json", "Validation report format (json|junit)")
+	prefix := fs.String("prefix", "", "Show each message's time, direction and session read from its log line with this prefix parser ("+strings.Join(decoder.PrefixParserNames(), ",")+") or prefix config file")
+	merge := fs.Bool("merge", false, "Decode all files as one timeline in timestamp order, each line tagged with its file")
+	mergeWindow := fs.Duration("merge-window", 5*time.Second, "How far out of timestamp order -merge inputs may be")
+	reject := fs.Bool("reject", false, "Show the Reject or BusinessMessageReject a counterparty would reply with (implies -validate)")
 	verbose := fs.Bool("verbose", false, "Show full message structure with enums")
 	xmlPath := fs.String("xml", "", "Path to alternative FIX XML file")
+	skeleton := fs.Bool("skeleton", false, "With -message=MSG, print a template message to fill in")
+	format := fs.String("format", "fix", "Template format for -skeleton (fix|json|yaml)")
+	optional := fs.Bool("optional", false, "Include optional fields in -skeleton templates")
 	showVersion := fs.Bool("version", false, "Print version information and exit")
+	output := fs.String("output", "terminal", "Decoded log output format (terminal|html|csv|tsv)")
+	layout := fs.String("layout", "lines", "Terminal layout for decoded fields (lines|wide)")
+	expand := fs.Bool("expand", false, "Show long values in full with -layout=wide")
+	columns := fs.String("columns", strings.Join(decoder.DefaultCSVColumns, ","), "Comma-separated tags or field names for csv/tsv output")
+	enumDesc := fs.Bool("enum-desc", false, "Write enum descriptions instead of raw values in csv/tsv output")
+	group := fs.String("group", "", "Write one csv/tsv row per instance of this repeating group (NumInGroup tag or name)")
+	theme := fs.String("theme", "", "Colour theme ("+strings.Join(decoder.ThemeNames(), ",")+") or path to a theme file")
 
 	fs.Var(&colour, "colour", "Force coloured output (yes|no). Default: auto-detect based on stdout")
 	fs.Var(&component, "component", "Component to display (omit to list all components)")
@@ -156,6 +203,14 @@ func parseFlagsArgs(args []string) CLIOptions {
 
 	fs.Parse(args)
 
+	// The flag package stops at the first positional argument; keep going so
+	// flags may follow file names, and so "-flag value" is never taken as a file.
+	var files []string
+	for rest := fs.Args(); len(rest) > 0; rest = fs.Args() {
+		files = append(files, rest[0])
+		fs.Parse(rest[1:])
+	}
+
 	return CLIOptions{
 		Colour:         colour,
 		ColumnOutput:   *columnOutput,
@@ -165,8 +220,31 @@ func parseFlagsArgs(args []string) CLIOptions {
 		IncludeTrailer: *includeTrailer,
 		Info:           *info,
 		Message:        message,
+		Skeleton:       *skeleton,
+		Format:         *format,
+		Optional:       *optional,
 		Secret:         *secret,
+		Scrub:          *scrub,
+		ScrubRules:     *scrubRules,
+		Rules:          *rules,
+		Profiles:       *profiles,
+		Profile:        *profile,
+		FailOn:         *failOn,
+		Report:         *report,
+		ReportFormat:   *reportFormat,
+		Reject:         *reject,
+		Prefix:         *prefix,
+		Merge:          *merge,
+		MergeWindow:    *mergeWindow,
+		Output:         *output,
+		Layout:         *layout,
+		Expand:         *expand,
+		Columns:        *columns,
+		EnumDesc:       *enumDesc,
+		Group:          *group,
+		Files:          files,
 		Tag:            tag,
+		Theme:          *theme,
 		Validate:       *validate,
 		Verbose:        *verbose,
 		XMLPath:        *xmlPath,
@@ -183,10 +261,18 @@ func printVersion() {
 func PrintUsage() {
 	printVersion()
 	fmt.Println("Usage: fixdecoder [[--fix=44] | [--xml=FIX44.xml]] [--message[=MSG] [--verbose] [--column] [--header] [--trailer]]")
+	fmt.Println("       fixdecoder [[--fix=44] | [--xml=FIX44.xml]] --message=MSG --skeleton [--format=fix|json|yaml] [--optional]")
 	fmt.Println("       fixdecoder [[--fix=44] | [--xml=FIX44.xml]] [--tag[=TAG] [--verbose] [--column]]")
 	fmt.Println("       fixdecoder [[--fix=44] | [--xml=FIX44.xml]] [--component=[NAME] [--verbose]]")
 	fmt.Println("       fixdecoder [[--fix=44] | [--xml=FIX44.xml]] [--info]")
-	fmt.Println("       fixdecoder [--validate] [--colour=yes|no] [--secret] [file1.log file2.log ...]")
+	fmt.Println("       fixdecoder [--xml=FIX44.xml] [--validate] [--rules=FILE] [--profiles=DIR] [--profile=NAME|FILE] [--fail-on=CODES] [--report=FILE [--report-format=json|junit]] [--reject] [--colour=yes|no] [--theme=NAME|FILE] [--prefix=NAME|FILE] [--merge [--merge-window=5s]] [--layout=lines|wide [--expand]] [--output=terminal|html|csv|tsv [--columns=A,B,...] [--enum-desc] [--group=NAME]] [--secret] [--scrub] [--scrub-rules=FILE] [file1.log file2.log ...]")
+	fmt.Println("       fixdecoder browse [--xml=FIX44.xml] [--prefix=NAME|FILE] [--colour=yes|no] [--theme=NAME|FILE] [--secret] FILE...")
+	fmt.Println("       fixdecoder serve [--addr=localhost:8080]")
+	fmt.Println("       fixdecoder generate [--fix=44] [--message=D,8] [--count=10] [--seed=1] [--invalid=RATE] [--pipe]")
+	fmt.Println("       fixdecoder dict-diff [--fix=42 | --xml=OLD.xml] [--to-fix=44 | --to-xml=NEW.xml] [--format=text|json]")
+	fmt.Println("       fixdecoder exec-check [--xml=FIX44.xml] [--format=text|json] FILE...")
+	fmt.Println("       fixdecoder latency [--xml=FIX44.xml] [--clock=auto|sending|log] [--prefix=NAME|FILE] [--format=text|json] FILE...")
+	fmt.Println("       fixdecoder export [--fix=44] [--xml=FIX44.xml] [--format=json|jsonschema|orchestra|markdown|html] [--out=PATH]")
 	fmt.Println("       fixdecoder [--version]")
 }
 
@@ -197,14 +283,65 @@ func loadSchema(path string) (decoder.SchemaTree, error) {
 		return decoder.SchemaTree{}, err
 	}
 
-	var dict decoder.FixDictionary
-	if err := xml.Unmarshal(data, &dict); err != nil {
+	dict, err := decoder.ParseFixDictionary(data)
+	if err != nil {
 		return decoder.SchemaTree{}, err
 	}
 
 	return decoder.BuildSchema(dict), nil
 }
 
+// loadCustomDictionary reads the -xml dictionary the log files are decoded
+// with, or returns nil for the embedded dictionaries when path is empty.
+func loadCustomDictionary(path string) (*decoder.CustomDictionary, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return decoder.NewCustomDictionary(string(data))
+}
+
+// loadRules reads the -rules file checked alongside the standard rules of
+// each dictionary, or returns none when path is empty.
+func loadRules(path string) ([]decoder.Rule, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	rules, err := decoder.LoadRuleFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// loadProfiles loads the -profiles validation profiles. -profile forces one
+// of them by name or, without -profiles, names a profile file.
+func loadProfiles(path, name string) (*decoder.ProfileSet, error) {
+	var list []decoder.Profile
+	var err error
+
+	switch {
+	case path != "":
+		list, err = decoder.LoadProfiles(path)
+	case name != "":
+		var p decoder.Profile
+		p, err = decoder.LoadProfile(name)
+		list, name = []decoder.Profile{p}, p.Name
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profiles: %w", err)
+	}
+
+	return decoder.NewProfileSet(list, name)
+}
+
 // extractFileArgsOrStdin returns all CLI elements that represent filenames
 // (i.e. arguments that do NOT begin with '-').
 // If the user supplied no such arguments, it returns []{"-"}, which
@@ -222,8 +359,26 @@ func extractFileArgsOrStdin(args []string) []string {
 	return files
 }
 
+// subcommands are dispatched on the first argument; anything else is
+// treated as flags and log files.
+var subcommands = map[string]func(args []string, out, errOut io.Writer) int{
+	"browse":     handleBrowse,
+	"dict-diff":  handleDictDiff,
+	"exec-check": handleExecCheck,
+	"export":     handleExport,
+	"generate":   handleGenerate,
+	"latency":    handleLatency,
+	"serve":      handleServe,
+}
+
 // Process is the entry point: parses flags, loads a schema, runs handlers, and returns an exit code.
 func Process(args []string, out, errOut io.Writer) int {
+	if len(args) > 0 {
+		if cmd, ok := subcommands[args[0]]; ok {
+			return cmd(args[1:], out, errOut)
+		}
+	}
+
 	opts := parseFlagsArgs(args)
 
 	if opts.Version {
@@ -231,7 +386,27 @@ func Process(args []string, out, errOut io.Writer) int {
 		return 0
 	}
 
-	decoder.SetValidation(opts.Validate)
+	validate := opts.Validate || opts.Rules != "" || opts.Profiles != "" || opts.Profile != "" || opts.Report != "" || opts.Reject
+	logOpts := decoder.LogOptions{
+		DecodeOptions: decoder.DecodeOptions{Validate: validate, Rejects: opts.Reject},
+		Expand:        opts.Expand,
+	}
+
+	switch opts.Layout {
+	case "", "lines":
+	case "wide":
+		logOpts.Wide = true
+	default:
+		fmt.Fprintf(errOut, "invalid value for -layout: %q\n", opts.Layout)
+		return 1
+	}
+
+	theme, err := selectTheme(opts.Theme, opts.Colour)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	opts.theme = theme
 
 	schema, err := loadSchemaFromOpts(opts)
 	if err != nil {
@@ -243,18 +418,182 @@ func Process(args []string, out, errOut io.Writer) int {
 		return 0
 	}
 
-	if !opts.Colour.isSet {
-		if !term.IsTerminal(int(os.Stdout.Fd())) {
-			decoder.DisableColours()
+	logOpts.Obfuscator = fix.CreateObfuscator(fix.SensitiveTagNames, opts.Secret)
+
+	scrubber, err := scrubberFromOpts(opts)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	logOpts.Obfuscator.SetScrubber(scrubber)
+
+	if logOpts.Prefix, err = prefixParserFromOpts(opts.Prefix); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if logOpts.Dictionary, err = loadCustomDictionary(opts.XMLPath); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if logOpts.Rules, err = loadRules(opts.Rules); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if logOpts.Profiles, err = loadProfiles(opts.Profiles, opts.Profile); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	if logOpts.Report, err = reportFromOpts(opts, validate); err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	files := extractFileArgsOrStdin(opts.Files)
+
+	if opts.Merge && opts.Output != "" && opts.Output != "terminal" {
+		fmt.Fprintf(errOut, "-merge needs -output=terminal, not %q\n", opts.Output)
+		return 1
+	}
+
+	var code int
+	switch opts.Output {
+	case "", "terminal":
+		if opts.Merge {
+			code = decoder.MergeFiles(files, decoder.WithTheme(out, opts.theme), decoder.WithTheme(errOut, opts.theme), logOpts, opts.MergeWindow)
+			break
+		}
+		code = decoder.PrettifyFiles(files, decoder.WithTheme(out, opts.theme), decoder.WithTheme(errOut, opts.theme), logOpts)
+	case "html":
+		code = decoder.WriteHTMLReport(files, out, errOut, logOpts)
+	case "csv", "tsv":
+		csvOpts := csvOptionsFrom(opts)
+		csvOpts.Dictionary = logOpts.Dictionary
+		code = decoder.WriteCSV(files, out, errOut, logOpts.Obfuscator, csvOpts)
+	default:
+		fmt.Fprintf(errOut, "invalid value for -output: %q\n", opts.Output)
+		return 1
+	}
+
+	return finishReport(logOpts.Report, opts, code, errOut)
+}
+
+// exitValidationFailed is returned when validation finds failing findings,
+// or exec-check finds issues.
+const exitValidationFailed = 2
+
+// reportFromOpts returns the report validation findings are collected
+// into, or nil when validation is off.
+func reportFromOpts(opts CLIOptions, validate bool) (*decoder.Report, error) {
+	switch opts.ReportFormat {
+	case "", "json", "junit":
+	default:
+		return nil, fmt.Errorf("invalid value for -report-format: %q", opts.ReportFormat)
+	}
+
+	failOn, err := decoder.ParseFailOn(opts.FailOn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for -fail-on: %w", err)
+	}
+
+	if !validate {
+		return nil, nil
+	}
+	return decoder.NewReport(failOn), nil
+}
+
+// finishReport writes the -report file and turns failing findings into
+// exitValidationFailed. Input errors (code != 0) take precedence.
+func finishReport(report *decoder.Report, opts CLIOptions, code int, errOut io.Writer) int {
+	if report == nil {
+		return code
+	}
+
+	if opts.Report != "" {
+		if err := writeReport(report, opts.Report, opts.ReportFormat); err != nil {
+			fmt.Fprintf(errOut, "failed to write report: %v\n", err)
+			return 1
 		}
-	} else if !opts.Colour.value {
-		decoder.DisableColours()
 	}
 
-	obfuscator := fix.CreateObfuscator(fix.SensitiveTagNames, opts.Secret)
+	failing := report.Failing()
+	if code != 0 || len(failing) == 0 {
+		return code
+	}
+
+	fmt.Fprintf(errOut, "%d failing validation finding(s) in %d message(s)\n", len(failing), report.Messages())
+	return exitValidationFailed
+}
+
+func writeReport(report *decoder.Report, path, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := report.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// csvOptionsFrom maps the csv/tsv related flags onto decoder.CSVOptions.
+func csvOptionsFrom(opts CLIOptions) decoder.CSVOptions {
+	csvOpts := decoder.CSVOptions{
+		Columns:      strings.Split(opts.Columns, ","),
+		Delimiter:    ',',
+		Descriptions: opts.EnumDesc,
+		Group:        opts.Group,
+	}
+
+	if opts.Output == "tsv" {
+		csvOpts.Delimiter = '\t'
+	}
+
+	return csvOpts
+}
+
+// selectTheme resolves the -theme flag and applies -colour, the colour
+// environment variables and terminal detection to it.
+func selectTheme(name string, colour colourFlag) (decoder.Theme, error) {
+	theme, err := decoder.ResolveTheme(name)
+	if err != nil {
+		return theme, err
+	}
+
+	return decoder.SelectTheme(theme, colour.isSet, colour.value, term.IsTerminal(int(os.Stdout.Fd()))), nil
+}
+
+// prefixParserFromOpts resolves -prefix, returning nil when it is unset.
+func prefixParserFromOpts(spec string) (decoder.PrefixParser, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	return decoder.ResolvePrefixParser(spec)
+}
+
+// scrubberFromOpts builds the free-text scrubber requested by -scrub and
+// -scrub-rules, or returns nil when scrubbing is off.
+func scrubberFromOpts(opts CLIOptions) (*fix.Scrubber, error) {
+	if !opts.Scrub && opts.ScrubRules == "" {
+		return nil, nil
+	}
+
+	rules := fix.DefaultScrubRules()
+
+	if opts.ScrubRules != "" {
+		custom, err := fix.LoadScrubRules(opts.ScrubRules)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load scrub rules: %w", err)
+		}
+		rules = append(custom, rules...)
+	}
 
-	files := extractFileArgsOrStdin(args)
-	return decoder.PrettifyFiles(files, out, errOut, obfuscator)
+	return fix.CreateScrubber(rules), nil
 }
 
 // loadSchemaFromOpts picks between an explicit XML file or an embedded schema.
@@ -25,6 +25,7 @@ package main
 import (
 	"encoding/xml"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -284,3 +285,297 @@ func TestLoadSchemaFromOptsXMLUnmarshalError(t *testing.T) {
 		t.Errorf("Expected XML syntax error, got: %v", err)
 	}
 }
+
+func TestScrubberFromOpts(t *testing.T) {
+	if s, err := scrubberFromOpts(CLIOptions{}); s != nil || err != nil {
+		t.Fatalf("expected no scrubber by default, got %v, %v", s, err)
+	}
+
+	s, err := scrubberFromOpts(CLIOptions{Scrub: true})
+	if err != nil || s == nil {
+		t.Fatalf("expected default scrubber, got %v, %v", s, err)
+	}
+	if got := s.Scrub("from 10.0.0.1", nil); got != "from IP0001" {
+		t.Errorf("expected default rules to apply, got %q", got)
+	}
+
+	tmp, _ := os.CreateTemp("", "rules*.txt")
+	defer os.Remove(tmp.Name())
+	_ = os.WriteFile(tmp.Name(), []byte("DESK=desk-[0-9]+\n"), 0644)
+
+	s, err = scrubberFromOpts(CLIOptions{ScrubRules: tmp.Name()})
+	if err != nil || s == nil {
+		t.Fatalf("expected custom scrubber, got %v, %v", s, err)
+	}
+	if got := s.Scrub("desk-7 at 10.0.0.1", nil); got != "DESK0001 at IP0001" {
+		t.Errorf("expected custom and default rules to apply, got %q", got)
+	}
+
+	if _, err := scrubberFromOpts(CLIOptions{ScrubRules: "nonexistent.rules"}); err == nil {
+		t.Error("expected error for missing rules file")
+	}
+}
+
+func TestProcessScrubRulesError(t *testing.T) {
+	var out, errOut strings.Builder
+	code := Process([]string{defaultFixFlag, "-scrub-rules=nonexistent.rules"}, &out, &errOut)
+	if code != 1 || !strings.Contains(errOut.String(), "failed to load scrub rules") {
+		t.Errorf("expected scrub rules failure, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessUnknownTheme(t *testing.T) {
+	var out, errOut strings.Builder
+	code := Process([]string{defaultFixFlag, "-theme=no-such-theme"}, &out, &errOut)
+	if code != 1 || !strings.Contains(errOut.String(), "unknown theme") {
+		t.Errorf("expected unknown theme failure, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessForcedColourUsesTheme(t *testing.T) {
+	tmp, _ := os.CreateTemp("", "theme*.log")
+	defer os.Remove(tmp.Name())
+	_ = os.WriteFile(tmp.Name(), []byte("plain line\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{defaultFixFlag, "-colour=yes", "-theme=16-colour", tmp.Name()}, &out, &errOut)
+	if code != 0 || !strings.Contains(out.String(), "\033[90mplain line") {
+		t.Errorf("expected 16-colour output, got code=%d out=%q", code, out.String())
+	}
+
+	out.Reset()
+	code = Process([]string{defaultFixFlag, "-colour=no", tmp.Name()}, &out, &errOut)
+	if code != 0 || strings.Contains(out.String(), "\033[") {
+		t.Errorf("expected plain output with -colour=no, got %q", out.String())
+	}
+}
+
+func TestProcessHTMLOutput(t *testing.T) {
+	tmp, _ := os.CreateTemp("", "html*.log")
+	defer os.Remove(tmp.Name())
+	_ = os.WriteFile(tmp.Name(), []byte("IN 8=FIX.4.4|9=5|35=0|10=000|\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{defaultFixFlag, "-output=html", tmp.Name()}, &out, &errOut)
+	if code != 0 || !strings.Contains(out.String(), "<!DOCTYPE html>") || !strings.Contains(out.String(), "Heartbeat") {
+		t.Errorf("expected HTML report, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessInvalidOutput(t *testing.T) {
+	var out, errOut strings.Builder
+	code := Process([]string{defaultFixFlag, "-output=pdf"}, &out, &errOut)
+	if code != 1 || !strings.Contains(errOut.String(), "invalid value for -output") {
+		t.Errorf("expected invalid output failure, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessTSVOutputWithSpacedFlags(t *testing.T) {
+	tmp, _ := os.CreateTemp("", "tsv*.log")
+	defer os.Remove(tmp.Name())
+	_ = os.WriteFile(tmp.Name(), []byte("IN 8=FIX.4.4|9=5|35=0|34=7|10=000|\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{defaultFixFlag, tmp.Name(), "-output", "tsv", "-columns", "MsgSeqNum,35", "-enum-desc"}, &out, &errOut)
+	if code != 0 || out.String() != "MsgSeqNum\tMsgType\n7\tHeartbeat\n" {
+		t.Errorf("unexpected tsv output code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+}
+
+func TestParseFlagsArgsCollectsFilesBetweenFlags(t *testing.T) {
+	opts := parseFlagsArgs([]string{"a.log", "-group", "NoPartyIDs", "b.log", "-validate"})
+	if len(opts.Files) != 2 || opts.Files[0] != "a.log" || opts.Files[1] != "b.log" || opts.Group != "NoPartyIDs" || !opts.Validate {
+		t.Errorf("unexpected options %+v", opts)
+	}
+}
+
+func TestProcessWideLayout(t *testing.T) {
+	tmp, _ := os.CreateTemp("", "wide*.log")
+	defer os.Remove(tmp.Name())
+	_ = os.WriteFile(tmp.Name(), []byte("IN 8=FIX.4.4|9=5|35=0|10=000|\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{defaultFixFlag, "-colour=no", "-layout=wide", tmp.Name()}, &out, &errOut)
+	if code != 0 || !strings.Contains(out.String(), "MsgType") || strings.Contains(out.String(), "(MsgType)") {
+		t.Errorf("expected wide layout, got code=%d out=%q", code, out.String())
+	}
+
+	code = Process([]string{defaultFixFlag, "-layout=grid"}, &out, &errOut)
+	if code != 1 || !strings.Contains(errOut.String(), "invalid value for -layout") {
+		t.Errorf("expected invalid layout failure, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessDecodesWithOrchestraDictionary(t *testing.T) {
+	dir := t.TempDir()
+	dict := filepath.Join(dir, "venue.xml")
+	_ = os.WriteFile(dict, []byte(`<fixr:repository xmlns:fixr="http://fixprotocol.io/2020/orchestra/repository" version="FIX.4.4">
+ <fixr:fields><fixr:field id="5001" name="VenueTag" type="String"/></fixr:fields>
+</fixr:repository>`), 0644)
+	log := filepath.Join(dir, "venue.log")
+	_ = os.WriteFile(log, []byte("IN 8=FIX.4.4|9=5|35=0|5001=X|10=000|\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{"-xml=" + dict, "-colour=no", log}, &out, &errOut)
+	if code != 0 || !strings.Contains(out.String(), "VenueTag") || !strings.Contains(out.String(), "Heartbeat") {
+		t.Errorf("expected the custom field name, got code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+	if strings.Contains(out.String(), "Dictionary loaded from") {
+		t.Error("expected no dictionary summary when decoding logs")
+	}
+
+	code = Process([]string{defaultFixFlag, "-colour=no", log}, &out, &errOut)
+	if code != 0 || strings.Count(out.String(), "VenueTag") != 1 {
+		t.Errorf("expected the embedded dictionaries without -xml, got %q", out.String())
+	}
+}
+
+func TestProcessValidatesWithRuleFile(t *testing.T) {
+	dir := t.TempDir()
+	rules := filepath.Join(dir, "venue.rules")
+	_ = os.WriteFile(rules, []byte("# venue\nVENUE_ACCOUNT = D Account required when Side == 1\n"), 0644)
+	log := filepath.Join(dir, "orders.log")
+	_ = os.WriteFile(log, []byte("IN 8=FIX.4.4|9=5|35=D|11=1|54=1|40=1|10=000|\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{"-rules=" + rules, "-colour=no", log}, &out, &errOut)
+	if code != exitValidationFailed || !strings.Contains(out.String(), "[VENUE_ACCOUNT] Missing conditionally required tag 1 (Account) when Side == 1") {
+		t.Errorf("expected the rule to fire, got code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+
+	bad := filepath.Join(dir, "bad.rules")
+	_ = os.WriteFile(bad, []byte("BROKEN\n"), 0644)
+	errOut.Reset()
+	if code := Process([]string{"-rules=" + bad, log}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "failed to load rules") {
+		t.Errorf("expected a rules error, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessWritesValidationReport(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "session.log")
+	_ = os.WriteFile(log, []byte("noise\nIN 8=FIX.4.4|9=5|35=0|10=000|\n"), 0644)
+	report := filepath.Join(dir, "report.json")
+
+	var out, errOut strings.Builder
+	code := Process([]string{"-report=" + report, "-colour=no", log}, &out, &errOut)
+	if code != exitValidationFailed || !strings.Contains(errOut.String(), "1 failing validation finding(s) in 1 message(s)") {
+		t.Fatalf("expected failing findings, got code=%d err=%q", code, errOut.String())
+	}
+
+	data, _ := os.ReadFile(report)
+	if !strings.Contains(string(data), `"code": "CHECKSUM"`) || !strings.Contains(string(data), `"offset": 9`) {
+		t.Errorf("unexpected JSON report %s", data)
+	}
+
+	junit := filepath.Join(dir, "report.xml")
+	errOut.Reset()
+	code = Process([]string{"-report=" + junit, "-report-format=junit", "-fail-on=none", "-colour=no", log}, &out, &errOut)
+	if code != 0 {
+		t.Errorf("expected -fail-on=none to pass, got code=%d err=%q", code, errOut.String())
+	}
+	if data, _ := os.ReadFile(junit); !strings.Contains(string(data), `<testsuite name="`+log+`" tests="1" failures="0">`) {
+		t.Errorf("unexpected JUnit report %s", data)
+	}
+
+	if code := Process([]string{"-validate", "-fail-on=warning", "-colour=no", log}, &out, &errOut); code != 0 {
+		t.Errorf("expected a checksum error not to fail on warnings, got %d", code)
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"-validate", "-fail-on=BOGUS", log}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "invalid value for -fail-on") {
+		t.Errorf("expected a -fail-on error, got code=%d err=%q", code, errOut.String())
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"-validate", "-report-format=xml", log}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "invalid value for -report-format") {
+		t.Errorf("expected a -report-format error, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessValidatesWithProfiles(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "venue.profile"), []byte("sender = VENUE\nenum OrdType = 2\n"), 0644)
+	log := filepath.Join(dir, "orders.log")
+	_ = os.WriteFile(log, []byte("IN 8=FIX.4.4|9=5|35=D|49=VENUE|56=ME|11=1|54=1|40=1|10=000|\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{"-profiles=" + dir, "-colour=no", log}, &out, &errOut)
+	if code != exitValidationFailed || !strings.Contains(out.String(), "[venue] Value '1' not allowed for tag 40 (OrdType)") {
+		t.Errorf("expected the profile selected by CompID, got code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+
+	out.Reset()
+	code = Process([]string{"-profile=" + filepath.Join(dir, "venue.profile"), "-colour=no", log}, &out, &errOut)
+	if code != exitValidationFailed || !strings.Contains(out.String(), "[venue] Value '1'") {
+		t.Errorf("expected the profile file to apply, got code=%d out=%q", code, out.String())
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"-profiles=" + dir, "-profile=other", log}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), `unknown profile "other"`) {
+		t.Errorf("expected an unknown profile error, got code=%d err=%q", code, errOut.String())
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"-profiles=" + filepath.Join(dir, "missing"), log}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "failed to load profiles") {
+		t.Errorf("expected a load error, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessShowsReject(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "session.log")
+	_ = os.WriteFile(log, []byte("8=FIX.4.4|9=109|35=D|49=CLIENT|56=BROKER|34=7|52=20240101-00:00:00|11=ORD1|21=1|55=IBM|54=Z|60=20240101-00:00:00|38=100|40=1|10=197|\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{"-reject", "-colour=no", log}, &out, &errOut)
+	if code != exitValidationFailed || !strings.Contains(out.String(), "== Reply: 8=FIX.4.4|9=116|35=3|49=BROKER|56=CLIENT|") {
+		t.Errorf("expected -reject to validate and show the reply, got code=%d out=%q", code, out.String())
+	}
+}
+
+func TestProcessValidatesSecretMessagesAsLogged(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "session.log")
+	_ = os.WriteFile(log, []byte("IN "+fix44Line("35=0|49=A|56=B|34=1|52=20240101-00:00:00|")+"\n"), 0644)
+
+	var out, errOut strings.Builder
+	code := Process([]string{"-secret", "-validate", "-colour=no", log}, &out, &errOut)
+	if code != 0 || strings.Contains(out.String(), "BodyLength mismatch") || !strings.Contains(out.String(), "SenderCompID0001") {
+		t.Errorf("expected the obfuscated message to validate cleanly, got code=%d out=%q", code, out.String())
+	}
+}
+
+func TestProcessShowsLinePrefix(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "session.log")
+	_ = os.WriteFile(log, []byte("2025-01-01 09:00:00.250 OUT "+fix44Line("35=0|49=A|56=B|34=1|52=20250101-09:00:00|")+"\n"), 0644)
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"-prefix=log4j", "-colour=no", log}, &out, &errOut); code != 0 || !strings.Contains(out.String(), "-- 2025-01-01 09:00:00.25 OUT\n") {
+		t.Errorf("expected the parsed prefix, got code=%d out=%q err=%q", code, out.String(), errOut.String())
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"-prefix=nosuch", log}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), `unknown prefix parser "nosuch"`) {
+		t.Errorf("expected a prefix parser error, got code=%d err=%q", code, errOut.String())
+	}
+}
+
+func TestProcessMerge(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+	_ = os.WriteFile(a, []byte(fix44Line("35=0|49=A|56=B|34=1|52=20250101-09:00:02|")+"\n"), 0644)
+	_ = os.WriteFile(b, []byte(fix44Line("35=0|49=B|56=A|34=1|52=20250101-09:00:01|")+"\n"), 0644)
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"-merge", "-colour=no", a, b}, &out, &errOut); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, errOut.String())
+	}
+	if first, second := strings.Index(out.String(), "["+b+"]"), strings.Index(out.String(), "["+a+"]"); first < 0 || second < first {
+		t.Errorf("expected %s before %s, got %q", b, a, out.String())
+	}
+
+	errOut.Reset()
+	if code := Process([]string{"-merge", "-output=csv", a, b}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "-merge needs -output=terminal") {
+		t.Errorf("expected an output error, got code=%d err=%q", code, errOut.String())
+	}
+}
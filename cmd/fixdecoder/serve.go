@@ -0,0 +1,62 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/stephenlclarke/fixdecoder/server"
+)
+
+var listenAndServe = func(srv *http.Server) error { return srv.ListenAndServe() }
+
+// handleServe implements "fixdecoder serve": the decode API and web UI.
+func handleServe(args []string, out, errOut io.Writer) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(errOut)
+
+	addr := fs.String("addr", "localhost:8080", "Address to listen on")
+
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	fmt.Fprintf(out, "Serving fixdecoder on http://%s/\n", *addr)
+
+	if err := listenAndServe(srv); err != nil && err != http.ErrServerClosed {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	return 0
+}
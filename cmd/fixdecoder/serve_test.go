@@ -0,0 +1,59 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProcessServe(t *testing.T) {
+	orig := listenAndServe
+	defer func() { listenAndServe = orig }()
+
+	var addr string
+	listenAndServe = func(srv *http.Server) error {
+		addr = srv.Addr
+		return http.ErrServerClosed
+	}
+
+	var out, errOut strings.Builder
+	if code := Process([]string{"serve", "-addr", "127.0.0.1:9999"}, &out, &errOut); code != 0 || addr != "127.0.0.1:9999" {
+		t.Errorf("expected server on requested address, got code=%d addr=%q err=%q", code, addr, errOut.String())
+	}
+
+	if !strings.Contains(out.String(), "http://127.0.0.1:9999/") {
+		t.Errorf("expected URL announcement, got %q", out.String())
+	}
+
+	listenAndServe = func(*http.Server) error { return errors.New("address in use") }
+	if code := Process([]string{"serve"}, &out, &errOut); code != 1 || !strings.Contains(errOut.String(), "address in use") {
+		t.Errorf("expected listen failure, got code=%d err=%q", code, errOut.String())
+	}
+
+	if code := Process([]string{"serve", "-bogus"}, &out, &errOut); code != 1 {
+		t.Errorf("expected flag error, got %d", code)
+	}
+}
@@ -0,0 +1,690 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+// cmd/generateMessageStructs/main.go
+//
+// Generates a Go package of typed message structs from a FIX dictionary,
+// embedded or loaded from a QuickFIX or Orchestra XML file:
+//
+//   - a struct per message, component and repeating group, one Go field per
+//     FIX field: *int, *big.Rat, *bool and *time.Time for the integer,
+//     decimal, boolean and UTC timestamp types (nil means absent), and a
+//     string for everything else (empty means absent)
+//   - enum constants per field, e.g. SideBuy = "1" or EncryptMethodNone = 0
+//   - MarshalFields/UnmarshalFields to and from a decoder.FieldMap, and
+//     Marshal/Unmarshal to and from a decoder.FixMessage for messages
+//
+// Run from anywhere:
+//
+//   go run ./cmd/generateMessageStructs -fix=44 -message=D,8 -out=internal/fix44 -package=fix44
+//
+// Output: fields.go (enums and helpers), components.go and messages.go.
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+// For testing/mocking:
+var formatSource = format.Source
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "generateMessageStructs: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("generateMessageStructs", flag.ContinueOnError)
+
+	fixVersion := fs.String("fix", "44", "FIX version to use ("+fix.SupportedFixVersions()+")")
+	xmlPath := fs.String("xml", "", "Path to alternative FIX XML file")
+	outDir := fs.String("out", "", "Directory to write the generated package to")
+	pkg := fs.String("package", "", "Package name (default the base name of -out)")
+	messages := fs.String("message", "", "Comma-separated message names or MsgTypes (default all)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *outDir == "" {
+		return errors.New("-out is required")
+	}
+
+	if *pkg == "" {
+		*pkg = filepath.Base(*outDir)
+	}
+	if !isIdentifier(*pkg) {
+		return fmt.Errorf("invalid package name %q", *pkg)
+	}
+
+	schema, err := loadSchema(*fixVersion, *xmlPath)
+	if err != nil {
+		return err
+	}
+
+	var only []string
+	if *messages != "" {
+		only = strings.Split(*messages, ",")
+	}
+
+	files, err := generate(schema, *pkg, only)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		return fmt.Errorf("mkdir %s: %w", *outDir, err)
+	}
+
+	for _, name := range sortedKeys(files) {
+		path := filepath.Join(*outDir, name)
+		if err := os.WriteFile(path, files[name], 0o644); err != nil {
+			return fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	fmt.Fprintf(out, "Generated package %s in %s\n", *pkg, *outDir)
+	return nil
+}
+
+// ---- Dictionary loading ----
+
+func loadSchema(version, xmlPath string) (decoder.SchemaTree, error) {
+	data := []byte(fix.ChooseEmbeddedXML(version))
+
+	if xmlPath != "" {
+		var err error
+		if data, err = os.ReadFile(xmlPath); err != nil {
+			return decoder.SchemaTree{}, err
+		}
+	}
+
+	dict, err := decoder.ParseFixDictionary(data)
+	if err != nil {
+		return decoder.SchemaTree{}, fmt.Errorf("parse dictionary: %w", err)
+	}
+
+	return decoder.BuildSchema(dict), nil
+}
+
+// headerAndTrailer returns the standard header and trailer; FIX 5.0
+// dictionaries leave them to the FIXT.1.1 transport dictionary.
+func headerAndTrailer(schema decoder.SchemaTree) (decoder.ComponentNode, decoder.ComponentNode) {
+	header, trailer := schema.Components["Header"], schema.Components["Trailer"]
+
+	if len(header.Fields) == 0 {
+		if transport, err := loadSchema("T11", ""); err == nil {
+			header, trailer = transport.Components["Header"], transport.Components["Trailer"]
+		}
+	}
+
+	return header, trailer
+}
+
+// beginString is the BeginString messages of schema carry.
+func beginString(schema decoder.SchemaTree) string {
+	if strings.HasPrefix(schema.Version, "5.") || strings.HasPrefix(schema.Version, "1.") {
+		return "FIXT.1.1"
+	}
+	return "FIX." + schema.Version
+}
+
+// ---- Code generation ----
+
+// generator writes the structs for a schema. Type and constant names share
+// one namespace, so clashes get a numeric suffix.
+type generator struct {
+	schema     decoder.SchemaTree
+	idents     map[string]bool
+	components map[string]string // component name → type name
+	header     decoder.ComponentNode
+	fields     map[int]decoder.Field
+	compBuf    bytes.Buffer
+	msgBuf     bytes.Buffer
+}
+
+// generate returns the formatted source files keyed by file name.
+func generate(schema decoder.SchemaTree, pkg string, only []string) (map[string][]byte, error) {
+	msgs, err := selectMessages(schema, only)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		schema:     schema,
+		idents:     map[string]bool{"BeginString": true},
+		components: make(map[string]string),
+		fields:     make(map[int]decoder.Field),
+	}
+
+	header, trailer := headerAndTrailer(schema)
+	header.Name, trailer.Name = "Header", "Trailer"
+	g.header = header
+	g.component(header)
+	g.component(trailer)
+
+	for _, m := range msgs {
+		g.message(m)
+	}
+
+	files := map[string][]byte{
+		"fields.go":     g.fieldsFile(schema),
+		"components.go": g.compBuf.Bytes(),
+		"messages.go":   g.msgBuf.Bytes(),
+	}
+
+	for name, body := range files {
+		var buf bytes.Buffer
+		writeHeader(&buf, pkg, schema, body)
+		buf.Write(body)
+
+		src, err := formatSource(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("format %s: %w", name, err)
+		}
+		files[name] = src
+	}
+
+	return files, nil
+}
+
+func selectMessages(schema decoder.SchemaTree, only []string) ([]decoder.MessageNode, error) {
+	var msgs []decoder.MessageNode
+
+	if len(only) == 0 {
+		for _, m := range schema.Messages {
+			msgs = append(msgs, m)
+		}
+	}
+
+	for _, id := range only {
+		id = strings.TrimSpace(id)
+		found := false
+		for _, m := range schema.Messages {
+			if m.Name == id || m.MsgType == id {
+				msgs = append(msgs, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("message not found: %s", id)
+		}
+	}
+
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Name < msgs[j].Name })
+	return msgs, nil
+}
+
+// stdImports maps the standard packages generated code may use to a
+// selector that shows body uses them.
+var stdImports = []struct{ path, use string }{
+	{"errors", "errors."},
+	{"fmt", "fmt."},
+	{"math/big", "big.Rat"},
+	{"strconv", "strconv."},
+	{"time", "time.Time"},
+}
+
+// writeHeader writes the file comment, package clause and the imports body
+// uses.
+func writeHeader(w io.Writer, pkg string, schema decoder.SchemaTree, body []byte) {
+	fmt.Fprintf(w, "// Code generated by generateMessageStructs; DO NOT EDIT.\n")
+	fmt.Fprintf(w, "// Source: FIX %s dictionary\n\n", schema.Version)
+	fmt.Fprintf(w, "package %s\n\n", pkg)
+
+	var imports []string
+	for _, imp := range stdImports {
+		if bytes.Contains(body, []byte(imp.use)) {
+			imports = append(imports, strconv.Quote(imp.path))
+		}
+	}
+	if bytes.Contains(body, []byte("decoder.")) {
+		imports = append(imports, "", `"github.com/stephenlclarke/fixdecoder/decoder"`)
+	}
+
+	if len(imports) > 0 {
+		fmt.Fprintf(w, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+}
+
+// ident reserves a package-level name based on name.
+func (g *generator) ident(name string) string {
+	base := exported(name)
+	id := base
+	for n := 2; g.idents[id]; n++ {
+		id = base + strconv.Itoa(n)
+	}
+	g.idents[id] = true
+	return id
+}
+
+// component returns the type name for c, generating it the first time.
+func (g *generator) component(c decoder.ComponentNode) string {
+	if name, ok := g.components[c.Name]; ok {
+		return name
+	}
+
+	name := g.ident(c.Name)
+	g.components[c.Name] = name
+	g.writeStruct(&g.compBuf, name, fmt.Sprintf("%s is the %s component.", name, c.Name), false, c.Fields, c.Components, c.Groups)
+
+	return name
+}
+
+func (g *generator) message(m decoder.MessageNode) {
+	name := g.ident(m.Name)
+	doc := fmt.Sprintf("%s is the %s message, MsgType %s.", name, m.Name, m.MsgType)
+
+	g.writeStruct(&g.msgBuf, name, doc, true, m.Fields, m.Components, m.Groups)
+	g.writeMessageMethods(name, m)
+}
+
+// structMember is one Go field of a generated struct.
+type structMember struct {
+	goName  string
+	goType  string
+	codec   string // for fields: "" for strings, else Int, Decimal, Bool or UTCTimestamp
+	tag     int
+	kind    string // field, component or group
+	comment string
+}
+
+// fieldCodec maps a FIX type to the Go type of its struct field and the
+// codec its helpers and decoder getter are named after. Types without a
+// typed getter stay strings.
+func fieldCodec(fixType string) (goType, codec string) {
+	switch strings.ToUpper(fixType) {
+	case "INT", "LENGTH", "SEQNUM", "NUMINGROUP", "TAGNUM", "DAYOFMONTH":
+		return "*int", "Int"
+	case "FLOAT", "QTY", "PRICE", "PRICEOFFSET", "AMT", "PERCENTAGE":
+		return "*big.Rat", "Decimal"
+	case "BOOLEAN":
+		return "*bool", "Bool"
+	case "UTCTIMESTAMP", "TIME":
+		return "*time.Time", "UTCTimestamp"
+	}
+	return "string", ""
+}
+
+func (g *generator) members(buf *bytes.Buffer, parent string, fields []decoder.FieldNode, comps []decoder.ComponentNode, groups []decoder.GroupNode, reserved ...string) []structMember {
+	used := make(map[string]bool)
+	for _, r := range reserved {
+		used[r] = true
+	}
+
+	unique := func(name string) string {
+		base := exported(name)
+		id := base
+		for n := 2; used[id]; n++ {
+			id = base + strconv.Itoa(n)
+		}
+		used[id] = true
+		return id
+	}
+
+	var out []structMember
+
+	for _, f := range fields {
+		g.fields[f.Field.Number] = f.Field
+		goType, codec := fieldCodec(f.Field.Type)
+		out = append(out, structMember{
+			goName:  unique(f.Field.Name),
+			goType:  goType,
+			codec:   codec,
+			tag:     f.Field.Number,
+			kind:    "field",
+			comment: fmt.Sprintf("%d %s%s", f.Field.Number, f.Field.Type, requiredSuffix(f.Ref.Required)),
+		})
+	}
+
+	for _, c := range comps {
+		out = append(out, structMember{
+			goName:  unique(c.Name),
+			goType:  g.component(c),
+			kind:    "component",
+			comment: "component" + requiredSuffix(c.Required),
+		})
+	}
+
+	for _, grp := range groups {
+		count := g.schema.Fields[grp.Name]
+		g.fields[count.Number] = count
+
+		typeName := g.ident(parent + exported(grp.Name))
+		out = append(out, structMember{
+			goName:  unique(grp.Name),
+			goType:  "[]" + typeName,
+			tag:     count.Number,
+			kind:    "group",
+			comment: fmt.Sprintf("%d NUMINGROUP%s", count.Number, requiredSuffix(grp.Required)),
+		})
+
+		defer g.writeStruct(buf, typeName, fmt.Sprintf("%s is an instance of the %s group.", typeName, grp.Name), false, grp.Fields, grp.Components, grp.Groups)
+	}
+
+	return out
+}
+
+func requiredSuffix(required string) string {
+	if required == "Y" {
+		return ", required"
+	}
+	return ""
+}
+
+// writeStruct writes the struct and its MarshalFields and UnmarshalFields
+// methods to buf, after the group types it uses. Messages also carry the
+// standard header and trailer.
+func (g *generator) writeStruct(buf *bytes.Buffer, name, doc string, isMessage bool, fields []decoder.FieldNode, comps []decoder.ComponentNode, groups []decoder.GroupNode) {
+	var reserved []string
+	if isMessage {
+		reserved = []string{"Header", "Trailer"}
+	}
+
+	var body bytes.Buffer
+	members := g.members(buf, name, fields, comps, groups, reserved...)
+
+	fmt.Fprintf(&body, "// %s\ntype %s struct {\n", doc, name)
+	if len(reserved) > 0 {
+		fmt.Fprintf(&body, "\tHeader %s\n", g.components["Header"])
+	}
+	for _, m := range members {
+		fmt.Fprintf(&body, "\t%s %s // %s\n", m.goName, m.goType, m.comment)
+	}
+	if len(reserved) > 0 {
+		fmt.Fprintf(&body, "\tTrailer %s\n", g.components["Trailer"])
+	}
+	body.WriteString("}\n\n")
+
+	fmt.Fprintf(&body, "// MarshalFields appends the fields of m to fm in dictionary order,\n// leaving out empty ones.\n")
+	fmt.Fprintf(&body, "func (m *%s) MarshalFields(fm *decoder.FieldMap) {\n", name)
+	for _, m := range members {
+		switch m.kind {
+		case "field":
+			fmt.Fprintf(&body, "\tappend%s(fm, %d, m.%s)\n", cmp.Or(m.codec, "Field"), m.tag, m.goName)
+		case "component":
+			fmt.Fprintf(&body, "\tm.%s.MarshalFields(fm)\n", m.goName)
+		case "group":
+			fmt.Fprintf(&body, "\tif len(m.%[1]s) > 0 {\n\t\tinstances := make([]*decoder.FieldMap, len(m.%[1]s))\n\t\tfor i := range m.%[1]s {\n\t\t\tinstances[i] = &decoder.FieldMap{}\n\t\t\tm.%[1]s[i].MarshalFields(instances[i])\n\t\t}\n\t\tappendGroup(fm, %[2]d, instances)\n\t}\n", m.goName, m.tag)
+		}
+	}
+	body.WriteString("}\n\n")
+
+	fmt.Fprintf(&body, "// UnmarshalFields sets m from the fields of fm. It fails on the first\n// value that does not parse as its field's type.\n")
+	fmt.Fprintf(&body, "func (m *%s) UnmarshalFields(fm *decoder.FieldMap) error {\n", name)
+	for _, m := range members {
+		switch {
+		case m.kind == "field" && m.codec == "":
+			fmt.Fprintf(&body, "\tm.%s, _ = fm.Get(%d)\n", m.goName, m.tag)
+		case m.kind == "field" && m.codec == "Decimal":
+			fmt.Fprintf(&body, "\tif err := getDecimal(fm, %d, &m.%s); err != nil {\n\t\treturn err\n\t}\n", m.tag, m.goName)
+		case m.kind == "field":
+			fmt.Fprintf(&body, "\tif err := getField(%d, fm.Get%s, &m.%s); err != nil {\n\t\treturn err\n\t}\n", m.tag, m.codec, m.goName)
+		case m.kind == "component":
+			fmt.Fprintf(&body, "\tif err := m.%s.UnmarshalFields(fm); err != nil {\n\t\treturn err\n\t}\n", m.goName)
+		case m.kind == "group":
+			fmt.Fprintf(&body, "\tm.%[1]s = nil\n\tfor _, instance := range fm.GetGroup(%[2]d) {\n\t\tvar v %[3]s\n\t\tif err := v.UnmarshalFields(instance); err != nil {\n\t\t\treturn err\n\t\t}\n\t\tm.%[1]s = append(m.%[1]s, v)\n\t}\n", m.goName, m.tag, strings.TrimPrefix(m.goType, "[]"))
+		}
+	}
+	body.WriteString("\treturn nil\n}\n\n")
+
+	buf.Write(body.Bytes())
+}
+
+func (g *generator) writeMessageMethods(name string, m decoder.MessageNode) {
+	buf := &g.msgBuf
+
+	fmt.Fprintf(buf, "// MsgType returns %q.\nfunc (m *%s) MsgType() string { return %q }\n\n", m.MsgType, name, m.MsgType)
+
+	fmt.Fprintf(buf, "// Marshal builds the message, setting MsgType and, when empty,\n// BeginString. Encode it with FixMessage.Encode.\n")
+	fmt.Fprintf(buf, "func (m *%s) Marshal() *decoder.FixMessage {\n", name)
+	fmt.Fprintf(buf, "\tmsg := &decoder.FixMessage{Header: &decoder.FieldMap{}, Body: &decoder.FieldMap{}, Trailer: &decoder.FieldMap{}}\n")
+	buf.WriteString("\th := m.Header\n")
+	if g.headerHas(8) {
+		buf.WriteString("\tif h.BeginString == \"\" {\n\t\th.BeginString = BeginString\n\t}\n")
+	}
+	if g.headerHas(35) {
+		fmt.Fprintf(buf, "\th.MsgType = %q\n\th.MarshalFields(msg.Header)\n", m.MsgType)
+	} else {
+		fmt.Fprintf(buf, "\th.MarshalFields(msg.Header)\n\tmsg.Header.Set(35, %q)\n", m.MsgType)
+	}
+	buf.WriteString("\tm.MarshalFields(msg.Body)\n\tm.Trailer.MarshalFields(msg.Trailer)\n\treturn msg\n}\n\n")
+
+	fmt.Fprintf(buf, "// Unmarshal sets m from msg, which must have MsgType %q.\n", m.MsgType)
+	fmt.Fprintf(buf, "func (m *%s) Unmarshal(msg *decoder.FixMessage) error {\n", name)
+	fmt.Fprintf(buf, "\tif msg.MsgType() != %[1]q {\n\t\treturn fmt.Errorf(\"unexpected MsgType %%q, want %%q\", msg.MsgType(), %[1]q)\n\t}\n", m.MsgType)
+	buf.WriteString("\tif err := m.Header.UnmarshalFields(msg.Header); err != nil {\n\t\treturn err\n\t}\n\tif err := m.UnmarshalFields(msg.Body); err != nil {\n\t\treturn err\n\t}\n\treturn m.Trailer.UnmarshalFields(msg.Trailer)\n}\n\n")
+}
+
+// headerHas reports whether the standard header holds tag directly.
+func (g *generator) headerHas(tag int) bool {
+	for _, f := range g.header.Fields {
+		if f.Field.Number == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldsFile holds BeginString, the enum constants of every field the
+// structs use and the helpers they share.
+func (g *generator) fieldsFile(schema decoder.SchemaTree) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// BeginString is the BeginString Marshal sets when none is given.\nconst BeginString = %q\n\n", beginString(schema))
+
+	tags := make([]int, 0, len(g.fields))
+	for tag := range g.fields {
+		tags = append(tags, tag)
+	}
+	sort.Ints(tags)
+
+	for _, tag := range tags {
+		f := g.fields[tag]
+		if len(f.Values) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&buf, "// %s (%d) values.\nconst (\n", f.Name, f.Number)
+		for _, v := range f.Values {
+			name := exported(f.Name) + enumSuffix(v)
+			fmt.Fprintf(&buf, "\t%s = %s\n", g.ident(name), enumLiteral(f.Type, v.Enum))
+		}
+		buf.WriteString(")\n\n")
+	}
+
+	buf.WriteString(`func appendField(fm *decoder.FieldMap, tag int, value string) {
+	if value != "" {
+		fm.Fields = append(fm.Fields, decoder.MessageField{FieldValue: decoder.FieldValue{Tag: tag, Value: value}})
+	}
+}
+
+func appendInt(fm *decoder.FieldMap, tag int, value *int) {
+	if value != nil {
+		appendField(fm, tag, strconv.Itoa(*value))
+	}
+}
+
+func appendDecimal(fm *decoder.FieldMap, tag int, value *big.Rat) {
+	if value != nil {
+		appendField(fm, tag, decoder.FormatDecimal(value))
+	}
+}
+
+func appendBool(fm *decoder.FieldMap, tag int, value *bool) {
+	switch {
+	case value == nil:
+	case *value:
+		appendField(fm, tag, "Y")
+	default:
+		appendField(fm, tag, "N")
+	}
+}
+
+func appendUTCTimestamp(fm *decoder.FieldMap, tag int, value *time.Time) {
+	if value != nil {
+		appendField(fm, tag, decoder.FormatUTCTimestamp(*value))
+	}
+}
+
+// getField sets *dst to the value get parses for tag, or nil when fm
+// does not hold tag.
+func getField[T any](tag int, get func(int) (T, error), dst **T) error {
+	v, err := get(tag)
+	switch {
+	case errors.Is(err, decoder.ErrFieldNotFound):
+		*dst = nil
+	case err != nil:
+		return err
+	default:
+		*dst = &v
+	}
+	return nil
+}
+
+func getDecimal(fm *decoder.FieldMap, tag int, dst **big.Rat) error {
+	v, err := fm.GetDecimal(tag)
+	if err != nil && !errors.Is(err, decoder.ErrFieldNotFound) {
+		return err
+	}
+	*dst = v
+	return nil
+}
+
+func appendGroup(fm *decoder.FieldMap, tag int, instances []*decoder.FieldMap) {
+	fm.Fields = append(fm.Fields, decoder.MessageField{
+		FieldValue: decoder.FieldValue{Tag: tag, Value: strconv.Itoa(len(instances))},
+		Groups:     instances,
+	})
+}
+`)
+
+	return buf.Bytes()
+}
+
+// enumLiteral writes an enum value as a constant of its field's Go type:
+// a number for integer fields, true or false for booleans and a string
+// otherwise.
+func enumLiteral(fixType, enum string) string {
+	switch _, codec := fieldCodec(fixType); codec {
+	case "Int":
+		if _, err := strconv.Atoi(enum); err == nil {
+			return enum
+		}
+	case "Bool":
+		if enum == "Y" || enum == "N" {
+			return strconv.FormatBool(enum == "Y")
+		}
+	}
+	return strconv.Quote(enum)
+}
+
+// enumSuffix turns an enum description such as "PER_UNIT" into "PerUnit",
+// falling back to the value itself.
+func enumSuffix(v decoder.Value) string {
+	if s := camelCase(v.Description); s != "" {
+		return s
+	}
+	if s := camelCase(v.Enum); s != "" {
+		return s
+	}
+	return "Value"
+}
+
+// camelCase joins the letter and digit runs of s, each capitalised.
+// All-caps text such as "PARTIALLY_FILLED" is lowered first.
+func camelCase(s string) string {
+	return joinWords(s, strings.ToUpper(s) == s)
+}
+
+func joinWords(s string, lower bool) string {
+	var sb strings.Builder
+	upper := true
+
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		switch {
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+		case lower:
+			sb.WriteRune(unicode.ToLower(r))
+		default:
+			sb.WriteRune(r)
+		}
+		upper = false
+	}
+
+	return sb.String()
+}
+
+// exported makes a dictionary name such as "IOIID" a valid exported
+// identifier, keeping its case.
+func exported(name string) string {
+	s := joinWords(name, false)
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+func isIdentifier(s string) bool {
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// ---- Helpers ----
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,303 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+)
+
+func mustReadFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(b)
+}
+
+func mustWriteFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestRunGeneratesPackage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "fix44")
+
+	var out bytes.Buffer
+	if err := run([]string{"-fix=44", "-message=D,ExecutionReport", "-out=" + dir}, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Generated package fix44") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+
+	fields := mustReadFile(t, filepath.Join(dir, "fields.go"))
+	for _, want := range []string{
+		"// Code generated by generateMessageStructs; DO NOT EDIT.",
+		"package fix44",
+		`const BeginString = "FIX.4.4"`,
+		`SideBuy`,
+		`OrdStatusPartiallyFilled`,
+	} {
+		if !strings.Contains(fields, want) {
+			t.Errorf("fields.go missing %q", want)
+		}
+	}
+
+	messages := mustReadFile(t, filepath.Join(dir, "messages.go"))
+	for _, want := range []string{
+		"type NewOrderSingle struct {",
+		"type ExecutionReport struct {",
+		"ClOrdID ",
+		`func (m *NewOrderSingle) MsgType() string { return "D" }`,
+		"func (m *ExecutionReport) Unmarshal(msg *decoder.FixMessage) error {",
+	} {
+		if !strings.Contains(messages, want) {
+			t.Errorf("messages.go missing %q", want)
+		}
+	}
+	if strings.Contains(messages, "type Logon struct") {
+		t.Error("-message filter not applied")
+	}
+
+	components := mustReadFile(t, filepath.Join(dir, "components.go"))
+	for _, want := range []string{"type Header struct {", "type Trailer struct {", "type Instrument struct {", "type PartiesNoPartyIDs struct {"} {
+		if !strings.Contains(components, want) {
+			t.Errorf("components.go missing %q", want)
+		}
+	}
+
+	for _, want := range []string{`MsgSeqNum +\*int `, `PossDupFlag +\*bool `, `OrderQty +\*big\.Rat `, `SendingTime +\*time\.Time `, `SenderCompID +string `} {
+		if !regexp.MustCompile(want).MatchString(components) {
+			t.Errorf("components.go missing %q", want)
+		}
+	}
+	if !regexp.MustCompile(`TransactTime +\*time\.Time `).MatchString(messages) {
+		t.Error("messages.go missing a time.Time TransactTime")
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "gen")
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "-out is required"},
+		{[]string{"-out=" + out, "-package=1bad"}, "invalid package name"},
+		{[]string{"-out=" + out, "-message=NoSuchMessage"}, "message not found: NoSuchMessage"},
+		{[]string{"-out=" + out, "-xml=" + filepath.Join(out, "missing.xml")}, "missing.xml"},
+		{[]string{"-bogus"}, "flag provided but not defined"},
+	}
+
+	for _, tc := range cases {
+		err := run(tc.args, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("run(%v) = %v, want error containing %q", tc.args, err, tc.want)
+		}
+	}
+}
+
+func TestRunFormatError(t *testing.T) {
+	orig := formatSource
+	defer func() { formatSource = orig }()
+	formatSource = func([]byte) ([]byte, error) { return nil, errors.New("boom") }
+
+	err := run([]string{"-out=" + filepath.Join(t.TempDir(), "gen"), "-message=0"}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected format error, got %v", err)
+	}
+}
+
+func TestGenerateFIXTHeaderForFIX50(t *testing.T) {
+	schema, err := loadSchema("50SP2", "")
+	if err != nil {
+		t.Fatalf("loadSchema: %v", err)
+	}
+
+	files, err := generate(schema, "fix50sp2", []string{"D"})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	if !strings.Contains(string(files["fields.go"]), `const BeginString = "FIXT.1.1"`) {
+		t.Error("expected FIXT.1.1 BeginString")
+	}
+	if !strings.Contains(string(files["components.go"]), "SenderCompID") {
+		t.Error("expected FIXT.1.1 header fields")
+	}
+}
+
+func TestNaming(t *testing.T) {
+	cases := map[string]string{
+		"PARTIALLY_FILLED": "PartiallyFilled",
+		"SellShort":        "SellShort",
+		"per unit":         "PerUnit",
+		"":                 "",
+	}
+	for in, want := range cases {
+		if got := camelCase(in); got != want {
+			t.Errorf("camelCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	if got := exported("1stLeg"); got != "X1stLeg" {
+		t.Errorf("exported = %q", got)
+	}
+	if got := exported("IOIID"); got != "IOIID" {
+		t.Errorf("exported = %q", got)
+	}
+	if got := enumSuffix(decoder.Value{Enum: "?"}); got != "Value" {
+		t.Errorf("enumSuffix = %q", got)
+	}
+	if got := enumSuffix(decoder.Value{Enum: "y"}); got != "Y" {
+		t.Errorf("enumSuffix = %q", got)
+	}
+
+	for s, want := range map[string]bool{"fix44": true, "_x": true, "4x": false, "a-b": false, "": false} {
+		if got := isIdentifier(s); got != want {
+			t.Errorf("isIdentifier(%q) = %v", s, got)
+		}
+	}
+
+	g := &generator{idents: map[string]bool{}}
+	if a, b := g.ident("Side"), g.ident("Side"); a != "Side" || b != "Side2" {
+		t.Errorf("ident = %q, %q", a, b)
+	}
+}
+
+// TestGeneratedCodeRoundTrips compiles the generated package and checks a
+// parse → Unmarshal → Marshal → Encode → parse round trip.
+func TestGeneratedCodeRoundTrips(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go test on generated code")
+	}
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	// The package must live inside the module to import decoder; the leading
+	// underscore keeps it out of ./... if a run is interrupted.
+	dir, err := os.MkdirTemp(".", "_roundtrip")
+	if err != nil {
+		t.Fatalf("mkdtemp: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	if err := run([]string{"-fix=44", "-message=D,8", "-out=" + dir, "-package=fix44"}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	mustWriteFile(t, filepath.Join(dir, "roundtrip_test.go"), `package fix44
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+)
+
+func TestRoundTrip(t *testing.T) {
+	raw := strings.ReplaceAll("8=FIX.4.4|9=0|35=D|49=BUY|56=SELL|34=2|43=N|52=20240101-10:30:00.123|11=ORD1|453=2|448=P1|447=D|452=1|448=P2|447=D|452=3|55=IBM|54=1|60=20240101-00:00:00|38=100|40=2|44=10.5|10=000|", "|", "\x01")
+	msg, err := decoder.ParseMessage(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var order NewOrderSingle
+	if err := order.Unmarshal(msg); err != nil {
+		t.Fatal(err)
+	}
+	if order.ClOrdID != "ORD1" || order.Side != SideBuy || order.Instrument.Symbol != "IBM" || len(order.Parties.NoPartyIDs) != 2 || order.Parties.NoPartyIDs[1].PartyID != "P2" {
+		t.Fatalf("unexpected %+v", order)
+	}
+
+	// Typed fields: nil means absent, so a false PossDupFlag survives.
+	sending := time.Date(2024, 1, 1, 10, 30, 0, 123e6, time.UTC)
+	if order.Header.MsgSeqNum == nil || *order.Header.MsgSeqNum != 2 ||
+		order.Header.PossDupFlag == nil || *order.Header.PossDupFlag ||
+		order.Header.SendingTime == nil || !order.Header.SendingTime.Equal(sending) ||
+		order.OrderQtyData.OrderQty == nil || order.OrderQtyData.OrderQty.Cmp(big.NewRat(100, 1)) != 0 ||
+		order.Price == nil || order.Price.Cmp(big.NewRat(21, 2)) != 0 ||
+		order.StopPx != nil {
+		t.Fatalf("unexpected typed fields %+v", order)
+	}
+	if OrdTypeLimit != "2" || OrdRejReasonUnknownSymbol != 1 || PossDupFlagNo != false {
+		t.Fatal("enum constants do not match their field types")
+	}
+
+	// Body fields come out in dictionary order, so compare decoded values
+	// and the encoded length rather than the raw bytes.
+	encoded := order.Marshal().Encode()
+	if len(encoded) != len(msg.Encode()) {
+		t.Fatalf("round trip changed the message:\n got %q\nwant %q", encoded, msg.Encode())
+	}
+
+	again, err := decoder.ParseMessage(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var copied NewOrderSingle
+	if err := copied.Unmarshal(again); err != nil {
+		t.Fatal(err)
+	}
+	copied.Header.BodyLength, copied.Trailer.CheckSum = order.Header.BodyLength, order.Trailer.CheckSum
+	if !reflect.DeepEqual(order, copied) {
+		t.Fatalf("round trip:\n got %+v\nwant %+v", copied, order)
+	}
+
+	var report ExecutionReport
+	if err := report.Unmarshal(msg); err == nil {
+		t.Fatal("expected MsgType mismatch")
+	}
+
+	msg.Body.Set(38, "lots")
+	if err := order.Unmarshal(msg); err == nil || !strings.Contains(err.Error(), "tag 38") {
+		t.Fatalf("expected invalid OrderQty, got %v", err)
+	}
+}
+`)
+
+	cmd := exec.Command(goTool, "test", "./"+filepath.Base(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test generated package: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,593 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// BrowseEntry is one FIX message found in a log, as listed by the browser.
+type BrowseEntry struct {
+	Source    string // file name, or (stdin)
+	Line      int    // 1-based line number within Source
+	Time      string // SendingTime when present
+	Direction string // IN, OUT or empty when the log line does not say
+	Message   DecodedMessage
+
+	text string // lower-cased search text
+}
+
+// browseKeyTags are summarised in the message list when present.
+var browseKeyTags = []int{11, 41, 55, 54, 38, 44, 39, 150, 112}
+
+func newBrowseEntry(source string, line int, prefix string, dm DecodedMessage, parser PrefixParser) BrowseEntry {
+	lp, _ := prefixParserOrAuto(parser).Parse(prefix)
+	e := BrowseEntry{Source: source, Line: line, Direction: lp.Direction, Message: dm}
+	e.Time, _ = e.Value(52)
+
+	var sb strings.Builder
+	sb.WriteString(visibleSOH(dm.Raw))
+	sb.WriteString(" " + dm.MsgName)
+	for _, f := range dm.Flat() {
+		sb.WriteString(" " + f.Name + " " + f.Description)
+	}
+	e.text = strings.ToLower(sb.String())
+
+	return e
+}
+
+// Value returns the first value of tag anywhere in the message.
+func (e BrowseEntry) Value(tag int) (string, bool) {
+	for _, f := range e.Message.Flat() {
+		if f.Tag == tag {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// Summary lists the key order fields of the message as Name=Value pairs.
+func (e BrowseEntry) Summary() string {
+	flat := e.Message.Flat()
+	var parts []string
+
+	for _, tag := range browseKeyTags {
+		for _, f := range flat {
+			if f.Tag != tag {
+				continue
+			}
+
+			v := f.Value
+			if f.Description != "" {
+				v = f.Description
+			}
+			parts = append(parts, f.Name+"="+v)
+			break
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// LoadBrowseEntries decodes every FIX message in paths. Errors are reported
+// to errOut; the bool is false if any input failed.
+func LoadBrowseEntries(paths []string, opts LogOptions, errOut io.Writer) ([]BrowseEntry, bool) {
+	var entries []BrowseEntry
+
+	ok := forEachInput(paths, errOut, func(name string, r io.Reader) error {
+		scanner := newLineScanner(r)
+
+		for n := 1; scanner.Scan(); n++ {
+			dl := DecodeLogLine(scanner.Text(), opts, errOut)
+			prev := 0
+
+			for i, dm := range dl.Messages {
+				prefix := dl.Text[prev:dl.Spans[i][0]]
+				prev = dl.Spans[i][1]
+				entries = append(entries, newBrowseEntry(name, n, prefix, dm, opts.Prefix))
+			}
+		}
+
+		return scanner.Err()
+	})
+
+	return entries, ok
+}
+
+type browseMode int
+
+const (
+	browseNormal browseMode = iota
+	browseSearch
+	browseFilter
+)
+
+const browseHelp = "q quit  ↑/↓ move  / search  n/N next/prev  f filter  c/C ClOrdID  e errors  J/K scroll"
+
+// Browser holds the state of the interactive log browser. It is driven by
+// HandleKey and drawn by Render, so it has no terminal dependencies.
+type Browser struct {
+	Theme Theme
+
+	entries   []BrowseEntry
+	visible   []int // indexes into entries that pass the filter
+	cursor    int   // position in visible
+	top       int   // first visible row of the list pane
+	detailTop int   // first row of the detail pane
+	page      int   // list pane height from the last Render
+
+	filter string
+	search string
+	mode   browseMode
+	input  string
+	origin int // cursor when the search prompt opened
+	status string
+	done   bool
+}
+
+// NewBrowser returns a browser positioned on the first entry.
+func NewBrowser(entries []BrowseEntry, t Theme) *Browser {
+	b := &Browser{Theme: t, entries: entries, page: 10}
+	b.applyFilter("")
+	return b
+}
+
+// Done reports whether the user asked to quit.
+func (b *Browser) Done() bool { return b.done }
+
+// Current returns the selected entry.
+func (b *Browser) Current() (BrowseEntry, bool) {
+	if len(b.visible) == 0 {
+		return BrowseEntry{}, false
+	}
+	return b.entries[b.visible[b.cursor]], true
+}
+
+// HandleKey applies one key as returned by ParseKeys.
+func (b *Browser) HandleKey(key string) {
+	if b.mode != browseNormal {
+		b.handleInput(key)
+		return
+	}
+
+	b.status = ""
+
+	switch key {
+	case "q", "ctrl-c":
+		b.done = true
+	case "down", "j":
+		b.moveTo(b.cursor + 1)
+	case "up", "k":
+		b.moveTo(b.cursor - 1)
+	case "pgdn", " ":
+		b.moveTo(b.cursor + b.page)
+	case "pgup":
+		b.moveTo(b.cursor - b.page)
+	case "home", "g":
+		b.moveTo(0)
+	case "end", "G":
+		b.moveTo(len(b.visible) - 1)
+	case "J":
+		b.detailTop++
+	case "K":
+		b.detailTop = max(b.detailTop-1, 0)
+	case "/":
+		b.mode, b.input, b.origin = browseSearch, "", b.cursor
+	case "n":
+		b.findNext(b.search, 1)
+	case "N":
+		b.findNext(b.search, -1)
+	case "f":
+		b.mode, b.input = browseFilter, b.filter
+	case "c":
+		b.jumpClOrdID(1)
+	case "C":
+		b.jumpClOrdID(-1)
+	case "e":
+		b.jump(1, func(e BrowseEntry) bool { return len(e.Message.Errors) > 0 }, "No other messages with validation errors")
+	}
+}
+
+func (b *Browser) handleInput(key string) {
+	switch key {
+	case "enter":
+		if b.mode == browseFilter {
+			b.applyFilter(b.input)
+		} else {
+			b.search = b.input
+		}
+		b.mode = browseNormal
+		return
+	case "esc", "ctrl-c":
+		if b.mode == browseSearch {
+			b.moveTo(b.origin)
+		}
+		b.mode = browseNormal
+		return
+	case "backspace":
+		if b.input != "" {
+			_, size := utf8.DecodeLastRuneInString(b.input)
+			b.input = b.input[:len(b.input)-size]
+		}
+	default:
+		if utf8.RuneCountInString(key) != 1 {
+			return
+		}
+		b.input += key
+	}
+
+	if b.mode == browseSearch {
+		b.status = ""
+		if pos := b.find(b.input, b.origin, 1); pos >= 0 {
+			b.moveTo(pos)
+		} else if b.input != "" {
+			b.status = "Not found: " + b.input
+		}
+	}
+}
+
+func (b *Browser) moveTo(pos int) {
+	if len(b.visible) == 0 {
+		return
+	}
+
+	pos = min(max(pos, 0), len(b.visible)-1)
+	if pos != b.cursor {
+		b.detailTop = 0
+	}
+	b.cursor = pos
+}
+
+// find returns the first visible position from start (inclusive) in
+// direction step whose entry contains query, wrapping around, or -1.
+func (b *Browser) find(query string, start, step int) int {
+	if query == "" || len(b.visible) == 0 {
+		return -1
+	}
+
+	query = strings.ToLower(query)
+
+	return b.scan(start, step, func(e BrowseEntry) bool { return strings.Contains(e.text, query) })
+}
+
+func (b *Browser) scan(start, step int, match func(BrowseEntry) bool) int {
+	n := len(b.visible)
+	for i := range n {
+		pos := ((start+i*step)%n + n) % n
+		if match(b.entries[b.visible[pos]]) {
+			return pos
+		}
+	}
+	return -1
+}
+
+func (b *Browser) findNext(query string, step int) {
+	if query == "" {
+		b.status = "No search; press / to search"
+		return
+	}
+
+	if pos := b.find(query, b.cursor+step, step); pos >= 0 {
+		b.moveTo(pos)
+	} else {
+		b.status = "Not found: " + query
+	}
+}
+
+// jump moves to the next entry after the cursor in direction step that
+// satisfies match, reporting notFound otherwise.
+func (b *Browser) jump(step int, match func(BrowseEntry) bool, notFound string) {
+	if len(b.visible) == 0 {
+		return
+	}
+
+	pos := b.scan(b.cursor+step, step, match)
+	if pos < 0 || pos == b.cursor {
+		b.status = notFound
+		return
+	}
+
+	b.moveTo(pos)
+}
+
+// jumpClOrdID moves to the next message whose ClOrdID or OrigClOrdID
+// matches the ClOrdID of the selected message.
+func (b *Browser) jumpClOrdID(step int) {
+	cur, ok := b.Current()
+	if !ok {
+		return
+	}
+
+	id, ok := cur.Value(11)
+	if !ok {
+		b.status = "No ClOrdID on this message"
+		return
+	}
+
+	b.jump(step, func(e BrowseEntry) bool {
+		v11, _ := e.Value(11)
+		v41, _ := e.Value(41)
+		return v11 == id || v41 == id
+	}, "No other messages for ClOrdID "+id)
+}
+
+// applyFilter keeps entries whose MsgType or message name is in the
+// comma-separated filter; an empty filter shows everything.
+func (b *Browser) applyFilter(filter string) {
+	var selected BrowseEntry
+	hadSelection := false
+	if cur, ok := b.Current(); ok {
+		selected, hadSelection = cur, true
+	}
+
+	var wanted []string
+	for _, s := range strings.Split(filter, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			wanted = append(wanted, s)
+		}
+	}
+
+	b.filter = strings.Join(wanted, ",")
+	b.visible = b.visible[:0]
+	b.cursor, b.top, b.detailTop = 0, 0, 0
+
+	for i, e := range b.entries {
+		if matchesMsgType(e.Message, wanted) {
+			if hadSelection && e.Source == selected.Source && e.Line == selected.Line && e.Message.Raw == selected.Message.Raw {
+				b.cursor = len(b.visible)
+			}
+			b.visible = append(b.visible, i)
+		}
+	}
+
+	if len(b.visible) == 0 {
+		b.status = "No messages match filter " + b.filter
+	}
+}
+
+func matchesMsgType(dm DecodedMessage, wanted []string) bool {
+	if len(wanted) == 0 {
+		return true
+	}
+
+	for _, w := range wanted {
+		if w == dm.MsgType || strings.EqualFold(w, dm.MsgName) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render draws the browser as height lines of at most width columns: the
+// message list, the detail pane of the selected message and a status line.
+func (b *Browser) Render(width, height int) []string {
+	width, height = max(width, 20), max(height, 6)
+	t := b.Theme
+
+	b.page = max((height-3)*2/5, 1)
+	if b.cursor < b.top {
+		b.top = b.cursor
+	}
+	if b.cursor >= b.top+b.page {
+		b.top = b.cursor - b.page + 1
+	}
+
+	lines := make([]string, 0, height)
+	lines = append(lines, t.Paint(t.Title, padRunes(fmt.Sprintf("  %6s %-24s %-3s %-22s %s", "#", "Time", "Dir", "MsgType", "Key fields"), width)))
+
+	for row := range b.page {
+		pos := b.top + row
+		if pos >= len(b.visible) {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, b.renderRow(pos, width))
+	}
+
+	cur, ok := b.Current()
+	label := " No messages "
+	if ok {
+		label = fmt.Sprintf(" %d/%d  %s:%d ", b.cursor+1, len(b.visible), cur.Source, cur.Line)
+		if b.filter != "" {
+			label += " filter " + b.filter + " "
+		}
+	}
+	lines = append(lines, t.Paint(t.Line, truncateRunes("──"+label+strings.Repeat("─", width), width)))
+
+	detail := browseDetail(cur, ok)
+	detailHeight := height - len(lines) - 1
+	b.detailTop = min(b.detailTop, max(len(detail)-detailHeight, 0))
+
+	for row := range detailHeight {
+		i := b.detailTop + row
+		if i >= len(detail) {
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, t.Paint(detail[i].code(t), truncateRunes(detail[i].text, width)))
+	}
+
+	return append(lines, b.statusLine(width))
+}
+
+func (b *Browser) renderRow(pos, width int) string {
+	t := b.Theme
+	idx := b.visible[pos]
+	e := b.entries[idx]
+
+	name := e.Message.MsgName
+	if name == "" {
+		name = e.Message.MsgType
+	}
+
+	mark := " "
+	if len(e.Message.Errors) > 0 {
+		mark = "!"
+	}
+
+	row := truncateRunes(fmt.Sprintf("%s %6d %-24s %-3s %-22s %s", mark, idx+1, e.Time, e.Direction, name, e.Summary()), width)
+
+	if pos == b.cursor {
+		return sgr("7") + padRunes(row, width) + sgr("0")
+	}
+	if mark == "!" {
+		return t.Paint(t.Error, row)
+	}
+	return row
+}
+
+func (b *Browser) statusLine(width int) string {
+	switch b.mode {
+	case browseSearch:
+		return truncateRunes("/"+b.input, width)
+	case browseFilter:
+		return truncateRunes("Filter MsgType (comma separated, empty for all): "+b.input, width)
+	}
+
+	if b.status != "" {
+		return b.Theme.Paint(b.Theme.Error, truncateRunes(b.status, width))
+	}
+	return b.Theme.Paint(b.Theme.Line, truncateRunes(browseHelp, width))
+}
+
+type detailKind int
+
+const (
+	detailField detailKind = iota
+	detailRaw
+	detailGroup
+	detailError
+)
+
+type detailLine struct {
+	text string
+	kind detailKind
+}
+
+func (d detailLine) code(t Theme) string {
+	switch d.kind {
+	case detailRaw:
+		return t.Message
+	case detailGroup:
+		return t.Title
+	case detailError:
+		return t.Error
+	}
+	return ""
+}
+
+// browseDetail lists the raw message, any validation errors and the
+// decoded field tree of e.
+func browseDetail(e BrowseEntry, ok bool) []detailLine {
+	if !ok {
+		return nil
+	}
+
+	lines := []detailLine{{text: visibleSOH(e.Message.Raw), kind: detailRaw}}
+	for _, err := range e.Message.Errors {
+		lines = append(lines, detailLine{text: "== " + err, kind: detailError})
+	}
+	lines = append(lines, detailLine{})
+
+	return appendDetailFields(lines, e.Message.Fields, tagWidth(e.Message.Flat()), "")
+}
+
+func appendDetailFields(lines []detailLine, fields []DecodedField, width int, indent string) []detailLine {
+	for _, f := range fields {
+		text := fmt.Sprintf("%s%*d %s = %s", indent, width, f.Tag, f.Name, f.Value)
+		if f.Description != "" {
+			text += " (" + f.Description + ")"
+		}
+		lines = append(lines, detailLine{text: text})
+
+		for i, g := range f.Groups {
+			lines = append(lines, detailLine{text: fmt.Sprintf("%s%*s [%d/%d]", indent, width, "", i+1, len(f.Groups)), kind: detailGroup})
+			lines = appendDetailFields(lines, g, width, indent+"    ")
+		}
+	}
+	return lines
+}
+
+func truncateRunes(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+func padRunes(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0))
+}
+
+// ParseKeys splits raw terminal input into key names: printable characters
+// as themselves, plus up, down, left, right, pgup, pgdn, home, end, enter,
+// esc, backspace and ctrl-c.
+func ParseKeys(b []byte) []string {
+	var keys []string
+
+	for i := 0; i < len(b); {
+		switch c := b[i]; {
+		case c == 0x1b && i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O'):
+			j := i + 2
+			for j < len(b) && (b[j] < 0x40 || b[j] > 0x7e) {
+				j++
+			}
+			if j == len(b) {
+				j--
+			}
+			if k, ok := escapeKeys[string(b[i+2:j+1])]; ok {
+				keys = append(keys, k)
+			}
+			i = j + 1
+		case c == 0x1b:
+			keys = append(keys, "esc")
+			i++
+		case c == '\r' || c == '\n':
+			keys = append(keys, "enter")
+			i++
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, "backspace")
+			i++
+		case c == 0x03:
+			keys = append(keys, "ctrl-c")
+			i++
+		case c < 0x20:
+			i++
+		default:
+			r, size := utf8.DecodeRune(b[i:])
+			keys = append(keys, string(r))
+			i += size
+		}
+	}
+
+	return keys
+}
+
+var escapeKeys = map[string]string{
+	"A": "up", "B": "down", "C": "right", "D": "left",
+	"H": "home", "F": "end", "1~": "home", "7~": "home", "4~": "end", "8~": "end",
+	"5~": "pgup", "6~": "pgdn",
+}
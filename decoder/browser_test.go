@@ -0,0 +1,175 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func browseFixture(t *testing.T) *Browser {
+	t.Helper()
+	useRealDecoding(t)
+
+	path := filepath.Join(t.TempDir(), "session.log")
+	content := "12:00:00 OUT " + newOrderWithParties + "\n" +
+		"12:00:01 IN 8=FIX.4.4|9=5|35=0|52=20250101-12:00:01|10=000|\n" +
+		"12:00:02 <- 8=FIX.4.4|9=5|35=8|11=ORD1|39=0|52=20250101-12:00:02|10=000|\n" +
+		"12:00:03 -> 8=FIX.4.4|9=5|35=F|11=ORD2|41=ORD1|10=000|\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, ok := LoadBrowseEntries([]string{path}, LogOptions{DecodeOptions: DecodeOptions{Validate: true}}, os.Stderr)
+	if !ok || len(entries) != 4 {
+		t.Fatalf("expected four entries, got %d (ok=%v)", len(entries), ok)
+	}
+
+	return NewBrowser(entries, NoTheme)
+}
+
+func TestLoadBrowseEntries(t *testing.T) {
+	b := browseFixture(t)
+
+	var dirs []string
+	for _, e := range b.entries {
+		dirs = append(dirs, e.Direction)
+	}
+	if !reflect.DeepEqual(dirs, []string{"OUT", "IN", "IN", "OUT"}) {
+		t.Errorf("unexpected directions %v", dirs)
+	}
+
+	e := b.entries[0]
+	if e.Line != 1 || e.Time != "20250101-12:00:00.123456" || !strings.Contains(e.Summary(), "ClOrdID=ORD1") || !strings.Contains(e.Summary(), "Side=BUY") {
+		t.Errorf("unexpected entry %+v summary %q", e, e.Summary())
+	}
+}
+
+func TestBrowserNavigationAndSearch(t *testing.T) {
+	b := browseFixture(t)
+
+	b.HandleKey("down")
+	if cur, _ := b.Current(); cur.Message.MsgType != "0" {
+		t.Fatalf("expected heartbeat after down, got %q", cur.Message.MsgType)
+	}
+
+	for _, k := range []string{"/", "e", "x", "e", "c"} {
+		b.HandleKey(k)
+	}
+	if cur, _ := b.Current(); cur.Message.MsgType != "8" {
+		t.Fatalf("expected incremental search to find the execution report, got %q", cur.Message.MsgType)
+	}
+
+	b.HandleKey("esc")
+	if cur, _ := b.Current(); cur.Message.MsgType != "0" {
+		t.Errorf("expected esc to restore position, got %q", cur.Message.MsgType)
+	}
+
+	b.HandleKey("/")
+	for _, k := range ParseKeys([]byte("orD2\r")) {
+		b.HandleKey(k)
+	}
+	b.HandleKey("n")
+	if cur, _ := b.Current(); cur.Message.MsgType != "F" || b.status != "" {
+		t.Errorf("expected n to stay on the only match, got %q status %q", cur.Message.MsgType, b.status)
+	}
+
+	b.HandleKey("end")
+	b.HandleKey("home")
+	if b.cursor != 0 {
+		t.Errorf("expected home to go to the first message, got %d", b.cursor)
+	}
+}
+
+func TestBrowserClOrdIDJumpAndFilter(t *testing.T) {
+	b := browseFixture(t)
+
+	b.HandleKey("c")
+	if cur, _ := b.Current(); cur.Message.MsgType != "8" {
+		t.Fatalf("expected jump to execution report for ORD1, got %q", cur.Message.MsgType)
+	}
+
+	b.HandleKey("c")
+	if cur, _ := b.Current(); cur.Message.MsgType != "F" {
+		t.Fatalf("expected jump to cancel referencing ORD1, got %q", cur.Message.MsgType)
+	}
+
+	b.HandleKey("c")
+	if !strings.Contains(b.status, "ORD2") {
+		t.Errorf("expected no further ORD2 messages, got status %q", b.status)
+	}
+
+	b.HandleKey("f")
+	for _, k := range ParseKeys([]byte("\x7fD, heartbeat\r")) {
+		b.HandleKey(k)
+	}
+	if len(b.visible) != 2 || b.filter != "D,heartbeat" {
+		t.Fatalf("expected two filtered messages, got %v filter %q", b.visible, b.filter)
+	}
+
+	b.HandleKey("f")
+	for _, k := range ParseKeys([]byte(strings.Repeat("\x7f", 20) + "Z\r")) {
+		b.HandleKey(k)
+	}
+	if _, ok := b.Current(); ok || !strings.Contains(b.status, "No messages match") {
+		t.Errorf("expected empty filter result, status %q", b.status)
+	}
+}
+
+func TestBrowserRender(t *testing.T) {
+	b := browseFixture(t)
+
+	lines := b.Render(100, 30)
+	if len(lines) != 30 {
+		t.Fatalf("expected 30 lines, got %d", len(lines))
+	}
+
+	frame := strings.Join(lines, "\n")
+	for _, want := range []string{"NewOrderSingle", "ClOrdID=ORD1", "== ", "[1/2]", "    448 PartyID = P1", browseHelp[:10]} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("expected %q in frame:\n%s", want, frame)
+		}
+	}
+
+	b.HandleKey("e")
+	if b.cursor != 1 {
+		t.Errorf("expected e to move to the next message with errors, got %d", b.cursor)
+	}
+
+	b.HandleKey("q")
+	if !b.Done() {
+		t.Error("expected q to quit")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	got := ParseKeys([]byte("a\x1b[A\x1b[6~\x1bOH\x1b\r\x03é"))
+	want := []string{"a", "up", "pgdn", "home", "esc", "enter", "ctrl-c", "é"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseKeys = %v, want %v", got, want)
+	}
+}
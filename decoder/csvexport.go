@@ -0,0 +1,267 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+// DefaultCSVColumns are exported when no columns are requested.
+var DefaultCSVColumns = []string{"SendingTime", "SenderCompID", "TargetCompID", "MsgSeqNum", "MsgType"}
+
+// CSVOptions controls WriteCSV.
+type CSVOptions struct {
+	Columns      []string          // tag numbers or field names
+	Delimiter    rune              // ',' for CSV, '\t' for TSV
+	Descriptions bool              // write enum descriptions instead of raw values
+	Group        string            // NumInGroup tag or name: one row per group instance
+	Dictionary   *CustomDictionary // nil uses the embedded dictionaries
+}
+
+type csvColumn struct {
+	Tag    int
+	Header string
+}
+
+// dictionarySearchOrder is used to resolve field names that are not in the
+// default FIX 4.4 dictionary.
+var dictionarySearchOrder = []string{"FIX44", "FIX50SP2", "FIX50SP1", "FIX50", "FIX43", "FIX42", "FIX41", "FIX40", "FIXT11"}
+
+// csvDictionaries lists the dictionaries column specs are resolved
+// against: custom first, when given, then the embedded ones.
+func csvDictionaries(custom *CustomDictionary) []*FixTagLookup {
+	var dicts []*FixTagLookup
+	if custom != nil {
+		dicts = append(dicts, custom.own)
+	}
+
+	for _, key := range dictionarySearchOrder {
+		if d := getDictionary(key); d != nil {
+			dicts = append(dicts, d)
+		}
+	}
+	return dicts
+}
+
+// resolveField turns a tag number or field name, matched regardless of
+// case, into a tag and the name the first of dicts to know it gives it.
+func resolveField(spec string, dicts []*FixTagLookup) (int, string, error) {
+	spec = strings.TrimSpace(spec)
+
+	if tag, err := strconv.Atoi(spec); err == nil {
+		for _, d := range dicts {
+			if name, ok := d.tagToName[tag]; ok {
+				return tag, name, nil
+			}
+		}
+		return tag, spec, nil
+	}
+
+	for _, d := range dicts {
+		if tag, ok := d.GetFieldTag(spec); ok {
+			return tag, d.GetFieldName(tag), nil
+		}
+	}
+
+	for _, d := range dicts {
+		for tag, name := range d.tagToName {
+			if strings.EqualFold(name, spec) {
+				return tag, name, nil
+			}
+		}
+	}
+
+	return 0, "", fmt.Errorf("unknown field %q", spec)
+}
+
+func resolveColumns(specs []string, dicts []*FixTagLookup) ([]csvColumn, error) {
+	if len(specs) == 0 {
+		specs = DefaultCSVColumns
+	}
+
+	cols := make([]csvColumn, 0, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+
+		tag, name, err := resolveField(spec, dicts)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, csvColumn{Tag: tag, Header: name})
+	}
+
+	return cols, nil
+}
+
+// WriteCSV streams one row per FIX message (or per instance of opts.Group)
+// found in paths to out, preceded by a header row of field names. A message
+// without opts.Group still gets one row of its own fields.
+func WriteCSV(paths []string, out io.Writer, errOut io.Writer, obfuscator *fix.Obfuscator, opts CSVOptions) int {
+	dicts := csvDictionaries(opts.Dictionary)
+
+	cols, err := resolveColumns(opts.Columns, dicts)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+
+	groupTag := 0
+	if opts.Group != "" {
+		if groupTag, _, err = resolveField(opts.Group, dicts); err != nil {
+			fmt.Fprintln(errOut, err)
+			return 1
+		}
+	}
+
+	w := csv.NewWriter(out)
+	if opts.Delimiter != 0 {
+		w.Comma = opts.Delimiter
+	}
+
+	header := make([]string, len(cols))
+	for i, c := range cols {
+		header[i] = c.Header
+	}
+	_ = w.Write(header)
+
+	exp := csvExporter{cols: cols, groupTag: groupTag, descriptions: opts.Descriptions, dictionary: opts.Dictionary}
+
+	ok := forEachInput(paths, errOut, func(_ string, r io.Reader) error {
+		scanner := newLineScanner(r)
+		for scanner.Scan() {
+			text, spans := sanitiseLine(scanner.Text(), findFixMessageIndices(scanner.Text()), obfuscator, errOut)
+
+			for _, span := range spans {
+				for _, row := range exp.rows(NormaliseDelimiters(text[span[0]:span[1]])) {
+					if err := w.Write(row); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return scanner.Err()
+	})
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		fmt.Fprintln(errOut, "Error writing CSV:"+err.Error())
+		return 1
+	}
+
+	if !ok {
+		return 1
+	}
+
+	return 0
+}
+
+type csvExporter struct {
+	cols         []csvColumn
+	groupTag     int
+	descriptions bool
+	dictionary   *CustomDictionary
+}
+
+func (e csvExporter) rows(msg string) [][]string {
+	dict := e.dictionary.Lookup(msg)
+	fields := parseFix(msg)
+	values := firstValues(fields)
+
+	if e.groupTag == 0 {
+		return [][]string{e.row(dict, values, nil)}
+	}
+
+	instances := findGroupInstances(NewMessageFromFields(fields, dict), e.groupTag)
+	if len(instances) == 0 {
+		return [][]string{e.row(dict, values, nil)}
+	}
+
+	rows := make([][]string, 0, len(instances))
+	for _, inst := range instances {
+		rows = append(rows, e.row(dict, values, firstValues(inst.appendFlat(nil))))
+	}
+
+	return rows
+}
+
+// row prefers values from the group instance, falling back to the message.
+func (e csvExporter) row(dict *FixTagLookup, msgValues, instValues map[int]string) []string {
+	row := make([]string, len(e.cols))
+
+	for i, c := range e.cols {
+		v, ok := instValues[c.Tag]
+		if !ok {
+			v = msgValues[c.Tag]
+		}
+
+		if e.descriptions {
+			if desc := dict.GetEnumDescription(c.Tag, v); desc != "" {
+				v = desc
+			}
+		}
+
+		row[i] = v
+	}
+
+	return row
+}
+
+func firstValues(fields []FieldValue) map[int]string {
+	values := make(map[int]string, len(fields))
+	for _, fv := range fields {
+		if _, seen := values[fv.Tag]; !seen {
+			values[fv.Tag] = fv.Value
+		}
+	}
+	return values
+}
+
+// findGroupInstances collects every instance of the group counted by
+// countTag, wherever it is nested in m.
+func findGroupInstances(m *FixMessage, countTag int) []*FieldMap {
+	var out []*FieldMap
+	for _, section := range m.sections() {
+		out = appendGroupInstances(out, section, countTag)
+	}
+	return out
+}
+
+func appendGroupInstances(out []*FieldMap, fm *FieldMap, countTag int) []*FieldMap {
+	for _, f := range fm.Fields {
+		if f.Tag == countTag {
+			out = append(out, f.Groups...)
+		}
+		for _, g := range f.Groups {
+			out = appendGroupInstances(out, g, countTag)
+		}
+	}
+	return out
+}
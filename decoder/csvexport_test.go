@@ -0,0 +1,107 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+func writeCSVLog(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "session.log")
+	content := "OUT " + newOrderWithParties + "\n" +
+		"no fix here\n" +
+		"IN 8=FIX.4.4|9=5|35=0|34=3|10=000|\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWriteCSVRowPerMessage(t *testing.T) {
+	useRealDecoding(t)
+
+	var out, errOut bytes.Buffer
+	code := WriteCSV([]string{writeCSVLog(t)}, &out, &errOut, fix.CreateObfuscator(nil, false),
+		CSVOptions{Columns: []string{"35", "ClOrdID", "Side"}, Delimiter: ','})
+
+	want := "MsgType,ClOrdID,Side\nD,ORD1,1\n0,,\n"
+	if code != 0 || out.String() != want {
+		t.Fatalf("code=%d err=%q\n got: %q\nwant: %q", code, errOut.String(), out.String(), want)
+	}
+}
+
+func TestWriteCSVGroupRowsWithDescriptions(t *testing.T) {
+	useRealDecoding(t)
+
+	var out, errOut bytes.Buffer
+	code := WriteCSV([]string{writeCSVLog(t)}, &out, &errOut, fix.CreateObfuscator(nil, false),
+		CSVOptions{Columns: []string{"clordid", "PartyID", "PartyRole", "Side", "MsgSeqNum"}, Delimiter: '\t', Descriptions: true, Group: "noPartyIDs"})
+
+	want := "ClOrdID\tPartyID\tPartyRole\tSide\tMsgSeqNum\n" +
+		"ORD1\tP1\tEXECUTING_FIRM\tBUY\t2\n" +
+		"ORD1\tP2\tCLIENT_ID\tBUY\t2\n" +
+		"\t\t\t\t3\n"
+	if code != 0 || out.String() != want {
+		t.Fatalf("code=%d err=%q\n got: %q\nwant: %q", code, errOut.String(), out.String(), want)
+	}
+}
+
+func TestWriteCSVCustomDictionaryColumns(t *testing.T) {
+	useRealDecoding(t)
+
+	custom, err := NewCustomDictionary(orchestraXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "venue.log")
+	if err := os.WriteFile(path, []byte("IN 8=FIX.4.4|9=5|35=0|5001=X|10=000|\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	code := WriteCSV([]string{path}, &out, &errOut, nil,
+		CSVOptions{Columns: []string{"5001", "venuetag", "MsgType"}, Delimiter: ',', Dictionary: custom})
+
+	want := "VenueTag,VenueTag,MsgType\nX,X,0\n"
+	if code != 0 || out.String() != want {
+		t.Fatalf("code=%d err=%q\n got: %q\nwant: %q", code, errOut.String(), out.String(), want)
+	}
+}
+
+func TestWriteCSVUnknownColumn(t *testing.T) {
+	var out, errOut bytes.Buffer
+	code := WriteCSV([]string{"-"}, &out, &errOut, nil, CSVOptions{Columns: []string{"NoSuchField"}})
+
+	if code != 1 || !strings.Contains(errOut.String(), `unknown field "NoSuchField"`) {
+		t.Fatalf("expected unknown field error, got code=%d err=%q", code, errOut.String())
+	}
+}
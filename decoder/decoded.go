@@ -0,0 +1,213 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"io"
+	"strings"
+)
+
+// DecodedField is a field as presented to the user. For a NumInGroup
+// field, Groups holds the decoded fields of each group instance.
+type DecodedField struct {
+	Tag         int              `json:"tag"`
+	Name        string           `json:"name"`
+	Value       string           `json:"value"`
+	Description string           `json:"description,omitempty"` // enum description, if any
+	Groups      [][]DecodedField `json:"groups,omitempty"`
+}
+
+// DecodedMessage is a FIX message decoded against its dictionary, plus any
+// validation findings. Errors holds the text of each finding. Every output
+// format renders from this structure.
+type DecodedMessage struct {
+	Raw      string         `json:"raw"`
+	MsgType  string         `json:"msgType"`
+	MsgName  string         `json:"msgName"`
+	Fields   []DecodedField `json:"fields"`
+	Errors   []string       `json:"errors,omitempty"`
+	Findings []Finding      `json:"-"`
+	Reject   string         `json:"reject,omitempty"` // SOH-delimited reply, see BuildReject
+	Prefix   *LinePrefix    `json:"prefix,omitempty"` // set when a prefix parser is active
+}
+
+// DecodedLine is a sanitised log line and the messages found in it.
+// Spans holds the [start, end) offsets of each message within Text, and
+// Offsets where each message started in the original line.
+type DecodedLine struct {
+	Text     string
+	Spans    [][]int
+	Offsets  []int
+	Messages []DecodedMessage
+}
+
+// DecodeMessage decodes msg against dict without validating it.
+func DecodeMessage(msg string, dict *FixTagLookup) DecodedMessage {
+	m := NewMessageFromFields(parseFix(msg), dict)
+	msgType := m.MsgType()
+
+	dm := DecodedMessage{
+		Raw:     msg,
+		MsgType: msgType,
+		MsgName: dict.GetEnumDescription(35, msgType),
+	}
+
+	for _, section := range m.sections() {
+		dm.Fields = append(dm.Fields, decodeFieldMap(section, dict)...)
+	}
+
+	return dm
+}
+
+func decodeFieldMap(fm *FieldMap, dict *FixTagLookup) []DecodedField {
+	out := make([]DecodedField, 0, len(fm.Fields))
+
+	for _, f := range fm.Fields {
+		df := DecodedField{
+			Tag:         f.Tag,
+			Name:        dict.GetFieldName(f.Tag),
+			Value:       f.Value,
+			Description: dict.GetEnumDescription(f.Tag, f.Value),
+		}
+
+		for _, g := range f.Groups {
+			df.Groups = append(df.Groups, decodeFieldMap(g, dict))
+		}
+
+		out = append(out, df)
+	}
+
+	return out
+}
+
+// Flat returns the message fields in wire order with groups expanded.
+func (dm DecodedMessage) Flat() []DecodedField {
+	return appendFlatDecoded(nil, dm.Fields)
+}
+
+func appendFlatDecoded(out []DecodedField, fields []DecodedField) []DecodedField {
+	for _, f := range fields {
+		out = append(out, f)
+		for _, g := range f.Groups {
+			out = appendFlatDecoded(out, g)
+		}
+	}
+	return out
+}
+
+// DecodeOptions controls how messages are decoded and checked. The zero
+// value decodes without validating. Decoding only reads it, so one value
+// may be shared by concurrent decoders.
+type DecodeOptions struct {
+	Validate   bool              // attach validation findings
+	Rules      []Rule            // conditional rules checked on top of each dictionary's
+	Profiles   *ProfileSet       // counterparty profiles; nil checks none
+	Rejects    bool              // with Validate, attach the reply a counterparty would send
+	Prefix     PrefixParser      // reads the text before each message; nil reads none
+	Dictionary *CustomDictionary // nil uses the embedded dictionaries
+}
+
+// decodeFixMessage picks the dictionary for msg, decodes it and, when
+// opts asks for validation, attaches the findings.
+func decodeFixMessage(msg string, opts DecodeOptions) DecodedMessage {
+	msg = NormaliseDelimiters(msg)
+	dict := opts.Dictionary.Lookup(msg)
+	dm := DecodeMessage(msg, dict)
+
+	if opts.Validate {
+		dm.Findings = ValidateFindings(dm.Raw, dict, opts)
+		dm.Errors = findingTexts(dm.Findings)
+		if opts.Rejects {
+			dm.Reject, _ = BuildReject(dm.Raw, dm.Findings, dict)
+		}
+	}
+
+	return dm
+}
+
+// showAs replaces the raw text and fields of dm with those of shown, the
+// sanitised text of the same message, keeping its findings and reply.
+func (dm *DecodedMessage) showAs(shown string, dict *FixTagLookup) {
+	decoded := DecodeMessage(shown, dict)
+	dm.Raw, dm.Fields = decoded.Raw, decoded.Fields
+}
+
+// DecodeText decodes every FIX message in a pasted message or log snippet.
+// Text holding no complete message is decoded as one message if it starts
+// with BeginString, so a message pasted without its CheckSum still decodes.
+func DecodeText(text string, opts DecodeOptions) []DecodedMessage {
+	var msgs []string
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		for _, span := range findFixMessageIndices(line) {
+			msgs = append(msgs, line[span[0]:span[1]])
+		}
+	}
+
+	if trimmed := strings.TrimSpace(text); len(msgs) == 0 && strings.HasPrefix(trimmed, "8=") {
+		msgs = append(msgs, trimmed)
+	}
+
+	out := make([]DecodedMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		out = append(out, decodeFixMessage(msg, opts))
+	}
+
+	return out
+}
+
+// DecodeLogLine sanitises line with opts.Obfuscator, frames the FIX
+// messages in it and decodes each one. Messages are validated as logged,
+// so aliases do not change their BodyLength, CheckSum or CompIDs; only
+// the decoded fields and the reply show the sanitised values.
+func DecodeLogLine(line string, opts LogOptions, errOut io.Writer) DecodedLine {
+	matches := findFixMessageIndices(line)
+	text, spans := sanitiseLine(line, matches, opts.Obfuscator, errOut)
+	dl := DecodedLine{Text: text, Spans: spans}
+
+	for _, m := range matches {
+		dl.Offsets = append(dl.Offsets, m[0])
+	}
+
+	last := 0
+	for i, span := range spans {
+		dm := decodeFixMessage(line[matches[i][0]:matches[i][1]], opts.DecodeOptions)
+		if shown := NormaliseDelimiters(text[span[0]:span[1]]); shown != dm.Raw {
+			dm.showAs(shown, opts.Dictionary.Lookup(shown))
+			if reject := opts.Obfuscator.Enabled(dm.Reject, errOut); reject != dm.Reject {
+				// Aliases change the lengths, so frame the reply again.
+				dm.Reject = NewMessageFromFields(ParseFix(reject), opts.Dictionary.Lookup(reject)).Encode()
+			}
+		}
+		if opts.Prefix != nil {
+			if lp, ok := opts.Prefix.Parse(text[last:span[0]]); ok {
+				dm.Prefix = &lp
+			}
+		}
+		dl.Messages = append(dl.Messages, dm)
+		last = span[1]
+	}
+
+	return dl
+}
@@ -0,0 +1,134 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+// useRealDecoding undoes any loadDictionary/parseFix mocks left by other tests.
+func useRealDecoding(t *testing.T) {
+	t.Helper()
+
+	origLoad, origParse := loadDictionary, parseFix
+	loadDictionary, parseFix = LoadDictionary, ParseFix
+
+	t.Cleanup(func() { loadDictionary, parseFix = origLoad, origParse })
+}
+
+func TestDecodeMessageNestsGroups(t *testing.T) {
+	useRealDecoding(t)
+
+	dm := DecodeMessage(newOrderWithParties, LoadDictionary(newOrderWithParties))
+
+	if dm.MsgType != "D" || dm.MsgName != "NewOrderSingle" {
+		t.Errorf("unexpected MsgType %q (%q)", dm.MsgType, dm.MsgName)
+	}
+
+	var parties *DecodedField
+	for i := range dm.Fields {
+		if dm.Fields[i].Tag == 453 {
+			parties = &dm.Fields[i]
+		}
+	}
+
+	if parties == nil || len(parties.Groups) != 2 || parties.Groups[0][0].Name != "PartyID" {
+		t.Fatalf("expected two decoded party instances, got %+v", parties)
+	}
+
+	if got := len(dm.Flat()); got != len(ParseFix(newOrderWithParties)) {
+		t.Errorf("expected flattened fields to match wire fields, got %d", got)
+	}
+}
+
+func TestDecodeLogLineValidation(t *testing.T) {
+	useRealDecoding(t)
+
+	line := "IN 8=FIX.4.4|9=5|35=0|10=000| done"
+	opts := LogOptions{DecodeOptions: DecodeOptions{Validate: true}, Obfuscator: fix.CreateObfuscator(nil, false)}
+	dl := DecodeLogLine(line, opts, io.Discard)
+
+	if len(dl.Messages) != 1 || dl.Messages[0].MsgName != "Heartbeat" {
+		t.Fatalf("expected one heartbeat, got %+v", dl.Messages)
+	}
+
+	if len(dl.Messages[0].Errors) == 0 {
+		t.Error("expected validation errors for bad checksum")
+	}
+
+	if !strings.HasPrefix(dl.Text[dl.Spans[0][0]:], "8=FIX.4.4|") {
+		t.Errorf("unexpected span %v in %q", dl.Spans, dl.Text)
+	}
+}
+
+func TestDecodeLogLineValidatesBeforeObfuscating(t *testing.T) {
+	useRealDecoding(t)
+
+	p, err := parseProfile(strings.NewReader(venueProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := NewProfileSet([]Profile{p}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := LogOptions{
+		DecodeOptions: DecodeOptions{Validate: true, Profiles: profiles},
+		Obfuscator:    fix.CreateObfuscator(fix.SensitiveTagNames, true),
+	}
+	line := "IN " + framedFIX44("35=B|49=VENUE|56=CLIENT|34=1|52=20240101-00:00:00|148=Hi|33=0|")
+	dm := DecodeLogLine(line, opts, io.Discard).Messages[0]
+
+	if !slices.Contains(dm.Errors, "[VENUE] MsgType B not allowed") {
+		t.Errorf("expected the profile for the logged CompIDs, got %v", dm.Errors)
+	}
+	if strings.Contains(dm.Raw, "VENUE") || !strings.Contains(dm.Raw, "49=SenderCompID0001") {
+		t.Errorf("expected the decoded message to be obfuscated, got %q", dm.Raw)
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	useRealDecoding(t)
+
+	text := "IN 8=FIX.4.4|9=5|35=0|10=000|\r\nnoise\nOUT " + newOrderWithParties + "\n"
+	msgs := DecodeText(text, DecodeOptions{Validate: true})
+
+	if len(msgs) != 2 || msgs[0].MsgType != "0" || msgs[1].MsgName != "NewOrderSingle" || len(msgs[0].Errors) == 0 {
+		t.Fatalf("unexpected messages %+v", msgs)
+	}
+
+	msgs = DecodeText(" 8=FIX.4.4|35=A|108=30 ", DecodeOptions{})
+	if len(msgs) != 1 || msgs[0].MsgType != "A" || msgs[0].Errors != nil {
+		t.Fatalf("expected message without CheckSum to decode, got %+v", msgs)
+	}
+
+	if msgs := DecodeText("no fix here", DecodeOptions{Validate: true}); len(msgs) != 0 {
+		t.Fatalf("expected no messages, got %+v", msgs)
+	}
+}
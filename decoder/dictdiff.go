@@ -0,0 +1,545 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DictDiff lists the differences between two dictionaries. Fields are
+// matched by tag, messages by MsgType and components and groups by name.
+type DictDiff struct {
+	From       string            `json:"from"`
+	To         string            `json:"to"`
+	Fields     FieldDiff         `json:"fields"`
+	Messages   MessageDiff       `json:"messages"`
+	Components StructureDiff     `json:"components"`
+	Groups     []StructureChange `json:"groups,omitempty"`
+}
+
+// FieldDiff holds the field differences.
+type FieldDiff struct {
+	Added       []DiffField  `json:"added,omitempty"`
+	Removed     []DiffField  `json:"removed,omitempty"`
+	Renamed     []DiffChange `json:"renamed,omitempty"`
+	TypeChanged []DiffChange `json:"typeChanged,omitempty"`
+	Enums       []EnumChange `json:"enums,omitempty"`
+}
+
+// DiffField identifies a field added or removed.
+type DiffField struct {
+	Tag  int    `json:"tag"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// DiffChange records a field's old and new name or type.
+type DiffChange struct {
+	Tag  int    `json:"tag"`
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// EnumChange lists the enum values added to or removed from a field.
+type EnumChange struct {
+	Tag     int      `json:"tag"`
+	Name    string   `json:"name"`
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// MessageDiff holds the message differences.
+type MessageDiff struct {
+	Added   []DiffMessage   `json:"added,omitempty"`
+	Removed []DiffMessage   `json:"removed,omitempty"`
+	Changed []MessageChange `json:"changed,omitempty"`
+}
+
+// DiffMessage identifies a message added or removed.
+type DiffMessage struct {
+	MsgType string `json:"msgType"`
+	Name    string `json:"name"`
+}
+
+// MessageChange describes a message present in both dictionaries.
+// RequiredAdded and RequiredRemoved compare the fields a message must
+// carry, including those of its required components.
+type MessageChange struct {
+	MsgType         string   `json:"msgType"`
+	Name            string   `json:"name"`
+	RenamedFrom     string   `json:"renamedFrom,omitempty"`
+	RequiredAdded   []string `json:"requiredAdded,omitempty"`
+	RequiredRemoved []string `json:"requiredRemoved,omitempty"`
+	StructureChange
+}
+
+// StructureDiff holds the component differences.
+type StructureDiff struct {
+	Added   []string          `json:"added,omitempty"`
+	Removed []string          `json:"removed,omitempty"`
+	Changed []StructureChange `json:"changed,omitempty"`
+}
+
+// StructureChange lists the direct members ("field Price", "component
+// Parties", "group NoAllocs") added to or removed from a message,
+// component or group, and those whose required flag changed.
+type StructureChange struct {
+	Name            string   `json:"name,omitempty"`
+	MembersAdded    []string `json:"membersAdded,omitempty"`
+	MembersRemoved  []string `json:"membersRemoved,omitempty"`
+	RequiredChanged []string `json:"requiredChanged,omitempty"`
+}
+
+func (s StructureChange) empty() bool {
+	return len(s.MembersAdded) == 0 && len(s.MembersRemoved) == 0 && len(s.RequiredChanged) == 0
+}
+
+// Empty reports whether the dictionaries are the same.
+func (d DictDiff) Empty() bool {
+	f := d.Fields
+	return len(f.Added)+len(f.Removed)+len(f.Renamed)+len(f.TypeChanged)+len(f.Enums) == 0 &&
+		len(d.Messages.Added)+len(d.Messages.Removed)+len(d.Messages.Changed) == 0 &&
+		len(d.Components.Added)+len(d.Components.Removed)+len(d.Components.Changed) == 0 &&
+		len(d.Groups) == 0
+}
+
+// DiffDictionaries compares from with to.
+func DiffDictionaries(from, to SchemaTree) DictDiff {
+	return DictDiff{
+		Fields:     diffFields(from, to),
+		Messages:   diffMessages(from, to),
+		Components: diffComponents(from, to),
+		Groups:     diffGroups(from, to),
+	}
+}
+
+func fieldsByTag(schema SchemaTree) map[int]Field {
+	out := make(map[int]Field, len(schema.Fields))
+	for _, f := range schema.Fields {
+		out[f.Number] = f
+	}
+	return out
+}
+
+func diffFields(from, to SchemaTree) FieldDiff {
+	var d FieldDiff
+	old, cur := fieldsByTag(from), fieldsByTag(to)
+
+	for _, tag := range sortedKeys(old, cur) {
+		of, inOld := old[tag]
+		nf, inNew := cur[tag]
+
+		switch {
+		case !inOld:
+			d.Added = append(d.Added, DiffField{Tag: tag, Name: nf.Name, Type: nf.Type})
+		case !inNew:
+			d.Removed = append(d.Removed, DiffField{Tag: tag, Name: of.Name, Type: of.Type})
+		default:
+			if of.Name != nf.Name {
+				d.Renamed = append(d.Renamed, DiffChange{Tag: tag, Name: nf.Name, From: of.Name, To: nf.Name})
+			}
+			if of.Type != nf.Type {
+				d.TypeChanged = append(d.TypeChanged, DiffChange{Tag: tag, Name: nf.Name, From: of.Type, To: nf.Type})
+			}
+			if added, removed := diffEnums(of.Values, nf.Values); len(added)+len(removed) > 0 {
+				d.Enums = append(d.Enums, EnumChange{Tag: tag, Name: nf.Name, Added: added, Removed: removed})
+			}
+		}
+	}
+
+	return d
+}
+
+func sortedKeys(a, b map[int]Field) []int {
+	keys := make([]int, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// diffEnums returns the enum values, as "VALUE (Description)", in one
+// list but not the other.
+func diffEnums(from, to []Value) (added, removed []string) {
+	old := make(map[string]bool, len(from))
+	for _, v := range from {
+		old[v.Enum] = true
+	}
+
+	cur := make(map[string]bool, len(to))
+	for _, v := range to {
+		cur[v.Enum] = true
+		if !old[v.Enum] {
+			added = append(added, v.Enum+" ("+v.Description+")")
+		}
+	}
+
+	for _, v := range from {
+		if !cur[v.Enum] {
+			removed = append(removed, v.Enum+" ("+v.Description+")")
+		}
+	}
+
+	return added, removed
+}
+
+func messagesByType(schema SchemaTree) map[string]MessageNode {
+	out := make(map[string]MessageNode, len(schema.Messages))
+	for _, m := range schema.Messages {
+		out[m.MsgType] = m
+	}
+	return out
+}
+
+func diffMessages(from, to SchemaTree) MessageDiff {
+	var d MessageDiff
+	old, cur := messagesByType(from), messagesByType(to)
+
+	for _, msgType := range sortedNames(old, cur) {
+		om, inOld := old[msgType]
+		nm, inNew := cur[msgType]
+
+		switch {
+		case !inOld:
+			d.Added = append(d.Added, DiffMessage{MsgType: msgType, Name: nm.Name})
+		case !inNew:
+			d.Removed = append(d.Removed, DiffMessage{MsgType: msgType, Name: om.Name})
+		default:
+			change := MessageChange{
+				MsgType:         msgType,
+				Name:            nm.Name,
+				StructureChange: diffMembers(structureMembers(om.Fields, om.Components, om.Groups), structureMembers(nm.Fields, nm.Components, nm.Groups)),
+			}
+			if om.Name != nm.Name {
+				change.RenamedFrom = om.Name
+			}
+			change.RequiredAdded, change.RequiredRemoved = diffNames(
+				requiredFields(nil, om.Fields, om.Components, om.Groups),
+				requiredFields(nil, nm.Fields, nm.Components, nm.Groups))
+
+			if change.RenamedFrom != "" || len(change.RequiredAdded)+len(change.RequiredRemoved) > 0 || !change.StructureChange.empty() {
+				d.Changed = append(d.Changed, change)
+			}
+		}
+	}
+
+	return d
+}
+
+func sortedNames[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// requiredFields appends the names of the required fields and groups,
+// descending into required components.
+func requiredFields(out []string, fields []FieldNode, comps []ComponentNode, groups []GroupNode) []string {
+	for _, f := range fields {
+		if f.Ref.Required == "Y" {
+			out = append(out, f.Field.Name)
+		}
+	}
+	for _, c := range comps {
+		if c.Required == "Y" {
+			out = requiredFields(out, c.Fields, c.Components, c.Groups)
+		}
+	}
+	for _, g := range groups {
+		if g.Required == "Y" {
+			out = append(out, g.Name)
+		}
+	}
+	return out
+}
+
+// diffNames returns the names in to but not from, and in from but not to.
+func diffNames(from, to []string) (added, removed []string) {
+	old := make(map[string]bool, len(from))
+	for _, n := range from {
+		old[n] = true
+	}
+
+	cur := make(map[string]bool, len(to))
+	for _, n := range to {
+		cur[n] = true
+		if !old[n] {
+			added = append(added, n)
+		}
+	}
+
+	for _, n := range from {
+		if !cur[n] {
+			removed = append(removed, n)
+		}
+	}
+
+	return added, removed
+}
+
+// memberSet holds the direct members of a message, component or group,
+// e.g. "field Price", in dictionary order and whether each is required.
+type memberSet struct {
+	order    []string
+	required map[string]bool
+}
+
+func structureMembers(fields []FieldNode, comps []ComponentNode, groups []GroupNode) memberSet {
+	ms := memberSet{required: make(map[string]bool)}
+
+	add := func(name string, req string) {
+		ms.order = append(ms.order, name)
+		ms.required[name] = req == "Y"
+	}
+
+	for _, f := range fields {
+		add("field "+f.Field.Name, f.Ref.Required)
+	}
+	for _, c := range comps {
+		add("component "+c.Name, c.Required)
+	}
+	for _, g := range groups {
+		add("group "+g.Name, g.Required)
+	}
+
+	return ms
+}
+
+func diffMembers(from, to memberSet) StructureChange {
+	var s StructureChange
+	s.MembersAdded, s.MembersRemoved = diffNames(from.order, to.order)
+
+	for _, name := range to.order {
+		if old, ok := from.required[name]; ok && old != to.required[name] {
+			s.RequiredChanged = append(s.RequiredChanged, fmt.Sprintf("%s (%s -> %s)", name, requiredFlag(old), requiredFlag(to.required[name])))
+		}
+	}
+
+	return s
+}
+
+func requiredFlag(required bool) string {
+	if required {
+		return "required"
+	}
+	return "optional"
+}
+
+func diffComponents(from, to SchemaTree) StructureDiff {
+	var d StructureDiff
+
+	for _, name := range sortedNames(from.Components, to.Components) {
+		oc, inOld := from.Components[name]
+		nc, inNew := to.Components[name]
+
+		switch {
+		case !inOld:
+			d.Added = append(d.Added, name)
+		case !inNew:
+			d.Removed = append(d.Removed, name)
+		default:
+			change := diffMembers(structureMembers(oc.Fields, oc.Components, oc.Groups), structureMembers(nc.Fields, nc.Components, nc.Groups))
+			if !change.empty() {
+				change.Name = name
+				d.Changed = append(d.Changed, change)
+			}
+		}
+	}
+
+	return d
+}
+
+// collectGroups maps each group to its path, e.g. "NewOrderSingle/NoAllocs".
+// Groups inside components are reached through the component, not through
+// every message that uses it.
+func collectGroups(schema SchemaTree) map[string]GroupNode {
+	out := make(map[string]GroupNode)
+
+	var walk func(prefix string, groups []GroupNode)
+	walk = func(prefix string, groups []GroupNode) {
+		for _, g := range groups {
+			path := prefix + "/" + g.Name
+			out[path] = g
+			walk(path, g.Groups)
+		}
+	}
+
+	for _, m := range schema.Messages {
+		walk(m.Name, m.Groups)
+	}
+	for _, c := range schema.Components {
+		walk(c.Name, c.Groups)
+	}
+
+	return out
+}
+
+// diffGroups compares the groups present in both dictionaries; groups
+// added or removed are reported as members of their parent.
+func diffGroups(from, to SchemaTree) []StructureChange {
+	var out []StructureChange
+	old, cur := collectGroups(from), collectGroups(to)
+
+	for _, path := range sortedNames(old, cur) {
+		og, inOld := old[path]
+		ng, inNew := cur[path]
+		if !inOld || !inNew {
+			continue
+		}
+
+		change := diffMembers(structureMembers(og.Fields, og.Components, og.Groups), structureMembers(ng.Fields, ng.Components, ng.Groups))
+		if !change.empty() {
+			change.Name = path
+			out = append(out, change)
+		}
+	}
+
+	return out
+}
+
+// WriteDictDiffJSON writes d as indented JSON.
+func WriteDictDiffJSON(w io.Writer, d DictDiff) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// WriteDictDiff writes d as text: "+" for additions, "-" for removals and
+// "~" for changes, section by section.
+func WriteDictDiff(w io.Writer, d DictDiff) {
+	fmt.Fprintf(w, "Dictionary diff: %s -> %s\n", d.From, d.To)
+
+	if d.Empty() {
+		fmt.Fprintln(w, "\nNo differences")
+		return
+	}
+
+	writeFieldDiff(w, d.Fields)
+	writeMessageDiff(w, d.Messages)
+
+	c := d.Components
+	if len(c.Added)+len(c.Removed)+len(c.Changed) > 0 {
+		fmt.Fprintln(w, "\nComponents")
+		for _, name := range c.Added {
+			fmt.Fprintf(w, "  + %s\n", name)
+		}
+		for _, name := range c.Removed {
+			fmt.Fprintf(w, "  - %s\n", name)
+		}
+		for _, s := range c.Changed {
+			fmt.Fprintf(w, "  ~ %s\n", s.Name)
+			writeStructureChange(w, s)
+		}
+	}
+
+	if len(d.Groups) > 0 {
+		fmt.Fprintln(w, "\nGroups")
+		for _, s := range d.Groups {
+			fmt.Fprintf(w, "  ~ %s\n", s.Name)
+			writeStructureChange(w, s)
+		}
+	}
+}
+
+func writeFieldDiff(w io.Writer, f FieldDiff) {
+	if len(f.Added)+len(f.Removed)+len(f.Renamed)+len(f.TypeChanged)+len(f.Enums) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nFields")
+	for _, a := range f.Added {
+		fmt.Fprintf(w, "  + %d %s (%s)\n", a.Tag, a.Name, a.Type)
+	}
+	for _, r := range f.Removed {
+		fmt.Fprintf(w, "  - %d %s (%s)\n", r.Tag, r.Name, r.Type)
+	}
+	for _, r := range f.Renamed {
+		fmt.Fprintf(w, "  ~ %d renamed %s -> %s\n", r.Tag, r.From, r.To)
+	}
+	for _, t := range f.TypeChanged {
+		fmt.Fprintf(w, "  ~ %d %s type %s -> %s\n", t.Tag, t.Name, t.From, t.To)
+	}
+	for _, e := range f.Enums {
+		fmt.Fprintf(w, "  ~ %d %s enums\n", e.Tag, e.Name)
+		writeList(w, "+", e.Added)
+		writeList(w, "-", e.Removed)
+	}
+}
+
+func writeMessageDiff(w io.Writer, m MessageDiff) {
+	if len(m.Added)+len(m.Removed)+len(m.Changed) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "\nMessages")
+	for _, a := range m.Added {
+		fmt.Fprintf(w, "  + %s %s\n", a.MsgType, a.Name)
+	}
+	for _, r := range m.Removed {
+		fmt.Fprintf(w, "  - %s %s\n", r.MsgType, r.Name)
+	}
+	for _, c := range m.Changed {
+		fmt.Fprintf(w, "  ~ %s %s\n", c.MsgType, c.Name)
+		if c.RenamedFrom != "" {
+			fmt.Fprintf(w, "      renamed from %s\n", c.RenamedFrom)
+		}
+		if len(c.RequiredAdded) > 0 {
+			fmt.Fprintf(w, "      now requires %s\n", strings.Join(c.RequiredAdded, ", "))
+		}
+		if len(c.RequiredRemoved) > 0 {
+			fmt.Fprintf(w, "      no longer requires %s\n", strings.Join(c.RequiredRemoved, ", "))
+		}
+		writeStructureChange(w, c.StructureChange)
+	}
+}
+
+func writeStructureChange(w io.Writer, s StructureChange) {
+	writeList(w, "+", s.MembersAdded)
+	writeList(w, "-", s.MembersRemoved)
+	writeList(w, "~", s.RequiredChanged)
+}
+
+func writeList(w io.Writer, mark string, items []string) {
+	for _, item := range items {
+		fmt.Fprintf(w, "      %s %s\n", mark, item)
+	}
+}
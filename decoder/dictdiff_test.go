@@ -0,0 +1,257 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const diffFromXML = `<fix major="4" minor="2">
+ <header><field name="BeginString" required="Y"/></header>
+ <trailer><field name="CheckSum" required="Y"/></trailer>
+ <messages>
+  <message name="Order" msgtype="D" msgcat="app">
+   <field name="ClOrdID" required="Y"/>
+   <field name="HandlInst" required="Y"/>
+   <component name="Instrument" required="Y"/>
+   <group name="NoAllocs" required="N"><field name="AllocAccount" required="N"/></group>
+  </message>
+  <message name="Gone" msgtype="X" msgcat="app"><field name="ClOrdID" required="Y"/></message>
+ </messages>
+ <components>
+  <component name="Instrument"><field name="Symbol" required="Y"/></component>
+  <component name="Old"><field name="Symbol" required="N"/></component>
+ </components>
+ <fields>
+  <field number="11" name="ClOrdID" type="STRING"/>
+  <field number="21" name="HandlInst" type="CHAR"><value enum="1" description="AUTO"/><value enum="3" description="MANUAL"/></field>
+  <field number="55" name="Symbol" type="STRING"/>
+  <field number="78" name="NoAllocs" type="NUMINGROUP"/>
+  <field number="79" name="AllocAccount" type="STRING"/>
+  <field number="8" name="BeginString" type="STRING"/>
+  <field number="10" name="CheckSum" type="STRING"/>
+  <field number="99" name="StopPx" type="FLOAT"/>
+ </fields>
+</fix>`
+
+const diffToXML = `<fix major="4" minor="4">
+ <header><field name="BeginString" required="Y"/></header>
+ <trailer><field name="CheckSum" required="Y"/></trailer>
+ <messages>
+  <message name="NewOrderSingle" msgtype="D" msgcat="app">
+   <field name="ClOrdID" required="Y"/>
+   <field name="HandlInst" required="N"/>
+   <field name="TransactTime" required="Y"/>
+   <component name="Instrument" required="Y"/>
+   <group name="NoAllocs" required="N"><field name="AllocAccount" required="N"/><field name="AllocQty" required="N"/></group>
+  </message>
+  <message name="New" msgtype="Y" msgcat="app"><field name="ClOrdID" required="Y"/></message>
+ </messages>
+ <components>
+  <component name="Instrument"><field name="Symbol" required="Y"/><field name="SecurityID" required="N"/></component>
+ </components>
+ <fields>
+  <field number="11" name="ClOrdID" type="STRING"/>
+  <field number="21" name="HandlInst" type="CHAR"><value enum="1" description="AUTO"/><value enum="2" description="SEMI"/></field>
+  <field number="48" name="SecurityID" type="STRING"/>
+  <field number="55" name="Symbol" type="STRING"/>
+  <field number="60" name="TransactTime" type="UTCTIMESTAMP"/>
+  <field number="78" name="NoAllocs" type="NUMINGROUP"/>
+  <field number="79" name="AllocAccount" type="STRING"/>
+  <field number="80" name="AllocQty" type="QTY"/>
+  <field number="8" name="BeginString" type="STRING"/>
+  <field number="10" name="CheckSum" type="STRING"/>
+  <field number="99" name="StopPrice" type="PRICE"/>
+ </fields>
+</fix>`
+
+func schemaFromXML(t *testing.T, data string) SchemaTree {
+	t.Helper()
+
+	var dict FixDictionary
+	if err := xml.Unmarshal([]byte(data), &dict); err != nil {
+		t.Fatal(err)
+	}
+	return BuildSchema(dict)
+}
+
+func TestDiffDictionaries(t *testing.T) {
+	d := DiffDictionaries(schemaFromXML(t, diffFromXML), schemaFromXML(t, diffToXML))
+
+	want := FieldDiff{
+		Added: []DiffField{
+			{Tag: 48, Name: "SecurityID", Type: "STRING"},
+			{Tag: 60, Name: "TransactTime", Type: "UTCTIMESTAMP"},
+			{Tag: 80, Name: "AllocQty", Type: "QTY"},
+		},
+		Renamed:     []DiffChange{{Tag: 99, Name: "StopPrice", From: "StopPx", To: "StopPrice"}},
+		TypeChanged: []DiffChange{{Tag: 99, Name: "StopPrice", From: "FLOAT", To: "PRICE"}},
+		Enums:       []EnumChange{{Tag: 21, Name: "HandlInst", Added: []string{"2 (SEMI)"}, Removed: []string{"3 (MANUAL)"}}},
+	}
+	if !reflect.DeepEqual(d.Fields, want) {
+		t.Errorf("fields:\n got %+v\nwant %+v", d.Fields, want)
+	}
+
+	if !reflect.DeepEqual(d.Messages.Added, []DiffMessage{{MsgType: "Y", Name: "New"}}) ||
+		!reflect.DeepEqual(d.Messages.Removed, []DiffMessage{{MsgType: "X", Name: "Gone"}}) {
+		t.Errorf("unexpected added/removed messages: %+v", d.Messages)
+	}
+
+	wantMsg := MessageChange{
+		MsgType:         "D",
+		Name:            "NewOrderSingle",
+		RenamedFrom:     "Order",
+		RequiredAdded:   []string{"TransactTime"},
+		RequiredRemoved: []string{"HandlInst"},
+		StructureChange: StructureChange{
+			MembersAdded:    []string{"field TransactTime"},
+			RequiredChanged: []string{"field HandlInst (required -> optional)"},
+		},
+	}
+	if len(d.Messages.Changed) != 1 || !reflect.DeepEqual(d.Messages.Changed[0], wantMsg) {
+		t.Errorf("changed messages:\n got %+v\nwant %+v", d.Messages.Changed, wantMsg)
+	}
+
+	wantComps := StructureDiff{
+		Removed: []string{"Old"},
+		Changed: []StructureChange{{Name: "Instrument", MembersAdded: []string{"field SecurityID"}}},
+	}
+	if !reflect.DeepEqual(d.Components, wantComps) {
+		t.Errorf("components:\n got %+v\nwant %+v", d.Components, wantComps)
+	}
+
+	// The group is matched by the message name, which changed, so it is
+	// compared only when the message keeps its name.
+	if len(d.Groups) != 0 {
+		t.Errorf("unexpected group changes: %+v", d.Groups)
+	}
+}
+
+func TestDiffDictionariesGroups(t *testing.T) {
+	from := schemaFromXML(t, strings.Replace(diffFromXML, `name="Order"`, `name="NewOrderSingle"`, 1))
+	d := DiffDictionaries(from, schemaFromXML(t, diffToXML))
+
+	want := []StructureChange{{Name: "NewOrderSingle/NoAllocs", MembersAdded: []string{"field AllocQty"}}}
+	if !reflect.DeepEqual(d.Groups, want) {
+		t.Errorf("groups:\n got %+v\nwant %+v", d.Groups, want)
+	}
+}
+
+func TestDiffDictionariesIdentical(t *testing.T) {
+	schema := embeddedSchema(t, "44")
+	d := DiffDictionaries(schema, schema)
+	if !d.Empty() {
+		t.Fatalf("expected no differences, got %+v", d)
+	}
+
+	var out bytes.Buffer
+	WriteDictDiff(&out, d)
+	if !strings.Contains(out.String(), "No differences") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestDiffEmbeddedDictionaries(t *testing.T) {
+	d := DiffDictionaries(embeddedSchema(t, "42"), embeddedSchema(t, "44"))
+	d.From, d.To = "FIX.4.2", "FIX.4.4"
+
+	var out bytes.Buffer
+	WriteDictDiff(&out, d)
+	text := out.String()
+
+	for _, want := range []string{
+		"Dictionary diff: FIX.4.2 -> FIX.4.4",
+		"  + 232 NoStipulations (NUMINGROUP)",
+		"  ~ D NewOrderSingle\n      no longer requires HandlInst, Symbol\n",
+		"\nComponents\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+}
+
+func TestWriteDictDiffJSON(t *testing.T) {
+	d := DiffDictionaries(schemaFromXML(t, diffFromXML), schemaFromXML(t, diffToXML))
+	d.From, d.To = "a.xml", "b.xml"
+
+	var out bytes.Buffer
+	if err := WriteDictDiffJSON(&out, d); err != nil {
+		t.Fatal(err)
+	}
+
+	var back DictDiff
+	if err := json.Unmarshal(out.Bytes(), &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, d) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", back, d)
+	}
+	if !strings.Contains(out.String(), `"requiredAdded": [`) {
+		t.Errorf("unexpected JSON %s", out.String())
+	}
+}
+
+func TestWriteDictDiffText(t *testing.T) {
+	d := DiffDictionaries(schemaFromXML(t, diffFromXML), schemaFromXML(t, diffToXML))
+	d.From, d.To = "a.xml", "b.xml"
+
+	var out bytes.Buffer
+	WriteDictDiff(&out, d)
+
+	want := `Dictionary diff: a.xml -> b.xml
+
+Fields
+  + 48 SecurityID (STRING)
+  + 60 TransactTime (UTCTIMESTAMP)
+  + 80 AllocQty (QTY)
+  ~ 99 renamed StopPx -> StopPrice
+  ~ 99 StopPrice type FLOAT -> PRICE
+  ~ 21 HandlInst enums
+      + 2 (SEMI)
+      - 3 (MANUAL)
+
+Messages
+  + Y New
+  - X Gone
+  ~ D NewOrderSingle
+      renamed from Order
+      now requires TransactTime
+      no longer requires HandlInst
+      + field TransactTime
+      ~ field HandlInst (required -> optional)
+
+Components
+  - Old
+  ~ Instrument
+      + field SecurityID
+`
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
@@ -25,9 +25,9 @@ package decoder
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"sort"
-
 	"strings"
 
 	"golang.org/x/term"
@@ -35,6 +35,36 @@ import (
 
 var getTerminalSize = term.GetSize
 
+const defaultWidth = 80
+
+// Renderer writes schema listings to Out, laying out columns to Width and
+// highlighting tags, names, enums and headings with Theme.
+type Renderer struct {
+	Out   io.Writer
+	Width int
+	Theme Theme
+}
+
+// NewRenderer returns a Renderer for out. A non-positive width means 80.
+func NewRenderer(out io.Writer, width int, theme Theme) *Renderer {
+	if width <= 0 {
+		width = defaultWidth
+	}
+
+	return &Renderer{Out: out, Width: width, Theme: theme}
+}
+
+// StdoutRenderer renders uncoloured to os.Stdout, sized to the terminal
+// (80 columns when stdout is not a terminal).
+func StdoutRenderer() *Renderer {
+	width, _, err := getTerminalSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width = defaultWidth
+	}
+
+	return NewRenderer(os.Stdout, width, NoTheme)
+}
+
 // findField returns the Field with the given number, or false if not found.
 func FindField(schema SchemaTree, tagID int) (Field, bool) {
 	for _, f := range schema.Fields {
@@ -45,21 +75,25 @@ func FindField(schema SchemaTree, tagID int) (Field, bool) {
 	return Field{}, false
 }
 
-func printField(field FieldNode, indent int) {
-	printIndent(indent)
+func (r *Renderer) printField(field FieldNode, indent int) {
+	r.printIndent(indent)
+	r.printFieldLine(field.Field, formatRequired(field.Ref.Required))
+}
 
-	fmt.Printf("%-4d: %s (%s)%s\n",
-		field.Field.Number, field.Field.Name, field.Field.Type, formatRequired(field.Ref.Required),
+// printFieldLine prints the "tag: Name (TYPE)" line shared by all listings.
+func (r *Renderer) printFieldLine(field Field, suffix string) {
+	fmt.Fprintf(r.Out, "%s: %s (%s)%s\n",
+		r.Theme.Paint(r.Theme.Tag, fmt.Sprintf("%-4d", field.Number)), r.Theme.Paint(r.Theme.FieldName, field.Name), field.Type, suffix,
 	)
 }
 
-// printStringColumns prints a slice of strings in columns based on terminal width.
+// PrintStringColumns prints a slice of strings to stdout in columns.
 func PrintStringColumns(items []string) {
-	width, _, err := getTerminalSize(int(os.Stdout.Fd()))
-	if err != nil {
-		width = 80
-	}
+	StdoutRenderer().PrintStringColumns(items)
+}
 
+// PrintStringColumns prints a slice of strings in columns based on the renderer width.
+func (r *Renderer) PrintStringColumns(items []string) {
 	maxLen := 0
 	for _, s := range items {
 		if len(s) > maxLen {
@@ -67,48 +101,48 @@ func PrintStringColumns(items []string) {
 		}
 	}
 
-	cols := width / (maxLen + 2)
+	cols := r.Width / (maxLen + 2)
 	if cols == 0 {
 		cols = 1
 	}
 
 	rows := (len(items) + cols - 1) / cols
 
-	for r := range make([]int, rows) {
-		for c := range make([]int, cols) {
-			i := c*rows + r
+	for row := range rows {
+		for c := range cols {
+			i := c*rows + row
 
 			if i < len(items) {
-				fmt.Printf("%-*s", maxLen+2, items[i])
+				fmt.Fprintf(r.Out, "%-*s", maxLen+2, items[i])
 			}
 		}
 
-		fmt.Println()
+		fmt.Fprintln(r.Out)
 	}
 }
 
 // printFields prints all the simple fields of the message.
-func printFields(msg MessageNode, verbose, column bool, indent int) {
+func (r *Renderer) printFields(msg MessageNode, verbose, column bool, indent int) {
 	for _, f := range msg.Fields {
-		printField(f, indent)
+		r.printField(f, indent)
 
 		if verbose && column {
-			printEnumColumns(f.Field.Values, indent)
+			r.printEnumColumns(f.Field.Values, indent)
 		} else if verbose {
 			for _, val := range f.Field.Values {
-				printEnum(val.Enum, val.Description, indent+2)
+				r.printEnum(val.Enum, val.Description, indent+2)
 			}
 		}
 	}
 }
 
-func printIndent(level int) {
-	fmt.Print(strings.Repeat(" ", level))
+func (r *Renderer) printIndent(level int) {
+	fmt.Fprint(r.Out, strings.Repeat(" ", level))
 }
 
-func printEnum(enum string, description string, indent int) {
-	printIndent(indent + 4)
-	fmt.Printf("%s : %s\n", enum, description)
+func (r *Renderer) printEnum(enum string, description string, indent int) {
+	r.printIndent(indent + 4)
+	fmt.Fprintf(r.Out, "%s : %s\n", r.Theme.Paint(r.Theme.Enum, enum), description)
 }
 
 func formatRequired(req string) string {
@@ -119,19 +153,14 @@ func formatRequired(req string) string {
 	return ""
 }
 
-func printEnumColumns(values []Value, indent int) {
+func (r *Renderer) printEnumColumns(values []Value, indent int) {
 	if len(values) == 0 {
 		return
 	}
 
-	width, _, err := term.GetSize(int(os.Stdout.Fd()))
-	if err != nil {
-		width = 80
-	}
-
-	usableWidth := width - indent
+	usableWidth := r.Width - indent
 	if usableWidth <= 0 {
-		usableWidth = width
+		usableWidth = r.Width
 	}
 
 	maxLen := 0
@@ -154,18 +183,18 @@ func printEnumColumns(values []Value, indent int) {
 		return values[i].Enum < values[j].Enum
 	})
 
-	for r := range rows {
-		printIndent(indent)
+	for row := range rows {
+		r.printIndent(indent)
 
 		for c := range cols {
-			i := c*rows + r
+			i := c*rows + row
 
 			if i < len(values) {
 				s := fmt.Sprintf("%s: %s", values[i].Enum, values[i].Description)
-				fmt.Printf("%-*s", maxLen+2, s)
+				fmt.Fprintf(r.Out, "%-*s", maxLen+2, s)
 			}
 		}
 
-		fmt.Println()
+		fmt.Fprintln(r.Out)
 	}
 }
@@ -28,10 +28,15 @@ import (
 	"sort"
 )
 
-var printEnumFunc = printEnum
+var printEnumFunc = (*Renderer).printEnum
 
-// listAllComponents prints all component names in sorted order.
+// ListAllComponents prints all component names to stdout in sorted order.
 func ListAllComponents(schema SchemaTree) {
+	StdoutRenderer().ListAllComponents(schema)
+}
+
+// ListAllComponents prints all component names in sorted order.
+func (r *Renderer) ListAllComponents(schema SchemaTree) {
 	names := make([]string, 0, len(schema.Components))
 	for name := range schema.Components {
 		names = append(names, name)
@@ -39,81 +44,87 @@ func ListAllComponents(schema SchemaTree) {
 
 	sort.Strings(names)
 	for _, n := range names {
-		fmt.Println(n)
+		fmt.Fprintln(r.Out, n)
 	}
 }
 
 // printMatchingEnum prints only the value whose Enum matches `want`.
-func printMatchingEnum(values []Value, want string, indent int) {
+func (r *Renderer) printMatchingEnum(values []Value, want string, indent int) {
 	for _, v := range values {
 		if v.Enum == want {
-			printEnumFunc(v.Enum, v.Description, indent)
+			printEnumFunc(r, v.Enum, v.Description, indent)
 			break
 		}
 	}
 }
 
 // printComponents prints all nested components of the message.
-func printComponents(schema SchemaTree, msg MessageNode, verbose, column bool, indent int) {
+func (r *Renderer) printComponents(schema SchemaTree, msg MessageNode, verbose, column bool, indent int) {
 	for _, c := range msg.Components {
-		DisplayComponent(schema, msg, c, verbose, column, indent)
+		r.DisplayComponent(schema, msg, c, verbose, column, indent)
 	}
 }
 
 // printHeader prints the Header component if includeHeader is true.
-func printHeader(schema SchemaTree, msg MessageNode, includeHeader, verbose, column bool, indent int) {
+func (r *Renderer) printHeader(schema SchemaTree, msg MessageNode, includeHeader, verbose, column bool, indent int) {
 	if !includeHeader {
 		return
 	}
 
 	if headerComp, ok := schema.Components["Header"]; ok {
-		DisplayComponent(schema, msg, headerComp, verbose, column, indent)
+		r.DisplayComponent(schema, msg, headerComp, verbose, column, indent)
 	}
 }
 
-func printTrailer(schema SchemaTree, msg MessageNode, includeTrailer, verbose, column bool, indent int) {
+func (r *Renderer) printTrailer(schema SchemaTree, msg MessageNode, includeTrailer, verbose, column bool, indent int) {
 	if !includeTrailer {
 		return
 	}
 
 	if trailerComp, ok := schema.Components["Trailer"]; ok {
-		DisplayComponent(schema, msg, trailerComp, verbose, column, indent)
+		r.DisplayComponent(schema, msg, trailerComp, verbose, column, indent)
 	}
 }
 
+// DisplayComponent prints a component to stdout.
 func DisplayComponent(schema SchemaTree, msg MessageNode, comp ComponentNode, verbose bool, columnOutput bool, indent int) {
-	printIndent(indent)
-	fmt.Printf("Component: %s\n", comp.Name)
+	StdoutRenderer().DisplayComponent(schema, msg, comp, verbose, columnOutput, indent)
+}
+
+// DisplayComponent prints a component with its fields, nested components and groups.
+func (r *Renderer) DisplayComponent(schema SchemaTree, msg MessageNode, comp ComponentNode, verbose bool, columnOutput bool, indent int) {
+	r.printIndent(indent)
+	fmt.Fprintf(r.Out, "%s %s\n", r.Theme.Paint(r.Theme.Title, "Component:"), comp.Name)
 
 	for _, f := range comp.Fields {
-		printField(f, indent+4)
+		r.printField(f, indent+4)
 		if verbose {
-			printEnums(f, msg, columnOutput, indent+6)
+			r.printEnums(f, msg, columnOutput, indent+6)
 		}
 	}
 
 	for _, c := range comp.Components {
-		DisplayComponent(schema, msg, c, verbose, columnOutput, indent+4)
+		r.DisplayComponent(schema, msg, c, verbose, columnOutput, indent+4)
 	}
 
 	for _, g := range comp.Groups {
-		DisplayGroup(schema, g, verbose, columnOutput, indent+4)
+		r.DisplayGroup(schema, g, verbose, columnOutput, indent+4)
 	}
 }
 
 // Helper to handle enum display logic
-func printEnums(f FieldNode, msg MessageNode, columnOutput bool, indent int) {
+func (r *Renderer) printEnums(f FieldNode, msg MessageNode, columnOutput bool, indent int) {
 	if f.Field.Number == 35 {
 		// Special case for MsgType
-		printMatchingEnum(f.Field.Values, msg.MsgType, indent)
+		r.printMatchingEnum(f.Field.Values, msg.MsgType, indent)
 		return
 	}
 
 	if columnOutput {
-		printEnumColumns(f.Field.Values, indent)
+		r.printEnumColumns(f.Field.Values, indent)
 	} else {
 		for _, v := range f.Field.Values {
-			printEnumFunc(v.Enum, v.Description, indent)
+			printEnumFunc(r, v.Enum, v.Description, indent)
 		}
 	}
 }
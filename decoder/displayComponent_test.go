@@ -134,7 +134,7 @@ func TestPrintHeaderIncludeFalse(t *testing.T) {
 	}
 	// Should print nothing
 	out := captureStdout(func() {
-		printHeader(schema, MessageNode{}, false, true, false, 0)
+		StdoutRenderer().printHeader(schema, MessageNode{}, false, true, false, 0)
 	})
 	if out != "" {
 		t.Errorf("printHeader(includeHeader=false) output = %q; want empty", out)
@@ -149,7 +149,7 @@ func TestPrintHeaderIncludeTrueHeaderExists(t *testing.T) {
 	}
 	// Should print header component
 	out := captureStdout(func() {
-		printHeader(schema, MessageNode{}, true, false, false, 1)
+		StdoutRenderer().printHeader(schema, MessageNode{}, true, false, false, 1)
 	})
 	if want := " Component: Header\n"; out != want {
 		t.Errorf("printHeader(includeHeader=true) = %q; want %q", out, want)
@@ -161,7 +161,7 @@ func TestPrintHeaderIncludeTrueHeaderMissing(t *testing.T) {
 		Components: map[string]ComponentNode{},
 	}
 	out := captureStdout(func() {
-		printHeader(schema, MessageNode{}, true, false, false, 0)
+		StdoutRenderer().printHeader(schema, MessageNode{}, true, false, false, 0)
 	})
 	// Should print nothing, as no Header exists
 	if out != "" {
@@ -212,7 +212,7 @@ func TestPrintMatchingEnumMatch(t *testing.T) {
 	var gotIndent int
 
 	original := printEnumFunc
-	printEnumFunc = func(enum, desc string, indent int) {
+	printEnumFunc = func(_ *Renderer, enum, desc string, indent int) {
 		called = true
 		gotEnum = enum
 		gotDesc = desc
@@ -226,7 +226,7 @@ func TestPrintMatchingEnumMatch(t *testing.T) {
 		{Enum: "2", Description: "Cancel"},
 	}
 
-	printMatchingEnum(values, "1", 2)
+	StdoutRenderer().printMatchingEnum(values, "1", 2)
 
 	if !called {
 		t.Fatal("Expected printEnumFunc to be called")
@@ -240,7 +240,7 @@ func TestPrintMatchingEnumNoMatch(t *testing.T) {
 	called := false
 
 	original := printEnumFunc
-	printEnumFunc = func(enum, desc string, indent int) {
+	printEnumFunc = func(_ *Renderer, enum, desc string, indent int) {
 		called = true
 	}
 	defer func() { printEnumFunc = original }()
@@ -250,7 +250,7 @@ func TestPrintMatchingEnumNoMatch(t *testing.T) {
 		{Enum: "1", Description: "Replace"},
 	}
 
-	printMatchingEnum(values, "X", 0)
+	StdoutRenderer().printMatchingEnum(values, "X", 0)
 
 	if called {
 		t.Error("Expected printEnumFunc NOT to be called")
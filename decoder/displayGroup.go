@@ -27,36 +27,41 @@ import (
 	"fmt"
 )
 
-// displayGroup displays a GroupNode with its fields, components, and nested groups.
+// DisplayGroup prints a group to stdout.
 func DisplayGroup(schema SchemaTree, g GroupNode, verbose bool, columnOutput bool, indent int) {
-	printIndent(indent)
+	StdoutRenderer().DisplayGroup(schema, g, verbose, columnOutput, indent)
+}
+
+// DisplayGroup displays a GroupNode with its fields, components, and nested groups.
+func (r *Renderer) DisplayGroup(schema SchemaTree, g GroupNode, verbose bool, columnOutput bool, indent int) {
+	r.printIndent(indent)
 
-	fmt.Printf("Group: %s%s\n", g.Name, formatRequired(g.Required))
+	fmt.Fprintf(r.Out, "%s %s%s\n", r.Theme.Paint(r.Theme.Title, "Group:"), g.Name, formatRequired(g.Required))
 
 	for _, f := range g.Fields {
-		printField(f, indent+4)
+		r.printField(f, indent+4)
 
 		if verbose && columnOutput {
-			printEnumColumns(f.Field.Values, indent+6)
+			r.printEnumColumns(f.Field.Values, indent+6)
 		} else if verbose {
 			for _, val := range f.Field.Values {
-				printEnum(val.Enum, val.Description, indent+6)
+				r.printEnum(val.Enum, val.Description, indent+6)
 			}
 		}
 	}
 
 	for _, c := range g.Components {
-		DisplayComponent(schema, MessageNode{}, c, verbose, columnOutput, indent+4)
+		r.DisplayComponent(schema, MessageNode{}, c, verbose, columnOutput, indent+4)
 	}
 
 	for _, sg := range g.Groups {
-		DisplayGroup(schema, sg, verbose, columnOutput, indent+4)
+		r.DisplayGroup(schema, sg, verbose, columnOutput, indent+4)
 	}
 }
 
 // printGroups prints all repeating groups of the message.
-func printGroups(schema SchemaTree, msg MessageNode, verbose, column bool, indent int) {
+func (r *Renderer) printGroups(schema SchemaTree, msg MessageNode, verbose, column bool, indent int) {
 	for _, g := range msg.Groups {
-		DisplayGroup(schema, g, verbose, column, indent)
+		r.DisplayGroup(schema, g, verbose, column, indent)
 	}
 }
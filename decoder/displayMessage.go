@@ -28,8 +28,13 @@ import (
 	"sort"
 )
 
-// listAllMessages prints all messages in sorted order by MsgType.
+// ListAllMessages prints all messages to stdout in sorted order by MsgType.
 func ListAllMessages(schema SchemaTree) {
+	StdoutRenderer().ListAllMessages(schema)
+}
+
+// ListAllMessages prints all messages in sorted order by MsgType.
+func (r *Renderer) ListAllMessages(schema SchemaTree) {
 	var msgs []MessageNode
 	for _, m := range schema.Messages {
 		msgs = append(msgs, m)
@@ -37,26 +42,36 @@ func ListAllMessages(schema SchemaTree) {
 
 	sort.Slice(msgs, func(i, j int) bool { return msgs[i].MsgType < msgs[j].MsgType })
 	for _, m := range msgs {
-		fmt.Printf("%-4s: %s (%s)\n", m.MsgType, m.Name, m.MsgCat)
+		fmt.Fprintf(r.Out, "%s: %s (%s)\n", r.Theme.Paint(r.Theme.Tag, fmt.Sprintf("%-4s", m.MsgType)), r.Theme.Paint(r.Theme.FieldName, m.Name), m.MsgCat)
 	}
 }
 
 // printMessageStart prints the “Message: Name (Type)” header.
-func printMessageStart(msg MessageNode) {
-	fmt.Printf("Message: %s (%s)\n", msg.Name, msg.MsgType)
+func (r *Renderer) printMessageStart(msg MessageNode) {
+	fmt.Fprintf(r.Out, "%s %s (%s)\n", r.Theme.Paint(r.Theme.Title, "Message:"), msg.Name, msg.MsgType)
 }
 
-// displayMessageStructureWithOptions orchestrates the above helpers.
+// DisplayMessageStructureWithOptions prints a message structure to stdout.
 func DisplayMessageStructureWithOptions(
 	schema SchemaTree,
 	msg MessageNode,
 	verbose, includeHeader, includeTrailer, column bool,
 	indent int,
 ) {
-	printMessageStart(msg)
-	printHeader(schema, msg, includeHeader, verbose, column, indent)
-	printFields(msg, verbose, column, indent)
-	printComponents(schema, msg, verbose, column, indent)
-	printGroups(schema, msg, verbose, column, indent)
-	printTrailer(schema, msg, includeTrailer, verbose, column, indent)
+	StdoutRenderer().DisplayMessageStructureWithOptions(schema, msg, verbose, includeHeader, includeTrailer, column, indent)
+}
+
+// DisplayMessageStructureWithOptions orchestrates the above helpers.
+func (r *Renderer) DisplayMessageStructureWithOptions(
+	schema SchemaTree,
+	msg MessageNode,
+	verbose, includeHeader, includeTrailer, column bool,
+	indent int,
+) {
+	r.printMessageStart(msg)
+	r.printHeader(schema, msg, includeHeader, verbose, column, indent)
+	r.printFields(msg, verbose, column, indent)
+	r.printComponents(schema, msg, verbose, column, indent)
+	r.printGroups(schema, msg, verbose, column, indent)
+	r.printTrailer(schema, msg, includeTrailer, verbose, column, indent)
 }
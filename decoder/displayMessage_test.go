@@ -31,7 +31,7 @@ func TestPrintMessageStart(t *testing.T) {
 	msg := MessageNode{Name: "OrderSingle", MsgType: "D"}
 
 	out := captureStdout(func() {
-		printMessageStart(msg)
+		StdoutRenderer().printMessageStart(msg)
 	})
 
 	want := "Message: OrderSingle (D)\n"
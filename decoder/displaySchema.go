@@ -26,14 +26,20 @@ package decoder
 import "fmt"
 
 // PrintSchemaSummary writes a one-line overview of the dictionary that was
-// just loaded.
+// just loaded to stdout.
 func PrintSchemaSummary(schema SchemaTree) {
+	StdoutRenderer().PrintSchemaSummary(schema)
+}
+
+// PrintSchemaSummary writes a one-line overview of the dictionary that was
+// just loaded.
+func (r *Renderer) PrintSchemaSummary(schema SchemaTree) {
 	fields := len(schema.Fields)
 	components := len(schema.Components)
 	messages := len(schema.Messages)
 	version := schema.Version
 	servicePack := schema.ServicePack
 
-	fmt.Printf("Fields: %d   Components: %d   Messages: %d   Version: %s  Service Pack: %s\n",
+	fmt.Fprintf(r.Out, "Fields: %d   Components: %d   Messages: %d   Version: %s  Service Pack: %s\n",
 		fields, components, messages, version, servicePack)
 }
@@ -28,37 +28,58 @@ import (
 	"sort"
 )
 
-var printStringColumns = PrintStringColumns
+var printStringColumns = (*Renderer).PrintStringColumns
 
-// listAllTags prints every tag number, name, and type.
+// ListAllTags prints every tag number, name, and type to stdout.
 func ListAllTags(schema SchemaTree) {
-	fields := make([]Field, 0, len(schema.Fields))
-	for _, f := range schema.Fields {
-		fields = append(fields, f)
-	}
+	StdoutRenderer().ListAllTags(schema)
+}
 
-	sort.Slice(fields, func(i, j int) bool { return fields[i].Number < fields[j].Number })
-	for _, field := range fields {
-		fmt.Printf("%-4d: %s (%s)\n", field.Number, field.Name, field.Type)
+// ListAllTags prints every tag number, name, and type.
+func (r *Renderer) ListAllTags(schema SchemaTree) {
+	for _, field := range sortedFields(schema) {
+		r.printFieldLine(field, "")
 	}
 }
 
-// printTagDetails prints a field's header and, if verbose, its enum values.
+// PrintTagDetails prints a field's details to stdout.
 func PrintTagDetails(field Field, verbose, column bool) {
-	fmt.Printf("%-4d: %s (%s)\n", field.Number, field.Name, field.Type)
+	StdoutRenderer().PrintTagDetails(field, verbose, column)
+}
+
+// PrintTagDetails prints a field's header and, if verbose, its enum values.
+func (r *Renderer) PrintTagDetails(field Field, verbose, column bool) {
+	r.printFieldLine(field, "")
 
 	if verbose {
 		if column {
-			printEnumColumns(field.Values, 4)
+			r.printEnumColumns(field.Values, 4)
 		} else {
 			for _, v := range field.Values {
-				printEnum(v.Enum, v.Description, 4)
+				r.printEnum(v.Enum, v.Description, 4)
 			}
 		}
 	}
 }
 
+// PrintTagsInColumns prints every tag to stdout in columns.
 func PrintTagsInColumns(schema SchemaTree) {
+	StdoutRenderer().PrintTagsInColumns(schema)
+}
+
+// PrintTagsInColumns prints every tag in columns sized to the renderer width.
+func (r *Renderer) PrintTagsInColumns(schema SchemaTree) {
+	fs := sortedFields(schema)
+
+	lines := make([]string, len(fs))
+	for i, f := range fs {
+		lines[i] = fmt.Sprintf("%-4d: %s (%s)", f.Number, f.Name, f.Type)
+	}
+
+	printStringColumns(r, lines)
+}
+
+func sortedFields(schema SchemaTree) []Field {
 	fs := make([]Field, 0, len(schema.Fields))
 	for _, f := range schema.Fields {
 		fs = append(fs, f)
@@ -68,10 +89,5 @@ func PrintTagsInColumns(schema SchemaTree) {
 		return fs[i].Number < fs[j].Number
 	})
 
-	lines := make([]string, len(fs))
-	for i, f := range fs {
-		lines[i] = fmt.Sprintf("%-4d: %s (%s)", f.Number, f.Name, f.Type)
-	}
-
-	printStringColumns(lines)
+	return fs
 }
@@ -136,7 +136,7 @@ func TestPrintTagsInColumns(t *testing.T) {
 	var got []string
 	original := printStringColumns
 
-	printStringColumns = func(lines []string) {
+	printStringColumns = func(_ *Renderer, lines []string) {
 		got = lines
 	}
 
@@ -54,7 +54,7 @@ func makeEnum(val, desc string) Value {
 func TestPrintEnumColumnsEmptyValues(t *testing.T) {
 	values := []Value{}
 	out := captureStdout(func() {
-		printEnumColumns(values, 0)
+		StdoutRenderer().printEnumColumns(values, 0)
 	})
 	if out != "" {
 		t.Errorf("expected no output for empty values, got %q", out)
@@ -68,7 +68,7 @@ func TestPrintEnumColumnsTermSizeError(t *testing.T) {
 		{Enum: "X", Description: "Y"},
 	}
 	out := captureStdout(func() {
-		printEnumColumns(values, 0)
+		StdoutRenderer().printEnumColumns(values, 0)
 	})
 	if !strings.Contains(out, "X: Y") {
 		t.Errorf("expected printed enum \"X: Y\", got %q", out)
@@ -84,7 +84,7 @@ func TestPrintEnumColumnsZeroCols(t *testing.T) {
 	}
 	// Use indent large enough to make usableWidth small
 	out := captureStdout(func() {
-		printEnumColumns(values, 80) // usableWidth = 80-80 = 0 → reset to 80; maxLen+2 > 80 → cols = 0 → cols=1
+		StdoutRenderer().printEnumColumns(values, 80) // usableWidth = 80-80 = 0 → reset to 80; maxLen+2 > 80 → cols = 0 → cols=1
 	})
 	// Should still print our single enum on one line
 	if !strings.Contains(out, "E: "+longDesc) {
@@ -128,7 +128,7 @@ func makeTestMessageNode() MessageNode {
 func TestPrintFieldsNoVerbose(t *testing.T) {
 	msg := makeTestMessageNode()
 	output := captureStdout(func() {
-		printFields(msg, false, false, 2)
+		StdoutRenderer().printFields(msg, false, false, 2)
 	})
 
 	// Should not contain any enum values
@@ -140,7 +140,7 @@ func TestPrintFieldsNoVerbose(t *testing.T) {
 func TestPrintFieldsVerboseNoColumn(t *testing.T) {
 	msg := makeTestMessageNode()
 	output := captureStdout(func() {
-		printFields(msg, true, false, 2)
+		StdoutRenderer().printFields(msg, true, false, 2)
 	})
 
 	// Should list each enum on its own line
@@ -155,7 +155,7 @@ func TestPrintFieldsVerboseNoColumn(t *testing.T) {
 func TestPrintFieldsVerboseColumn(t *testing.T) {
 	msg := makeTestMessageNode()
 	output := captureStdout(func() {
-		printFields(msg, true, true, 0)
+		StdoutRenderer().printFields(msg, true, true, 0)
 	})
 
 	// Should contain all enum values in one or more columns
@@ -311,3 +311,54 @@ func TestPrintStringColumnsColsFallback(t *testing.T) {
 		t.Errorf("Expected output to include fallback rendering:\n%s", got)
 	}
 }
+
+func TestRendererWritesToWriter(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRenderer(&buf, 40, NoTheme)
+
+	schema := SchemaTree{
+		Fields: map[string]Field{
+			"Side": {Name: "Side", Number: 54, Type: "CHAR", Values: []Value{{Enum: "1", Description: "Buy"}}},
+		},
+	}
+
+	r.PrintTagDetails(schema.Fields["Side"], true, false)
+	r.PrintSchemaSummary(schema)
+
+	got := buf.String()
+	if !strings.Contains(got, "54  : Side (CHAR)") || !strings.Contains(got, "1 : Buy") || !strings.Contains(got, "Fields: 1") {
+		t.Errorf("unexpected renderer output:\n%s", got)
+	}
+}
+
+func TestRendererTheme(t *testing.T) {
+	var plain, coloured bytes.Buffer
+
+	field := Field{Name: "Side", Number: 54, Type: "CHAR"}
+	NewRenderer(&plain, 80, NoTheme).PrintTagDetails(field, false, false)
+	NewRenderer(&coloured, 80, DarkTheme).PrintTagDetails(field, false, false)
+
+	if strings.Contains(plain.String(), "\033[") {
+		t.Errorf("expected no escape codes without colour, got %q", plain.String())
+	}
+
+	if !strings.Contains(coloured.String(), DarkTheme.Tag) {
+		t.Errorf("expected coloured tag, got %q", coloured.String())
+	}
+}
+
+func TestNewRendererDefaultWidth(t *testing.T) {
+	if r := NewRenderer(io.Discard, 0, NoTheme); r.Width != 80 {
+		t.Errorf("expected default width 80, got %d", r.Width)
+	}
+}
+
+func TestRendererPrintStringColumnsUsesWidth(t *testing.T) {
+	var buf bytes.Buffer
+	NewRenderer(&buf, 20, NoTheme).PrintStringColumns([]string{"aaaa", "bbbb", "cccc", "dddd", "eeee"})
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Errorf("expected 3 columns over 2 rows at width 20, got %d rows:\n%s", len(lines), buf.String())
+	}
+}
@@ -0,0 +1,378 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// Execution report consistency issue codes.
+const (
+	ExecQtyBalance      = "QTY_BALANCE"      // CumQty + LeavesQty != OrderQty, or LeavesQty != 0 once done
+	ExecCumQtyDecreased = "CUMQTY_DECREASED" // CumQty fell without a trade correction or bust
+	ExecFillSum         = "FILL_SUM"         // the fills' LastQty do not add up to CumQty
+	ExecAvgPx           = "AVGPX"            // AvgPx does not match the fills' LastPx and LastQty
+	ExecState           = "EXEC_STATE"       // ExecType and OrdStatus cannot go together
+	ExecRefMissing      = "EXECREF"          // a correction or bust does not name an earlier ExecID
+)
+
+// ExecRef points at one message of a log.
+type ExecRef struct {
+	File   string `json:"file"`
+	Index  int    `json:"index"`  // 1-based message number within File
+	Offset int64  `json:"offset"` // byte offset of the message within File
+	ExecID string `json:"execId,omitempty"`
+}
+
+func (r ExecRef) String() string {
+	s := fmt.Sprintf("%s message %d (offset %d", r.File, r.Index, r.Offset)
+	if r.ExecID != "" {
+		s += ", ExecID " + r.ExecID
+	}
+	return s + ")"
+}
+
+// ExecIssue is an inconsistency between an order's execution reports. At
+// is the report where it showed; Refs are the earlier reports involved.
+type ExecIssue struct {
+	Code  string    `json:"code"`
+	Order string    `json:"order"`
+	Text  string    `json:"message"`
+	At    ExecRef   `json:"at"`
+	Refs  []ExecRef `json:"refs,omitempty"`
+}
+
+// execStates lists the OrdStatus values each ExecType may carry, after the
+// FIX 4.4 order state matrices (Volume 4, Appendix D), which FIX 5.0 keeps.
+// ExecTypes not listed (Calculated, Restated, Trade Correct, Trade Cancel,
+// Order Status and the FIX 5.0 additions) may carry any OrdStatus.
+var execStates = map[string]string{
+	"0": "0D",     // New: New, Accepted for bidding
+	"3": "3",      // Done for day
+	"4": "4",      // Canceled
+	"5": "01236E", // Replaced: the order's status after the replace
+	"6": "6",      // Pending cancel
+	"7": "7",      // Stopped
+	"8": "8",      // Rejected
+	"9": "9",      // Suspended
+	"A": "A",      // Pending new
+	"C": "C",      // Expired
+	"E": "E",      // Pending replace
+	"F": "1267BE", // Trade: filled, or a higher-precedence status
+}
+
+// doneStatuses are the OrdStatus values of an order with nothing left open.
+const doneStatuses = "2348C"
+
+// ExecChecker checks execution reports (MsgType 8) across each order.
+// Orders are keyed by their CompIDs and OrderID, or ClOrdID without one.
+type ExecChecker struct {
+	Issues []ExecIssue
+	orders map[string]*execOrder
+}
+
+type execOrder struct {
+	name    string
+	last    ExecRef
+	cumQty  *big.Rat
+	fills   map[string]execFill // by ExecID
+	execIDs map[string]ExecRef
+	partial bool // the log misses some of the order's fills
+}
+
+type execFill struct {
+	qty, px *big.Rat
+	ref     ExecRef
+}
+
+// NewExecChecker returns a checker with no orders seen.
+func NewExecChecker() *ExecChecker {
+	return &ExecChecker{orders: make(map[string]*execOrder)}
+}
+
+// Add checks m, found at ref, against the order's earlier reports. Other
+// message types are ignored.
+func (c *ExecChecker) Add(m *FixMessage, ref ExecRef) {
+	if m.MsgType() != "8" {
+		return
+	}
+
+	body := m.Body
+	ref.ExecID, _ = body.Get(17)
+	key, name := execOrderKey(m)
+	if key == "" {
+		return
+	}
+
+	o, seen := c.orders[key]
+	if !seen {
+		o = &execOrder{name: name, fills: make(map[string]execFill), execIDs: make(map[string]ExecRef)}
+		c.orders[key] = o
+	}
+
+	report := func(code string, refs []ExecRef, format string, args ...any) {
+		c.Issues = append(c.Issues, ExecIssue{Code: code, Order: o.name, Text: fmt.Sprintf(format, args...), At: ref, Refs: refs})
+	}
+
+	execType, _ := body.Get(150)
+	ordStatus, _ := body.Get(39)
+
+	if allowed, ok := execStates[execType]; ok && ordStatus != "" && hasStateMatrix(m) && !strings.Contains(allowed, ordStatus) {
+		report(ExecState, nil, "ExecType %s (%s) cannot carry OrdStatus %s (%s)", execType, body.dict.GetEnumDescription(150, execType), ordStatus, body.dict.GetEnumDescription(39, ordStatus))
+	}
+
+	// Trade Correct and Trade Cancel, or ExecTransType Correct and Cancel
+	// before FIX 4.3, amend the fill named by ExecRefID.
+	transType, _ := body.Get(20)
+	correction := execType == "G" || execType == "H" || transType == "1" || transType == "2"
+	refID, hasRefID := body.Get(19)
+	var refFill ExecRef
+	if correction {
+		prior, found := o.execIDs[refID]
+		switch {
+		case !hasRefID:
+			report(ExecRefMissing, nil, "Correction or bust without ExecRefID (19)")
+		case !found:
+			report(ExecRefMissing, nil, "ExecRefID %s does not match an earlier ExecID of the order", refID)
+		default:
+			refFill = prior
+		}
+	}
+
+	cum, hasCum := decimalField(body, 14)
+	leaves, hasLeaves := decimalField(body, 151)
+	qty, hasQty := decimalField(body, 38)
+
+	switch {
+	case !hasCum || !hasLeaves:
+	case strings.Contains(doneStatuses, ordStatus) && ordStatus != "":
+		if leaves.Sign() != 0 {
+			report(ExecQtyBalance, nil, "LeavesQty %s is not 0 with OrdStatus %s (%s)", ratString(leaves), ordStatus, body.dict.GetEnumDescription(39, ordStatus))
+		}
+	case hasQty:
+		if sum := new(big.Rat).Add(cum, leaves); sum.Cmp(qty) != 0 {
+			report(ExecQtyBalance, nil, "CumQty %s + LeavesQty %s = %s, expected OrderQty %s", ratString(cum), ratString(leaves), ratString(sum), ratString(qty))
+		}
+	}
+
+	if hasCum && o.cumQty != nil && !correction && cum.Cmp(o.cumQty) < 0 {
+		report(ExecCumQtyDecreased, []ExecRef{o.last}, "CumQty fell from %s to %s", ratString(o.cumQty), ratString(cum))
+	}
+
+	lastQty, hasLastQty := decimalField(body, 32)
+	lastPx, hasLastPx := decimalField(body, 31)
+	bust := execType == "H" || transType == "1"
+	newFill := !bust && hasLastQty && hasLastPx && lastQty.Sign() > 0 &&
+		(execType == "F" || execType == "G" || execType == "1" || execType == "2")
+
+	if refFill.ExecID != "" {
+		delete(o.fills, refFill.ExecID)
+	}
+	if newFill {
+		o.fills[ref.ExecID] = execFill{qty: lastQty, px: lastPx, ref: ref}
+	}
+
+	if !seen && hasCum {
+		before := new(big.Rat).Set(cum)
+		if newFill {
+			before.Sub(before, lastQty)
+		}
+		o.partial = before.Sign() > 0
+	}
+
+	if hasCum && !o.partial {
+		c.checkFills(o, cum, body, report)
+	}
+
+	if ref.ExecID != "" {
+		o.execIDs[ref.ExecID] = ref
+	}
+	if hasCum {
+		o.cumQty = cum
+	}
+	o.last = ref
+}
+
+// checkFills compares CumQty and AvgPx with the fills seen so far. After
+// a mismatch the order is no longer checked, so one gap is reported once.
+func (c *ExecChecker) checkFills(o *execOrder, cum *big.Rat, body *FieldMap, report func(string, []ExecRef, string, ...any)) {
+	total, value := new(big.Rat), new(big.Rat)
+	refs := make([]ExecRef, 0, len(o.fills))
+	for _, f := range o.fills {
+		total.Add(total, f.qty)
+		value.Add(value, new(big.Rat).Mul(f.qty, f.px))
+		refs = append(refs, f.ref)
+	}
+	sortExecRefs(refs)
+
+	if total.Cmp(cum) != 0 {
+		report(ExecFillSum, refs, "LastQty of the fills adds up to %s, CumQty is %s", ratString(total), ratString(cum))
+		o.partial = true
+		return
+	}
+
+	avgPx, hasAvg := decimalField(body, 6)
+	raw, _ := body.Get(6)
+	if !hasAvg || total.Sign() == 0 {
+		return
+	}
+
+	want := new(big.Rat).Quo(value, total)
+	diff := new(big.Rat).Sub(avgPx, want)
+	if diff.Abs(diff).Cmp(halfLastDigit(raw)) > 0 {
+		report(ExecAvgPx, refs, "AvgPx %s does not match the fills' average price %s", raw, want.FloatString(8))
+		o.partial = true
+	}
+}
+
+// hasStateMatrix reports whether m is FIX 4.4 or FIX 5.0 (FIXT), whose
+// state matrices execStates follows.
+func hasStateMatrix(m *FixMessage) bool {
+	begin, _ := m.Header.Get(8)
+	return begin == "FIX.4.4" || strings.HasPrefix(begin, "FIXT.")
+}
+
+// execOrderKey identifies the order m reports on and names it for issues.
+func execOrderKey(m *FixMessage) (string, string) {
+	sender, _ := m.Header.Get(49)
+	target, _ := m.Header.Get(56)
+
+	if id, ok := m.Body.Get(37); ok && id != "" && !strings.EqualFold(id, "NONE") {
+		return sender + "|" + target + "|37=" + id, "OrderID " + id
+	}
+	if id, ok := m.Body.Get(11); ok && id != "" {
+		return sender + "|" + target + "|11=" + id, "ClOrdID " + id
+	}
+	return "", ""
+}
+
+// decimalField parses tag using the dictionary's type for it, so INT
+// quantities of older FIX versions work as well as QTY and PRICE.
+func decimalField(fm *FieldMap, tag int) (*big.Rat, bool) {
+	v, err := fm.GetValue(tag)
+	if err != nil {
+		return nil, false
+	}
+
+	switch x := v.(type) {
+	case *big.Rat:
+		return x, true
+	case int:
+		return big.NewRat(int64(x), 1), true
+	case string:
+		if r, ok := parseDecimal(x); ok {
+			return r.(*big.Rat), true
+		}
+	}
+	return nil, false
+}
+
+// halfLastDigit is half a unit in the last decimal place of s, the
+// rounding a reported price may carry.
+func halfLastDigit(s string) *big.Rat {
+	places := 0
+	if _, frac, ok := strings.Cut(s, "."); ok {
+		places = len(frac)
+	}
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	return new(big.Rat).SetFrac(big.NewInt(1), denom.Mul(denom, big.NewInt(2)))
+}
+
+// ratString formats r without trailing zeros.
+func ratString(r *big.Rat) string {
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	s := strings.TrimRight(r.FloatString(10), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func sortExecRefs(refs []ExecRef) {
+	slices.SortFunc(refs, func(a, b ExecRef) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		return a.Index - b.Index
+	})
+}
+
+// CheckExecutionReports runs an ExecChecker over every FIX message in paths
+// (stdin for "-"), decoded with dict. Errors are reported to errOut; the
+// bool is false if any input failed.
+func CheckExecutionReports(paths []string, dict *CustomDictionary, errOut io.Writer) ([]ExecIssue, bool) {
+	c := NewExecChecker()
+
+	ok := forEachInput(paths, errOut, func(name string, r io.Reader) error {
+		scanner := newLineScanner(r)
+		index := 0
+
+		for scanner.Scan() {
+			line := scanner.Text()
+			for _, span := range findFixMessageIndices(line) {
+				index++
+				msg := NormaliseDelimiters(line[span[0]:span[1]])
+				m, err := NewMessage(msg, dict.Lookup(msg))
+				if err != nil {
+					continue
+				}
+				c.Add(m, ExecRef{File: name, Index: index, Offset: scanner.Offset() + int64(span[0])})
+			}
+		}
+
+		return scanner.Err()
+	})
+
+	return c.Issues, ok
+}
+
+// WriteExecIssues writes issues as text, one per line followed by the
+// earlier reports involved.
+func WriteExecIssues(w io.Writer, issues []ExecIssue) {
+	for _, is := range issues {
+		fmt.Fprintf(w, "%s %s at %s: %s\n", is.Code, is.Order, is.At, is.Text)
+		for _, ref := range is.Refs {
+			fmt.Fprintf(w, "    see %s\n", ref)
+		}
+	}
+
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "No execution report issues")
+		return
+	}
+	fmt.Fprintf(w, "%d execution report issue(s)\n", len(issues))
+}
+
+// WriteExecIssuesJSON writes issues as an indented JSON array.
+func WriteExecIssuesJSON(w io.Writer, issues []ExecIssue) error {
+	if issues == nil {
+		issues = []ExecIssue{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
@@ -0,0 +1,160 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// execReport frames an execution report from BROKER with body fields.
+func execReport(body string) string {
+	return framedFIX44("35=8|49=BROKER|56=CLIENT|34=1|52=20250101-09:00:00|" + body)
+}
+
+func execIssueCodes(issues []ExecIssue) []string {
+	var codes []string
+	for _, is := range issues {
+		codes = append(codes, is.Code)
+	}
+	return codes
+}
+
+func checkExecLog(t *testing.T, reports ...string) []ExecIssue {
+	t.Helper()
+	useRealDecoding(t)
+
+	path := filepath.Join(t.TempDir(), "execs.log")
+	_ = os.WriteFile(path, []byte(strings.Join(reports, "\n")+"\n"), 0644)
+
+	issues, ok := CheckExecutionReports([]string{path}, nil, io.Discard)
+	if !ok {
+		t.Fatal("CheckExecutionReports failed")
+	}
+	return issues
+}
+
+func TestCheckExecutionReportsConsistentOrder(t *testing.T) {
+	issues := checkExecLog(t,
+		execReport("37=O1|11=C1|17=E1|150=0|39=0|55=IBM|54=1|38=100|14=0|151=100|6=0|"),
+		"noise",
+		execReport("37=O1|11=C1|17=E2|150=F|39=1|55=IBM|54=1|38=100|32=40|31=10|14=40|151=60|6=10|"),
+		execReport("37=O1|11=C1|17=E3|150=F|39=2|55=IBM|54=1|38=100|32=60|31=11|14=100|151=0|6=10.6|"),
+	)
+
+	if len(issues) != 0 {
+		t.Errorf("expected no issues, got %+v", issues)
+	}
+}
+
+func TestCheckExecutionReportsIssues(t *testing.T) {
+	issues := checkExecLog(t,
+		execReport("37=O2|11=C2|17=E1|150=0|39=0|55=IBM|54=1|38=100|14=0|151=100|6=0|"),
+		execReport("37=O2|11=C2|17=E2|150=F|39=1|55=IBM|54=1|38=100|32=40|31=10|14=40|151=50|6=10|"),
+		execReport("37=O2|11=C2|17=E3|150=F|39=0|55=IBM|54=1|38=100|32=10|31=10|14=50|151=50|6=10|"),
+		execReport("37=O2|11=C2|17=E4|150=H|19=EX|39=1|55=IBM|54=1|38=100|14=30|151=70|6=10|"),
+		execReport("37=O2|11=C2|17=E5|150=I|39=1|55=IBM|54=1|38=100|14=20|151=80|6=10|"),
+	)
+
+	want := []string{ExecQtyBalance, ExecState, ExecRefMissing, ExecFillSum, ExecCumQtyDecreased}
+	if got := execIssueCodes(issues); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v\n%+v", got, want, issues)
+	}
+
+	if is := issues[0]; is.Order != "OrderID O2" || is.At.Index != 2 || is.At.ExecID != "E2" || is.Text != "CumQty 40 + LeavesQty 50 = 90, expected OrderQty 100" {
+		t.Errorf("unexpected balance issue %+v", is)
+	}
+	if is := issues[1]; is.Text != "ExecType F (TRADE) cannot carry OrdStatus 0 (NEW)" {
+		t.Errorf("unexpected state issue %q", is.Text)
+	}
+	if is := issues[3]; len(is.Refs) != 2 || is.Refs[0].ExecID != "E2" || is.Refs[1].ExecID != "E3" {
+		t.Errorf("expected the fill sum to reference both fills, got %+v", is.Refs)
+	}
+	if is := issues[4]; len(is.Refs) != 1 || is.Refs[0].ExecID != "E4" || is.Text != "CumQty fell from 30 to 20" {
+		t.Errorf("unexpected CumQty issue %+v", is)
+	}
+}
+
+func TestCheckExecutionReportsCorrectionsAndAvgPx(t *testing.T) {
+	issues := checkExecLog(t,
+		execReport("37=O3|11=C3|17=E1|150=F|39=1|55=IBM|54=1|38=100|32=50|31=10|14=50|151=50|6=10|"),
+		execReport("37=O3|11=C3|17=E2|150=G|19=E1|39=1|55=IBM|54=1|38=100|32=40|31=10|14=40|151=60|6=10|"),
+		execReport("37=O3|11=C3|17=E3|150=F|39=2|55=IBM|54=1|38=100|32=60|31=20|14=100|151=0|6=17|"),
+		execReport("37=O4|11=C4|17=X9|150=F|39=1|55=IBM|54=1|38=100|32=10|31=10|14=50|151=50|6=12|"),
+		execReport("37=O4|11=C4|17=X10|150=4|39=4|55=IBM|54=1|38=100|14=50|151=10|6=12|"),
+	)
+
+	want := []string{ExecAvgPx, ExecQtyBalance}
+	if got := execIssueCodes(issues); !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v\n%+v", got, want, issues)
+	}
+	if is := issues[0]; is.Text != "AvgPx 17 does not match the fills' average price 16.00000000" {
+		t.Errorf("unexpected AvgPx issue %q", is.Text)
+	}
+	if is := issues[1]; is.Text != "LeavesQty 10 is not 0 with OrdStatus 4 (CANCELED)" {
+		t.Errorf("unexpected done-order issue %q", is.Text)
+	}
+}
+
+func TestWriteExecIssues(t *testing.T) {
+	issues := []ExecIssue{{
+		Code:  ExecCumQtyDecreased,
+		Order: "OrderID O1",
+		Text:  "CumQty fell from 30 to 20",
+		At:    ExecRef{File: "a.log", Index: 5, Offset: 400, ExecID: "E5"},
+		Refs:  []ExecRef{{File: "a.log", Index: 4, Offset: 300, ExecID: "E4"}},
+	}}
+
+	var buf bytes.Buffer
+	WriteExecIssues(&buf, issues)
+	want := "CUMQTY_DECREASED OrderID O1 at a.log message 5 (offset 400, ExecID E5): CumQty fell from 30 to 20\n" +
+		"    see a.log message 4 (offset 300, ExecID E4)\n" +
+		"1 execution report issue(s)\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	WriteExecIssues(&buf, nil)
+	if buf.String() != "No execution report issues\n" {
+		t.Errorf("unexpected output %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteExecIssuesJSON(&buf, nil); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("expected an empty JSON array, got %q, %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	_ = WriteExecIssuesJSON(&buf, issues)
+	var got []ExecIssue
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || len(got) != 1 || got[0].Refs[0].Offset != 300 {
+		t.Errorf("unexpected JSON %s (%v)", buf.String(), err)
+	}
+}
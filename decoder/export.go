@@ -0,0 +1,508 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ExportFormats lists the formats accepted by ExportSchema and
+// WriteReference.
+var ExportFormats = []string{"json", "jsonschema", "orchestra", "markdown", "html"}
+
+// SchemaDocument is the JSON form of a dictionary.
+type SchemaDocument struct {
+	Version     string            `json:"version"`
+	ServicePack string            `json:"servicePack,omitempty"`
+	Fields      []DocumentField   `json:"fields"`
+	Components  []DocumentSection `json:"components"`
+	Messages    []DocumentMessage `json:"messages"`
+}
+
+// DocumentField is a field and its enum values.
+type DocumentField struct {
+	Number int     `json:"number"`
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Values []Value `json:"values,omitempty"`
+}
+
+// DocumentMember is a field, component or group within a message,
+// component or group.
+type DocumentMember struct {
+	Kind     string           `json:"kind"` // field, component or group
+	Name     string           `json:"name"`
+	Number   int              `json:"number,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Required bool             `json:"required"`
+	Rules    []PresenceRule   `json:"rules,omitempty"`
+	Members  []DocumentMember `json:"members,omitempty"`
+}
+
+// DocumentSection is a named component, including Header and Trailer.
+type DocumentSection struct {
+	Name    string           `json:"name"`
+	Members []DocumentMember `json:"members"`
+}
+
+// DocumentMessage is a message and its members.
+type DocumentMessage struct {
+	Name    string           `json:"name"`
+	MsgType string           `json:"msgType"`
+	MsgCat  string           `json:"msgCat"`
+	Members []DocumentMember `json:"members"`
+}
+
+// ExportSchema writes schema to w as a JSON document ("json"), a JSON
+// Schema for messages in the FIX JSON encoding ("jsonschema") or a FIX
+// Orchestra repository ("orchestra"). Markdown and HTML references are
+// written to a directory by WriteReference.
+func ExportSchema(w io.Writer, schema SchemaTree, format string) error {
+	switch format {
+	case "json":
+		return writeIndentedJSON(w, NewSchemaDocument(schema))
+	case "jsonschema":
+		return writeIndentedJSON(w, messageJSONSchema(schema))
+	case "orchestra":
+		return writeOrchestra(w, schema)
+	default:
+		return fmt.Errorf("invalid export format: %q", format)
+	}
+}
+
+func writeIndentedJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// NewSchemaDocument converts schema, with fields sorted by tag, components
+// by name and messages by MsgType.
+func NewSchemaDocument(schema SchemaTree) SchemaDocument {
+	doc := SchemaDocument{
+		Version:    schema.Version,
+		Fields:     make([]DocumentField, 0, len(schema.Fields)),
+		Components: make([]DocumentSection, 0, len(schema.Components)),
+		Messages:   make([]DocumentMessage, 0, len(schema.Messages)),
+	}
+	if hasServicePack(schema) {
+		doc.ServicePack = schema.ServicePack
+	}
+
+	for _, f := range sortedFields(schema) {
+		doc.Fields = append(doc.Fields, DocumentField{Number: f.Number, Name: f.Name, Type: f.Type, Values: f.Values})
+	}
+
+	for _, c := range sortedComponents(schema) {
+		doc.Components = append(doc.Components, DocumentSection{Name: c.Name, Members: documentMembers(c.Fields, c.Components, c.Groups)})
+	}
+
+	for _, m := range sortedMessages(schema) {
+		doc.Messages = append(doc.Messages, DocumentMessage{
+			Name:    m.Name,
+			MsgType: m.MsgType,
+			MsgCat:  m.MsgCat,
+			Members: documentMembers(m.Fields, m.Components, m.Groups),
+		})
+	}
+
+	return doc
+}
+
+// hasServicePack reports whether schema names a service pack; QuickFIX
+// dictionaries without one say "0" and BuildSchema records "n/a".
+func hasServicePack(schema SchemaTree) bool {
+	return schema.ServicePack != "" && schema.ServicePack != "n/a" && schema.ServicePack != "0"
+}
+
+func documentMembers(fields []FieldNode, comps []ComponentNode, groups []GroupNode) []DocumentMember {
+	out := make([]DocumentMember, 0, len(fields)+len(comps)+len(groups))
+
+	for _, f := range fields {
+		out = append(out, DocumentMember{
+			Kind:     "field",
+			Name:     f.Field.Name,
+			Number:   f.Field.Number,
+			Type:     f.Field.Type,
+			Required: f.Ref.Required == "Y",
+			Rules:    f.Ref.Rules,
+		})
+	}
+
+	for _, c := range comps {
+		out = append(out, DocumentMember{
+			Kind:     "component",
+			Name:     c.Name,
+			Required: c.Required == "Y",
+			Members:  documentMembers(c.Fields, c.Components, c.Groups),
+		})
+	}
+
+	for _, g := range groups {
+		out = append(out, DocumentMember{
+			Kind:     "group",
+			Name:     g.Name,
+			Required: g.Required == "Y",
+			Members:  documentMembers(g.Fields, g.Components, g.Groups),
+		})
+	}
+
+	return out
+}
+
+func sortedComponents(schema SchemaTree) []ComponentNode {
+	comps := make([]ComponentNode, 0, len(schema.Components))
+	for _, c := range schema.Components {
+		comps = append(comps, c)
+	}
+	sort.Slice(comps, func(i, j int) bool { return comps[i].Name < comps[j].Name })
+	return comps
+}
+
+func sortedMessages(schema SchemaTree) []MessageNode {
+	msgs := make([]MessageNode, 0, len(schema.Messages))
+	for _, m := range schema.Messages {
+		msgs = append(msgs, m)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if len(msgs[i].MsgType) != len(msgs[j].MsgType) {
+			return len(msgs[i].MsgType) < len(msgs[j].MsgType)
+		}
+		return msgs[i].MsgType < msgs[j].MsgType
+	})
+	return msgs
+}
+
+// jsonSchema is the subset of JSON Schema 2020-12 used by
+// messageJSONSchema.
+type jsonSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Type        string                 `json:"type,omitempty"`
+	Const       string                 `json:"const,omitempty"`
+	Enum        []string               `json:"enum,omitempty"`
+	Properties  map[string]*jsonSchema `json:"properties,omitempty"`
+	Required    []string               `json:"required,omitempty"`
+	Items       *jsonSchema            `json:"items,omitempty"`
+	MinItems    int                    `json:"minItems,omitempty"`
+	AllOf       []*jsonSchema          `json:"allOf,omitempty"`
+	OneOf       []*jsonSchema          `json:"oneOf,omitempty"`
+	Defs        map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// messageJSONSchema describes messages in the FIX JSON encoding written by
+// WriteSkeleton: Header, Body and Trailer objects keyed by field name,
+// string values, components flattened and groups as arrays of objects.
+func messageJSONSchema(schema SchemaTree) *jsonSchema {
+	header, trailer := headerAndTrailer(schema)
+
+	root := &jsonSchema{
+		Schema: "https://json-schema.org/draft/2020-12/schema",
+		Title:  "FIX " + schema.Version + " messages",
+		Defs: map[string]*jsonSchema{
+			"Header":  jsonObjectSchema(schema, header.Fields, header.Components, header.Groups),
+			"Trailer": jsonObjectSchema(schema, trailer.Fields, trailer.Components, trailer.Groups),
+		},
+	}
+
+	for _, m := range sortedMessages(schema) {
+		root.OneOf = append(root.OneOf, &jsonSchema{Ref: "#/$defs/" + m.Name})
+		root.Defs[m.Name] = &jsonSchema{
+			Title: m.Name + " (" + m.MsgType + ")",
+			Type:  "object",
+			Properties: map[string]*jsonSchema{
+				"Header": {AllOf: []*jsonSchema{
+					{Ref: "#/$defs/Header"},
+					{Properties: map[string]*jsonSchema{"MsgType": {Const: m.MsgType}}},
+				}},
+				"Body":    jsonObjectSchema(schema, m.Fields, m.Components, m.Groups),
+				"Trailer": {Ref: "#/$defs/Trailer"},
+			},
+			Required: []string{"Header", "Body", "Trailer"},
+		}
+	}
+
+	return root
+}
+
+func jsonObjectSchema(schema SchemaTree, fields []FieldNode, comps []ComponentNode, groups []GroupNode) *jsonSchema {
+	obj := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+	addJSONProperties(obj, schema, fields, comps, groups, true)
+	return obj
+}
+
+// addJSONProperties adds the members to obj. Members of an optional
+// component are never required.
+func addJSONProperties(obj *jsonSchema, schema SchemaTree, fields []FieldNode, comps []ComponentNode, groups []GroupNode, required bool) {
+	for _, f := range fields {
+		prop := &jsonSchema{
+			Type:        "string",
+			Description: fmt.Sprintf("%d %s (%s)", f.Field.Number, f.Field.Name, f.Field.Type),
+		}
+		for _, v := range f.Field.Values {
+			prop.Enum = append(prop.Enum, v.Enum)
+		}
+		obj.Properties[f.Field.Name] = prop
+
+		if required && f.Ref.Required == "Y" {
+			obj.Required = append(obj.Required, f.Field.Name)
+		}
+	}
+
+	for _, c := range comps {
+		addJSONProperties(obj, schema, c.Fields, c.Components, c.Groups, required && c.Required == "Y")
+	}
+
+	for _, g := range groups {
+		count := schema.Fields[g.Name]
+		obj.Properties[g.Name] = &jsonSchema{
+			Type:        "array",
+			Description: fmt.Sprintf("%d %s (%s)", count.Number, g.Name, count.Type),
+			Items:       jsonObjectSchema(schema, g.Fields, g.Components, g.Groups),
+			MinItems:    1,
+		}
+
+		if required && g.Required == "Y" {
+			obj.Required = append(obj.Required, g.Name)
+		}
+	}
+}
+
+// messageStructure renders msg, header and trailer included, as the
+// --message listing does.
+func messageStructure(schema SchemaTree, msg MessageNode) string {
+	var buf bytes.Buffer
+	NewRenderer(&buf, 100, NoTheme).DisplayMessageStructureWithOptions(schema, msg, false, true, true, false, 0)
+	return buf.String()
+}
+
+// referencePage is one message of a Markdown or HTML reference.
+type referencePage struct {
+	File      string
+	Name      string
+	MsgType   string
+	MsgCat    string
+	Version   string
+	Structure string
+	Fields    []referenceField
+}
+
+// referenceField is a row of a message's field table. In names the
+// component or group holding the field, empty for the body itself.
+type referenceField struct {
+	Tag      int
+	Name     string
+	Type     string
+	Required bool
+	In       string
+	Values   []Value
+}
+
+// WriteReference writes a Markdown ("markdown") or HTML ("html") reference
+// to dir: an index of messages and one page per message with its
+// structure, as listed by --message, and a table of its fields and enums.
+func WriteReference(dir string, schema SchemaTree, format string) error {
+	var ext string
+	var write func(io.Writer, referencePage) error
+	var writeIndex func(io.Writer, string, []referencePage) error
+
+	switch format {
+	case "markdown":
+		ext, write, writeIndex = ".md", writeMarkdownPage, writeMarkdownIndex
+	case "html":
+		ext, write, writeIndex = ".html", writeHTMLPage, writeHTMLIndex
+	default:
+		return fmt.Errorf("invalid reference format: %q", format)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	version := "FIX " + schema.Version
+	if hasServicePack(schema) {
+		version += " SP" + schema.ServicePack
+	}
+
+	var pages []referencePage
+	for _, m := range sortedMessages(schema) {
+		page := referencePage{
+			File:      m.Name + ext,
+			Name:      m.Name,
+			MsgType:   m.MsgType,
+			MsgCat:    m.MsgCat,
+			Version:   version,
+			Structure: messageStructure(schema, m),
+			Fields:    referenceFields(nil, "", m.Fields, m.Components, m.Groups, schema),
+		}
+		pages = append(pages, page)
+
+		if err := writeFile(filepath.Join(dir, page.File), func(w io.Writer) error { return write(w, page) }); err != nil {
+			return err
+		}
+	}
+
+	return writeFile(filepath.Join(dir, "index"+ext), func(w io.Writer) error { return writeIndex(w, version, pages) })
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func referenceFields(out []referenceField, in string, fields []FieldNode, comps []ComponentNode, groups []GroupNode, schema SchemaTree) []referenceField {
+	for _, f := range fields {
+		out = append(out, referenceField{
+			Tag:      f.Field.Number,
+			Name:     f.Field.Name,
+			Type:     f.Field.Type,
+			Required: f.Ref.Required == "Y",
+			In:       in,
+			Values:   f.Field.Values,
+		})
+	}
+
+	for _, c := range comps {
+		out = referenceFields(out, c.Name, c.Fields, c.Components, c.Groups, schema)
+	}
+
+	for _, g := range groups {
+		count := schema.Fields[g.Name]
+		out = append(out, referenceField{Tag: count.Number, Name: g.Name, Type: count.Type, Required: g.Required == "Y", In: in})
+		out = referenceFields(out, g.Name, g.Fields, g.Components, g.Groups, schema)
+	}
+
+	return out
+}
+
+// markdownCell escapes the pipes that would end a table cell.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+func writeMarkdownPage(w io.Writer, p referencePage) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s (%s)\n\n", p.Name, p.MsgType)
+	fmt.Fprintf(&sb, "%s, category %s. [All messages](index.md)\n\n", p.Version, p.MsgCat)
+	fmt.Fprintf(&sb, "## Structure\n\n```text\n%s```\n\n", p.Structure)
+	sb.WriteString("## Fields\n\n")
+	sb.WriteString("| Tag | Name | Type | Required | In | Values |\n")
+	sb.WriteString("|----:|------|------|:--------:|----|--------|\n")
+
+	for _, f := range p.Fields {
+		required := ""
+		if f.Required {
+			required = "Y"
+		}
+
+		values := make([]string, 0, len(f.Values))
+		for _, v := range f.Values {
+			values = append(values, fmt.Sprintf("`%s` %s", markdownCell(v.Enum), markdownCell(v.Description)))
+		}
+
+		fmt.Fprintf(&sb, "| %d | %s | %s | %s | %s | %s |\n", f.Tag, f.Name, f.Type, required, f.In, strings.Join(values, "<br>"))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeMarkdownIndex(w io.Writer, version string, pages []referencePage) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s messages\n\n", version)
+	sb.WriteString("| MsgType | Message | Category |\n")
+	sb.WriteString("|---------|---------|----------|\n")
+
+	for _, p := range pages {
+		fmt.Fprintf(&sb, "| %s | [%s](%s) | %s |\n", p.MsgType, p.Name, p.File, p.MsgCat)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+const referenceStyle = `<style>
+body { font-family: sans-serif; margin: 2em; }
+pre { background: #f4f4f4; padding: 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.2em 0.5em; text-align: left; vertical-align: top; }
+code { background: #f4f4f4; }
+</style>`
+
+var referencePageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Name}} ({{.MsgType}})</title>` + referenceStyle + `</head>
+<body>
+<h1>{{.Name}} ({{.MsgType}})</h1>
+<p>{{.Version}}, category {{.MsgCat}}. <a href="index.html">All messages</a></p>
+<h2>Structure</h2>
+<pre>{{.Structure}}</pre>
+<h2>Fields</h2>
+<table>
+<tr><th>Tag</th><th>Name</th><th>Type</th><th>Required</th><th>In</th><th>Values</th></tr>
+{{range .Fields}}<tr><td>{{.Tag}}</td><td>{{.Name}}</td><td>{{.Type}}</td><td>{{if .Required}}Y{{end}}</td><td>{{.In}}</td><td>{{range $i, $v := .Values}}{{if $i}}<br>{{end}}<code>{{$v.Enum}}</code> {{$v.Description}}{{end}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+var referenceIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Version}} messages</title>` + referenceStyle + `</head>
+<body>
+<h1>{{.Version}} messages</h1>
+<table>
+<tr><th>MsgType</th><th>Message</th><th>Category</th></tr>
+{{range .Pages}}<tr><td>{{.MsgType}}</td><td><a href="{{.File}}">{{.Name}}</a></td><td>{{.MsgCat}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+func writeHTMLPage(w io.Writer, p referencePage) error {
+	return referencePageTemplate.Execute(w, p)
+}
+
+func writeHTMLIndex(w io.Writer, version string, pages []referencePage) error {
+	return referenceIndexTemplate.Execute(w, struct {
+		Version string
+		Pages   []referencePage
+	}{version, pages})
+}
@@ -0,0 +1,242 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExportSchemaJSON(t *testing.T) {
+	var out bytes.Buffer
+	if err := ExportSchema(&out, embeddedSchema(t, "44"), "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc SchemaDocument
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.Version != "4.4" || doc.ServicePack != "" || doc.Fields[0].Number != 1 || doc.Messages[0].MsgType != "0" {
+		t.Fatalf("unexpected document %s %q %+v %+v", doc.Version, doc.ServicePack, doc.Fields[0], doc.Messages[0])
+	}
+
+	i := slices.IndexFunc(doc.Messages, func(m DocumentMessage) bool { return m.MsgType == "D" })
+	order := doc.Messages[i]
+	if order.Name != "NewOrderSingle" || order.MsgCat != "app" {
+		t.Fatalf("unexpected message %+v", order)
+	}
+
+	want := DocumentMember{Kind: "field", Name: "ClOrdID", Number: 11, Type: "STRING", Required: true}
+	if !reflect.DeepEqual(order.Members[0], want) {
+		t.Errorf("got %+v, want %+v", order.Members[0], want)
+	}
+
+	j := slices.IndexFunc(order.Members, func(m DocumentMember) bool { return m.Name == "Instrument" })
+	if j < 0 || order.Members[j].Kind != "component" || !order.Members[j].Required || len(order.Members[j].Members) == 0 {
+		t.Errorf("expected the required Instrument component with members, got %+v", order.Members)
+	}
+}
+
+func TestExportSchemaJSONSchema(t *testing.T) {
+	var out bytes.Buffer
+	if err := ExportSchema(&out, embeddedSchema(t, "44"), "jsonschema"); err != nil {
+		t.Fatal(err)
+	}
+
+	var root jsonSchema
+	if err := json.Unmarshal(out.Bytes(), &root); err != nil {
+		t.Fatal(err)
+	}
+
+	order := root.Defs["NewOrderSingle"]
+	if order == nil || len(root.OneOf) != 93 || root.OneOf[0].Ref != "#/$defs/Heartbeat" {
+		t.Fatalf("expected a definition per message, got %d", len(root.OneOf))
+	}
+
+	if order.Properties["Header"].AllOf[1].Properties["MsgType"].Const != "D" {
+		t.Error("expected the header to pin MsgType")
+	}
+
+	body := order.Properties["Body"]
+	for _, name := range []string{"ClOrdID", "Side", "TransactTime", "OrdType"} {
+		if !slices.Contains(body.Required, name) {
+			t.Errorf("expected %s to be required, got %v", name, body.Required)
+		}
+	}
+	if slices.Contains(body.Required, "PartyID") {
+		t.Error("expected members of an optional group not to be required")
+	}
+
+	if side := body.Properties["Side"]; side.Type != "string" || !slices.Contains(side.Enum, "1") || side.Description != "54 Side (CHAR)" {
+		t.Errorf("unexpected Side property %+v", side)
+	}
+
+	parties := body.Properties["NoPartyIDs"]
+	if parties.Type != "array" || parties.Items.Properties["PartyID"] == nil {
+		t.Errorf("expected NoPartyIDs to be an array of objects, got %+v", parties)
+	}
+
+	if header := root.Defs["Header"]; !slices.Contains(header.Required, "SenderCompID") {
+		t.Errorf("expected SenderCompID to be required in the header, got %v", header.Required)
+	}
+}
+
+func TestExportSchemaOrchestraRoundTrip(t *testing.T) {
+	for _, ver := range []string{"42", "44", "50SP2"} {
+		schema := embeddedSchema(t, ver)
+
+		var out bytes.Buffer
+		if err := ExportSchema(&out, schema, "orchestra"); err != nil {
+			t.Fatal(err)
+		}
+
+		dict, err := ParseFixDictionary(out.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		back := BuildSchema(dict)
+		if d := DiffDictionaries(schema, back); !d.Empty() || back.Version != schema.Version || hasServicePack(back) != hasServicePack(schema) {
+			t.Errorf("FIX %s: expected an identical dictionary, got %s/%s %+v", ver, back.Version, back.ServicePack, d)
+		}
+	}
+}
+
+func TestExportSchemaOrchestraKeepsRules(t *testing.T) {
+	dict, err := ParseFixDictionary([]byte(orchestraXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := ExportSchema(&out, BuildSchema(dict), "orchestra"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), `<fixr:fieldRef id="44" presence="conditional">`) {
+		t.Errorf("expected a conditional Price, got:\n%s", out.String())
+	}
+
+	again, err := ParseFixDictionary(out.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	price := BuildSchema(again).Messages["NewOrderSingle"].Fields[2]
+	want := []PresenceRule{{Name: "PriceForLimit", Presence: "required", When: "OrdType == ^Limit"}}
+	if price.Field.Name != "Price" || !reflect.DeepEqual(price.Ref.Rules, want) {
+		t.Errorf("expected the rule to survive, got %+v", price)
+	}
+}
+
+func TestExportSchemaInvalidFormat(t *testing.T) {
+	if err := ExportSchema(&bytes.Buffer{}, SchemaTree{}, "pdf"); err == nil || !strings.Contains(err.Error(), "invalid export format") {
+		t.Errorf("expected an invalid format error, got %v", err)
+	}
+	if err := WriteReference(t.TempDir(), SchemaTree{}, "pdf"); err == nil || !strings.Contains(err.Error(), "invalid reference format") {
+		t.Errorf("expected an invalid format error, got %v", err)
+	}
+}
+
+func TestWriteReferenceMarkdown(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ref")
+	if err := WriteReference(dir, embeddedSchema(t, "44"), "markdown"); err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), "| D | [NewOrderSingle](NewOrderSingle.md) | app |") {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "NewOrderSingle.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# NewOrderSingle (D)\n\nFIX 4.4, category app.",
+		"```text\nMessage: NewOrderSingle (D)\nComponent: Header\n",
+		"| 11 | ClOrdID | STRING | Y |  |  |\n",
+		"| 54 | Side | CHAR | Y |  | `1` BUY<br>`2` SELL<br>",
+		"| 448 | PartyID | STRING |  | NoPartyIDs |  |\n",
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 94 {
+		t.Errorf("expected an index and 93 pages, got %d files", len(entries))
+	}
+}
+
+func TestWriteReferenceHTML(t *testing.T) {
+	dir := t.TempDir()
+	schema := embeddedSchema(t, "44")
+	schema.Messages["NewOrderSingle"].Fields[0].Field.Values = []Value{{Enum: "<x>", Description: "a & b"}}
+
+	if err := WriteReference(dir, schema, "html"); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := os.ReadFile(filepath.Join(dir, "NewOrderSingle.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"<h1>NewOrderSingle (D)</h1>",
+		"<pre>Message: NewOrderSingle (D)\n",
+		"<code>&lt;x&gt;</code> a &amp; b",
+		`<a href="index.html">All messages</a>`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	if index, _ := os.ReadFile(filepath.Join(dir, "index.html")); !strings.Contains(string(index), `<a href="NewOrderSingle.html">NewOrderSingle</a>`) {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+}
+
+func TestWriteReferenceDirectoryError(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	_ = os.WriteFile(file, nil, 0o644)
+
+	if err := WriteReference(filepath.Join(file, "ref"), embeddedSchema(t, "44"), "markdown"); err == nil {
+		t.Error("expected an error when the directory cannot be created")
+	}
+}
@@ -0,0 +1,156 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severity ranks a validation finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Finding codes. They are part of the JSON and JUnit reports and of
+// -fail-on, so existing codes must not change.
+const (
+	CodeMissingTag     = "MISSING_TAG"     // a required tag is absent
+	CodeHeaderOrder    = "HEADER_ORDER"    // 8, 9, 35 do not open the message or 10 does not close it
+	CodeMisplacedTag   = "MISPLACED_TAG"   // a header tag after the header or a body tag in the trailer
+	CodeRepeatedTag    = "REPEATED_TAG"    // a tag repeats outside a repeating group
+	CodeBodyLength     = "BODY_LENGTH"     // BodyLength (9) does not match the message
+	CodeChecksum       = "CHECKSUM"        // CheckSum (10) is missing or wrong
+	CodeMissingMsgType = "MISSING_MSGTYPE" // no MsgType (35)
+	CodeUnknownMsgType = "UNKNOWN_MSGTYPE" // MsgType not in the dictionary
+	CodeInvalidEnum    = "INVALID_ENUM"    // value not among the field's enums
+	CodeInvalidType    = "INVALID_TYPE"    // value does not match the field's data type
+	CodeFieldOrder     = "FIELD_ORDER"     // body fields out of dictionary order
+	CodeRule           = "RULE"            // a conditional rule is broken
+	CodeProfile        = "PROFILE"         // a counterparty profile is broken
+)
+
+// FindingCodes lists every finding code.
+var FindingCodes = []string{
+	CodeMissingTag, CodeHeaderOrder, CodeMisplacedTag, CodeRepeatedTag,
+	CodeBodyLength, CodeChecksum, CodeMissingMsgType, CodeUnknownMsgType,
+	CodeInvalidEnum, CodeInvalidType, CodeFieldOrder, CodeRule, CodeProfile,
+}
+
+// Finding is one validation result. Validation fills in Code, Severity,
+// Tag and Text; a Report adds where the message was found.
+type Finding struct {
+	File     string   `json:"file,omitempty"`
+	Index    int      `json:"index,omitempty"` // 1-based message number within File
+	Offset   int64    `json:"offset"`          // byte offset of the message within File
+	MsgType  string   `json:"msgType,omitempty"`
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Tag      int      `json:"tag,omitempty"`
+	Text     string   `json:"message"`
+
+	// RejectMsgType is what a counterparty would reply with: a Reject
+	// ("3") carrying SessionRejectReason RejectReason, or a
+	// BusinessMessageReject ("j") carrying BusinessRejectReason
+	// RejectReason. It is empty when the message would be dropped as
+	// garbled or accepted.
+	RejectMsgType string `json:"rejectMsgType,omitempty"`
+	RejectReason  int    `json:"rejectReason,omitempty"`
+}
+
+func (f Finding) String() string {
+	return f.Text
+}
+
+// newFinding builds an error-severity finding about tag.
+func newFinding(code string, tag int, format string, args ...any) Finding {
+	return Finding{Code: code, Severity: SeverityError, Tag: tag, Text: fmt.Sprintf(format, args...)}
+}
+
+// rejectFinding is newFinding for a structural error, with the
+// SessionRejectReason a counterparty would reject it with.
+func rejectFinding(code string, reason, tag int, format string, args ...any) Finding {
+	f := newFinding(code, tag, format, args...).rejectWith("3", reason)
+	f.Text += fmt.Sprintf(" (SessionRejectReason %d)", reason)
+	return f
+}
+
+// rejectWith records the reply a counterparty would send for f.
+func (f Finding) rejectWith(msgType string, reason int) Finding {
+	f.RejectMsgType, f.RejectReason = msgType, reason
+	return f
+}
+
+// findingTexts returns the text of each finding.
+func findingTexts(findings []Finding) []string {
+	if findings == nil {
+		return nil
+	}
+
+	out := make([]string, len(findings))
+	for i, f := range findings {
+		out[i] = f.Text
+	}
+	return out
+}
+
+// FailOn selects the findings that fail a run, by code or by severity.
+type FailOn map[string]bool
+
+// ParseFailOn parses a comma-separated list of finding codes and
+// severities. An empty list, or "none", fails on nothing.
+func ParseFailOn(list string) (FailOn, error) {
+	failOn := FailOn{}
+
+	for _, item := range splitList(list) {
+		switch upper := strings.ToUpper(item); {
+		case strings.EqualFold(item, "none"):
+		case isSeverity(Severity(strings.ToLower(item))):
+			failOn[strings.ToLower(item)] = true
+		case isFindingCode(upper):
+			failOn[upper] = true
+		default:
+			return nil, fmt.Errorf("unknown finding code or severity %q", item)
+		}
+	}
+
+	return failOn, nil
+}
+
+// Fails reports whether f fails the run.
+func (fo FailOn) Fails(f Finding) bool {
+	return fo[f.Code] || fo[string(f.Severity)]
+}
+
+func isSeverity(s Severity) bool {
+	return s == SeverityError || s == SeverityWarning || s == SeverityInfo
+}
+
+func isFindingCode(code string) bool {
+	return slices.Contains(FindingCodes, code)
+}
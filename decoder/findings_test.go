@@ -0,0 +1,104 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func findingFor(findings []Finding, code string) (Finding, bool) {
+	i := slices.IndexFunc(findings, func(f Finding) bool { return f.Code == code })
+	if i < 0 {
+		return Finding{}, false
+	}
+	return findings[i], true
+}
+
+func TestValidateFindingsCodes(t *testing.T) {
+	d := fix44Lookup(t)
+	const order = "35=D|49=A|56=B|34=1|52=20240101-00:00:00|11=1|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=1|"
+
+	if f := ValidateFindings(framedFIX44(order), d, DecodeOptions{}); len(f) != 0 {
+		t.Fatalf("expected no findings, got %v", f)
+	}
+
+	cases := []struct {
+		body     string
+		code     string
+		severity Severity
+		tag      int
+	}{
+		{strings.Replace(order, "40=1|", "", 1), CodeMissingTag, SeverityError, 40},
+		{strings.Replace(order, "54=1", "54=Z", 1), CodeInvalidEnum, SeverityError, 54},
+		{strings.Replace(order, "38=100", "38=1e2", 1), CodeInvalidType, SeverityError, 38},
+		{strings.Replace(order, "11=1|", "", 1) + "11=1|", CodeFieldOrder, SeverityWarning, 11},
+		{order + "55=MSFT|", CodeRepeatedTag, SeverityError, 55},
+		{order + "49=C|", CodeMisplacedTag, SeverityError, 49},
+		{strings.Replace(order, "35=D", "35=UX", 1), CodeUnknownMsgType, SeverityInfo, 35},
+		{strings.Replace(order, "35=D", "35=ZZ", 1), CodeUnknownMsgType, SeverityError, 35},
+	}
+
+	for _, tc := range cases {
+		f, ok := findingFor(ValidateFindings(framedFIX44(tc.body), d, DecodeOptions{}), tc.code)
+		if !ok || f.Severity != tc.severity || f.Tag != tc.tag {
+			t.Errorf("%s: got %+v (found=%v), want %s on tag %d", tc.code, f, ok, tc.severity, tc.tag)
+		}
+	}
+
+	bad := strings.Replace(framedFIX44(order), "9=", "9=1", 1)
+	if f, ok := findingFor(ValidateFindings(bad, d, DecodeOptions{}), CodeBodyLength); !ok || f.Tag != 9 || f.RejectMsgType != "" || f.RejectReason != 0 {
+		t.Errorf("unexpected BodyLength finding %+v", f)
+	}
+	if _, ok := findingFor(ValidateFindings(bad, d, DecodeOptions{}), CodeChecksum); !ok {
+		t.Error("expected a checksum finding")
+	}
+}
+
+func TestParseFailOn(t *testing.T) {
+	fo, err := ParseFailOn("error, field_order")
+	if err != nil {
+		t.Fatalf("ParseFailOn failed: %v", err)
+	}
+
+	if !fo.Fails(Finding{Code: CodeChecksum, Severity: SeverityError}) {
+		t.Error("expected errors to fail")
+	}
+	if !fo.Fails(Finding{Code: CodeFieldOrder, Severity: SeverityWarning}) {
+		t.Error("expected FIELD_ORDER to fail by code")
+	}
+	if fo.Fails(Finding{Code: CodeUnknownMsgType, Severity: SeverityInfo}) {
+		t.Error("expected info findings to pass")
+	}
+
+	for _, list := range []string{"", "none"} {
+		if fo, err := ParseFailOn(list); err != nil || len(fo) != 0 {
+			t.Errorf("ParseFailOn(%q) = %v, %v", list, fo, err)
+		}
+	}
+
+	if _, err := ParseFailOn("error,BOGUS"); err == nil || !strings.Contains(err.Error(), `"BOGUS"`) {
+		t.Errorf("expected an unknown code error, got %v", err)
+	}
+}
@@ -33,6 +33,15 @@ type FieldValue struct {
 	Value string
 }
 
+// NormaliseDelimiters converts a pipe-delimited message (as often written to
+// human-readable logs) back to SOH so it can be parsed and validated.
+func NormaliseDelimiters(msg string) string {
+	if strings.Contains(msg, "\x01") || !strings.Contains(msg, "|") {
+		return msg
+	}
+	return strings.ReplaceAll(msg, "|", "\x01")
+}
+
 func ParseFix(msg string) []FieldValue {
 	// If there's no SOH delimiter, assume no valid fields
 	if !strings.Contains(msg, "\x01") {
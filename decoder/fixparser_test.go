@@ -86,3 +86,17 @@ func TestParseFixInvalidTagNumber(t *testing.T) {
 		t.Errorf("Expected valid numeric tags only, got %v", got)
 	}
 }
+
+func TestNormaliseDelimiters(t *testing.T) {
+	cases := map[string]string{
+		"8=FIX.4.4|35=A|10=000|":            "8=FIX.4.4\x0135=A\x0110=000\x01",
+		"8=FIX.4.4\x0158=a|b\x0110=000\x01": "8=FIX.4.4\x0158=a|b\x0110=000\x01",
+		"no delimiters":                     "no delimiters",
+	}
+
+	for in, want := range cases {
+		if got := NormaliseDelimiters(in); got != want {
+			t.Errorf("NormaliseDelimiters(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
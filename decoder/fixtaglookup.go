@@ -36,6 +36,9 @@ import (
 var chooseEmbeddedXML = fix.ChooseEmbeddedXML
 
 type rawFix struct {
+	Major string `xml:"major,attr"`
+	Minor string `xml:"minor,attr"`
+
 	Fields []struct {
 		XMLName xml.Name `xml:"field"`
 		Name    string   `xml:"name,attr"`
@@ -84,15 +87,26 @@ type GroupDef struct {
 
 type FixTagLookup struct {
 	tagToName   map[int]string
+	nameToTag   map[string]int
+	headerTags  map[int]bool
+	trailerTags map[int]bool
 	enumMap     map[int]map[string]string
 	fieldTypes  map[int]string
 	groupCounts map[int]bool
 	groupOwners map[int]int
 	groupDefs   map[int]GroupDef
+	msgGroups   map[string]map[int]GroupDef // MsgType → layouts of the groups inside it
+	repeatable  map[string]map[int]bool     // MsgType → tags inside its repeating groups
+	rules       []Rule                      // standard pack plus Orchestra presence rules
 	Messages    map[string]MessageDef
 }
 
 func parseDictionary(xmlData string) (*FixTagLookup, error) {
+	xmlData, err := quickFIXXML(xmlData)
+	if err != nil {
+		return nil, err
+	}
+
 	dec := xml.NewDecoder(strings.NewReader(xmlData))
 	dec.CharsetReader = charset.NewReaderLabel
 
@@ -103,11 +117,16 @@ func parseDictionary(xmlData string) (*FixTagLookup, error) {
 
 	d := &FixTagLookup{
 		tagToName:   make(map[int]string, len(raw.Fields)),
+		nameToTag:   make(map[string]int, len(raw.Fields)),
+		headerTags:  make(map[int]bool),
+		trailerTags: make(map[int]bool),
 		enumMap:     make(map[int]map[string]string, len(raw.Fields)),
 		fieldTypes:  make(map[int]string, len(raw.Fields)),
 		groupCounts: make(map[int]bool),
 		groupOwners: make(map[int]int),
 		groupDefs:   make(map[int]GroupDef),
+		msgGroups:   make(map[string]map[int]GroupDef),
+		repeatable:  make(map[string]map[int]bool),
 		Messages:    make(map[string]MessageDef),
 	}
 
@@ -115,13 +134,164 @@ func parseDictionary(xmlData string) (*FixTagLookup, error) {
 	parseMessages(&raw, d)
 	parseGroups(&raw, d)
 
+	if err := parseStructure(xmlData, d); err != nil {
+		return nil, err
+	}
+
 	return d, nil
 }
 
+// parseStructure records the header/trailer tags and the repeating groups
+// nested inside messages and components. QuickFIX dictionaries declare
+// groups inline rather than in a top-level <groups> block.
+func parseStructure(xmlData string, d *FixTagLookup) error {
+	dec := xml.NewDecoder(strings.NewReader(xmlData))
+	dec.CharsetReader = charset.NewReaderLabel
+
+	var dict FixDictionary
+	if err := dec.Decode(&dict); err != nil {
+		return err
+	}
+
+	comps := make(map[string]Component, len(dict.Components))
+	for _, c := range dict.Components {
+		comps[c.Name] = c
+	}
+
+	w := structureWalker{d: d, comps: comps}
+
+	for _, tag := range w.componentTags(dict.Header, 0) {
+		d.headerTags[tag] = true
+	}
+
+	for _, tag := range w.componentTags(dict.Trailer, 0) {
+		d.trailerTags[tag] = true
+	}
+
+	for _, m := range dict.Messages {
+		body := Component{Fields: m.Fields, Groups: m.Groups, Components: m.Components}
+		mw := structureWalker{d: d, comps: comps, groups: make(map[int]GroupDef)}
+		mw.componentTags(body, 0)
+		d.msgGroups[m.MsgType] = mw.groups
+
+		set := make(map[int]bool)
+		for _, c := range []Component{dict.Header, body, dict.Trailer} {
+			w.groupTags(c, set, false, 0)
+		}
+		d.repeatable[m.MsgType] = set
+	}
+
+	for _, c := range dict.Components {
+		w.componentTags(c, 0)
+	}
+
+	d.rules = append(standardRules(dict.Major, dict.Minor), orchestraRules(dict)...)
+
+	return nil
+}
+
+// maxStructureDepth guards against self-referencing components.
+const maxStructureDepth = 32
+
+type structureWalker struct {
+	d      *FixTagLookup
+	comps  map[string]Component
+	groups map[int]GroupDef // the enclosing message's group layouts; nil outside a message
+}
+
+// componentTags returns the flattened tag order of a component (nested
+// components expanded, groups represented by their NumInGroup tag) and
+// registers every group it contains.
+func (w structureWalker) componentTags(c Component, depth int) []int {
+	if depth > maxStructureDepth {
+		return nil
+	}
+
+	var tags []int
+
+	for _, f := range c.Fields {
+		if tag, ok := w.d.nameToTag[f.Name]; ok {
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, ref := range c.Components {
+		if sub, ok := w.comps[ref.Name]; ok {
+			tags = append(tags, w.componentTags(sub, depth+1)...)
+		}
+	}
+
+	for _, g := range c.Groups {
+		if tag := w.registerGroup(g, depth+1); tag != 0 {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// groupTags adds to set the tags that may repeat in c because they sit
+// inside a repeating group, including groups reached through components.
+func (w structureWalker) groupTags(c Component, set map[int]bool, inGroup bool, depth int) {
+	if depth > maxStructureDepth {
+		return
+	}
+
+	for _, f := range c.Fields {
+		if tag, ok := w.d.nameToTag[f.Name]; ok && inGroup {
+			set[tag] = true
+		}
+	}
+
+	for _, ref := range c.Components {
+		if sub, ok := w.comps[ref.Name]; ok {
+			w.groupTags(sub, set, inGroup, depth+1)
+		}
+	}
+
+	for _, g := range c.Groups {
+		if tag, ok := w.d.nameToTag[g.Name]; ok && inGroup {
+			set[tag] = true
+		}
+		w.groupTags(Component{Fields: g.Fields, Groups: g.Groups, Components: g.Components}, set, true, depth+1)
+	}
+}
+
+func (w structureWalker) registerGroup(g Group, depth int) int {
+	countTag, ok := w.d.nameToTag[g.Name]
+	if !ok {
+		return 0
+	}
+
+	order := w.componentTags(Component{Fields: g.Fields, Groups: g.Groups, Components: g.Components}, depth)
+
+	w.d.groupCounts[countTag] = true
+	for _, tag := range order {
+		if _, owned := w.d.groupOwners[tag]; !owned {
+			w.d.groupOwners[tag] = countTag
+		}
+	}
+
+	def := GroupDef{NumInGroupTag: countTag, FieldOrder: order}
+	if _, exists := w.d.groupDefs[countTag]; !exists {
+		w.d.groupDefs[countTag] = def
+	}
+	if w.groups != nil {
+		if _, exists := w.groups[countTag]; !exists {
+			w.groups[countTag] = def
+		}
+	}
+
+	return countTag
+}
+
 func parseFields(raw *rawFix, d *FixTagLookup) {
 	for _, f := range raw.Fields {
 		d.tagToName[f.Tag] = f.Name
-		d.fieldTypes[f.Tag] = f.Type
+		if d.nameToTag != nil {
+			d.nameToTag[f.Name] = f.Tag
+		}
+		d.fieldTypes[f.Tag] = raw.fieldType(f.Tag, f.Type)
 
 		enumMap := make(map[string]string, len(f.Values)+len(f.ValuesWrapper))
 		for _, v := range f.Values {
@@ -137,6 +307,19 @@ func parseFields(raw *rawFix, d *FixTagLookup) {
 	}
 }
 
+// fieldType is the type a field is validated as. FIX 4.0 and 4.1 declare
+// strings such as BeginString and the CompIDs as CHAR, and EndSeqNo (16)
+// uses 0 for "up to the latest message", which SEQNUM does not allow.
+func (raw *rawFix) fieldType(tag int, typ string) string {
+	switch {
+	case typ == "CHAR" && raw.Major == "4" && (raw.Minor == "0" || raw.Minor == "1"):
+		return "STRING"
+	case tag == 16 && typ == "SEQNUM":
+		return "INT"
+	}
+	return typ
+}
+
 func parseMessages(raw *rawFix, d *FixTagLookup) {
 	const msgTypeTag = 35
 
@@ -266,7 +449,8 @@ func detectSchemaKey(msg string) string {
 	return strings.ReplaceAll(begin, ".", "")
 }
 
-// mergeLookups grafts tags/enums from src into dst without overwriting.
+// mergeLookups grafts tags, enums and messages from src into dst without
+// overwriting, so FIX 5.0 dictionaries know FIXT.1.1 session messages.
 func mergeLookups(dst, src *FixTagLookup) {
 	if dst == nil || src == nil {
 		return
@@ -289,6 +473,34 @@ func mergeLookups(dst, src *FixTagLookup) {
 			}
 		}
 	}
+
+	mergeMissing(&dst.nameToTag, src.nameToTag)
+	mergeMissing(&dst.fieldTypes, src.fieldTypes)
+	mergeMissing(&dst.headerTags, src.headerTags)
+	mergeMissing(&dst.trailerTags, src.trailerTags)
+	mergeMissing(&dst.groupCounts, src.groupCounts)
+	mergeMissing(&dst.groupOwners, src.groupOwners)
+	mergeMissing(&dst.groupDefs, src.groupDefs)
+	mergeMissing(&dst.msgGroups, src.msgGroups)
+	mergeMissing(&dst.repeatable, src.repeatable)
+	mergeMissing(&dst.Messages, src.Messages)
+}
+
+// mergeMissing copies entries of src that dst lacks, allocating dst if needed.
+func mergeMissing[K comparable, V any](dst *map[K]V, src map[K]V) {
+	if len(src) == 0 {
+		return
+	}
+
+	if *dst == nil {
+		*dst = make(map[K]V, len(src))
+	}
+
+	for k, v := range src {
+		if _, exists := (*dst)[k]; !exists {
+			(*dst)[k] = v
+		}
+	}
 }
 
 var (
@@ -348,6 +560,66 @@ func getDictionary(key string) *FixTagLookup {
 	return parsed
 }
 
+// CustomDictionary is a QuickFIX dictionary or FIX Orchestra repository
+// that falls back to the embedded dictionary for each message's version
+// for anything it does not define. It is safe for concurrent use.
+type CustomDictionary struct {
+	xml   string
+	own   *FixTagLookup // the dictionary on its own, without fallback
+	mu    sync.RWMutex
+	dicts map[string]*FixTagLookup // schema-key → merged lookup
+}
+
+// NewCustomDictionary parses xmlData. An empty xmlData returns nil, which
+// Lookup treats as the embedded dictionaries.
+func NewCustomDictionary(xmlData string) (*CustomDictionary, error) {
+	if xmlData == "" {
+		return nil, nil
+	}
+
+	own, err := parseDictionary(xmlData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CustomDictionary{xml: xmlData, own: own, dicts: make(map[string]*FixTagLookup)}, nil
+}
+
+// Lookup returns the dictionary for msg: c merged with the embedded
+// dictionary for its version, or the embedded dictionary when c is nil.
+func (c *CustomDictionary) Lookup(msg string) *FixTagLookup {
+	if c == nil {
+		return loadDictionary(msg)
+	}
+
+	key := detectSchemaKey(msg)
+
+	c.mu.RLock()
+	d := c.dicts[key]
+	c.mu.RUnlock()
+
+	if d != nil {
+		return d
+	}
+
+	parsed, err := parseDictionary(c.xml)
+	if err != nil {
+		return loadDictionary(msg)
+	}
+
+	embedded := getDictionary(key)
+	if embedded == nil {
+		embedded = getDictionary("FIX44")
+	}
+	mergeLookups(parsed, embedded)
+
+	c.mu.Lock()
+	c.dicts[key] = parsed
+	c.mu.Unlock()
+
+	return parsed
+}
+
 /* ---------- PUBLIC API ---------- */
 
 func LoadDictionary(msg string) *FixTagLookup {
@@ -377,6 +649,59 @@ func (d *FixTagLookup) GetEnumDescription(tag int, val string) string {
 	return ""
 }
 
+// GetFieldTag resolves a field name to its tag number.
+func (d *FixTagLookup) GetFieldTag(name string) (int, bool) {
+	if tag, ok := d.nameToTag[name]; ok {
+		return tag, true
+	}
+
+	if tag := resolveTagByName(name, d.tagToName); tag != -1 {
+		return tag, true
+	}
+
+	return 0, false
+}
+
+// IsHeaderField reports whether tag belongs to the standard header.
+func (d *FixTagLookup) IsHeaderField(tag int) bool {
+	if len(d.headerTags) == 0 {
+		return defaultHeaderTags[tag]
+	}
+	return d.headerTags[tag]
+}
+
+// IsTrailerField reports whether tag belongs to the standard trailer.
+func (d *FixTagLookup) IsTrailerField(tag int) bool {
+	if len(d.trailerTags) == 0 {
+		return defaultTrailerTags[tag]
+	}
+	return d.trailerTags[tag]
+}
+
+// GetGroupDef returns the definition of the repeating group whose
+// NumInGroup field is countTag.
+func (d *FixTagLookup) GetGroupDef(countTag int) (GroupDef, bool) {
+	g, ok := d.groupDefs[countTag]
+	return g, ok
+}
+
+// GetMessageGroupDef is GetGroupDef for a group inside a message of
+// msgType. A NumInGroup field may be declared inline with different
+// members in different messages, such as NoRelatedSym in a FIX 4.2
+// MarketDataRequest and QuoteRequest.
+func (d *FixTagLookup) GetMessageGroupDef(msgType string, countTag int) (GroupDef, bool) {
+	if g, ok := d.msgGroups[msgType][countTag]; ok {
+		return g, true
+	}
+	return d.GetGroupDef(countTag)
+}
+
+// Used when a lookup carries no header/trailer definition (e.g. hand-built).
+var (
+	defaultHeaderTags  = map[int]bool{8: true, 9: true, 35: true, 34: true, 43: true, 49: true, 50: true, 52: true, 56: true, 57: true, 97: true, 115: true, 122: true, 128: true, 1128: true, 1129: true}
+	defaultTrailerTags = map[int]bool{10: true, 89: true, 93: true}
+)
+
 func (d *FixTagLookup) GetFieldType(tag int) string {
 	return d.fieldTypes[tag]
 }
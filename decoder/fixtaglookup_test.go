@@ -252,8 +252,9 @@ func TestChooseEmbeddedXMLFIX50Parses(t *testing.T) {
 }
 
 func TestGetDictionaryWithT11Merge(t *testing.T) {
-	// Clear cache
+	// Clear cache, and again afterwards so later tests load the real FIXT11
 	dicts = make(map[string]*FixTagLookup)
+	t.Cleanup(func() { dicts = make(map[string]*FixTagLookup) })
 
 	// Manually preload FIXT11 without triggering getDictionary (no locking issue)
 	t11 := &FixTagLookup{
@@ -441,3 +442,40 @@ func TestExtractMessageFieldsRequiredFlagAppends(t *testing.T) {
 		t.Fatalf("required = %v, want %v", required, wantReq)
 	}
 }
+
+func TestParseDictionaryStructure(t *testing.T) {
+	d := LoadDictionary("8=FIX.4.4\x01")
+
+	if !d.IsHeaderField(49) || d.IsHeaderField(55) {
+		t.Error("expected SenderCompID in header and Symbol not")
+	}
+
+	if !d.IsTrailerField(10) {
+		t.Error("expected CheckSum in trailer")
+	}
+
+	def, ok := d.GetGroupDef(453)
+	if !ok || len(def.FieldOrder) == 0 || def.FieldOrder[0] != 448 {
+		t.Fatalf("expected NoPartyIDs group starting with PartyID, got %+v (%v)", def, ok)
+	}
+
+	if tag, ok := d.GetFieldTag("ClOrdID"); !ok || tag != 11 {
+		t.Errorf("GetFieldTag(ClOrdID) = %d, %v", tag, ok)
+	}
+}
+
+func TestFixTagLookupDefaultsWithoutStructure(t *testing.T) {
+	d := &FixTagLookup{tagToName: map[int]string{11: "ClOrdID"}}
+
+	if !d.IsHeaderField(35) || !d.IsTrailerField(10) {
+		t.Error("expected built-in header/trailer tags")
+	}
+
+	if tag, ok := d.GetFieldTag("ClOrdID"); !ok || tag != 11 {
+		t.Errorf("expected fallback name lookup, got %d, %v", tag, ok)
+	}
+
+	if _, ok := d.GetFieldTag("Missing"); ok {
+		t.Error("expected unknown name to fail")
+	}
+}
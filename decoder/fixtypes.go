@@ -0,0 +1,476 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MonthYear is a parsed MONTHYEAR value: YYYYMM, optionally followed by a
+// day (YYYYMMDD) or a week code (YYYYMMwN). Day and Week are 0 when absent.
+type MonthYear struct {
+	Year  int
+	Month time.Month
+	Day   int
+	Week  int
+}
+
+// ParseValue parses val as the FIX data type typ and returns it as a Go
+// value:
+//
+//	INT, LENGTH, SEQNUM, NUMINGROUP, DAYOFMONTH, TAGNUM       int
+//	FLOAT, QTY, PRICE, PRICEOFFSET, AMT, PERCENTAGE           *big.Rat
+//	BOOLEAN                                                   bool
+//	CHAR                                                      byte
+//	MULTIPLECHARVALUE                                         []byte
+//	MULTIPLEVALUESTRING, MULTIPLESTRINGVALUE                  []string
+//	UTCTIMESTAMP, TIME, TZTIMESTAMP                           time.Time
+//	UTCDATEONLY, UTCDATE, DATE, LOCALMKTDATE                  time.Time (midnight UTC)
+//	UTCTIMEONLY, LOCALMKTTIME, TZTIMEONLY                     time.Time (year 0)
+//	MONTHYEAR                                                 MonthYear
+//	everything else                                           string
+//
+// Types the decoder does not know about are returned as strings without
+// any checks.
+func ParseValue(val, typ string) (any, error) {
+	typ = strings.ToUpper(typ)
+
+	v, ok := parseValue(val, typ)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s value %q", typ, val)
+	}
+	return v, nil
+}
+
+// FormatDecimal writes r as a FIX decimal with as many places as it needs,
+// e.g. "10.5" or "100". Values without a finite decimal expansion are
+// rounded to their non-repeating digits.
+func FormatDecimal(r *big.Rat) string {
+	prec, _ := r.FloatPrec()
+	return r.FloatString(prec)
+}
+
+// FormatUTCTimestamp writes t as a FIX UTCTimestamp in UTC, with
+// milliseconds, microseconds or nanoseconds only when t has them.
+func FormatUTCTimestamp(t time.Time) string {
+	t = t.UTC()
+	switch ns := t.Nanosecond(); {
+	case ns == 0:
+		return t.Format("20060102-15:04:05")
+	case ns%1e6 == 0:
+		return t.Format("20060102-15:04:05.000")
+	case ns%1e3 == 0:
+		return t.Format("20060102-15:04:05.000000")
+	default:
+		return t.Format("20060102-15:04:05.000000000")
+	}
+}
+
+// IsValidType reports whether val is a well-formed value of FIX type typ.
+func IsValidType(val string, typ string) bool {
+	_, ok := parseValue(val, strings.ToUpper(typ))
+	return ok
+}
+
+func parseValue(val, typ string) (any, bool) {
+	switch typ {
+	case "INT":
+		return parseInt(val)
+	case "LENGTH", "NUMINGROUP":
+		return parseIntRange(val, 0, -1)
+	case "SEQNUM":
+		return parseIntRange(val, 1, -1)
+	case "TAGNUM":
+		if strings.HasPrefix(val, "0") {
+			return nil, false
+		}
+		return parseIntRange(val, 1, -1)
+	case "DAYOFMONTH":
+		return parseIntRange(val, 1, 31)
+	case "FLOAT", "QTY", "PRICE", "PRICEOFFSET", "AMT", "PERCENTAGE":
+		return parseDecimal(val)
+	case "BOOLEAN":
+		if val == "Y" || val == "N" {
+			return val == "Y", true
+		}
+		return nil, false
+	case "CHAR":
+		if len(val) == 1 && isCharValue(val[0]) {
+			return val[0], true
+		}
+		return nil, false
+	case "MULTIPLECHARVALUE":
+		return parseMultipleChar(val)
+	case "MULTIPLEVALUESTRING", "MULTIPLESTRINGVALUE":
+		return parseMultipleString(val)
+	case "UTCTIMESTAMP", "TIME":
+		return parseTimestamp(val, true, false)
+	case "TZTIMESTAMP":
+		return parseTimestamp(val, false, true)
+	case "UTCDATEONLY", "UTCDATE", "DATE", "LOCALMKTDATE":
+		return parseDateOnly(val)
+	case "UTCTIMEONLY", "LOCALMKTTIME":
+		return parseTimeOnly(val, true, false)
+	case "TZTIMEONLY":
+		return parseTimeOnly(val, false, true)
+	case "MONTHYEAR":
+		return parseMonthYear(val)
+	case "CURRENCY":
+		return val, isCode(val, 3, 3, isUpper)
+	case "COUNTRY":
+		return val, isCode(val, 2, 2, isUpper)
+	case "EXCHANGE":
+		// ISO 10383 MICs are four characters; FIX 4.2 and earlier used
+		// shorter codes such as "N" for NYSE, so accept both.
+		return val, isCode(val, 1, 4, isUpperOrDigit)
+	case "LANGUAGE":
+		return val, isCode(val, 2, 2, isLower)
+	case "XID", "XIDREF":
+		return val, val != "" && !strings.ContainsAny(val, " \t\r\n")
+	case "XMLDATA":
+		return val, isWellFormedXML(val)
+	default:
+		return val, true
+	}
+}
+
+// parseInt accepts an optional leading '-' followed by digits. Unlike
+// strconv.Atoi, a leading '+' is rejected.
+func parseInt(val string) (any, bool) {
+	if !isSignedDigits(val) {
+		return nil, false
+	}
+	n, err := strconv.Atoi(val)
+	return n, err == nil
+}
+
+// parseIntRange is parseInt limited to [lo, hi]; hi < 0 means unbounded.
+func parseIntRange(val string, lo, hi int) (any, bool) {
+	v, ok := parseInt(val)
+	if !ok {
+		return nil, false
+	}
+	n := v.(int)
+	if n < lo || (hi >= 0 && n > hi) {
+		return nil, false
+	}
+	return n, true
+}
+
+// parseDecimal accepts digits with an optional sign and decimal point.
+// Exponents ("1e5"), a leading '+' and fractions ("1/2") are not FIX.
+func parseDecimal(val string) (any, bool) {
+	digits := strings.TrimPrefix(val, "-")
+	whole, frac, _ := strings.Cut(digits, ".")
+	if (whole == "" && frac == "") || !isDigits(whole, true) || !isDigits(frac, true) {
+		return nil, false
+	}
+
+	r, ok := new(big.Rat).SetString(val)
+	if !ok {
+		return nil, false
+	}
+	return r, true
+}
+
+func parseMultipleChar(val string) (any, bool) {
+	parts := strings.Split(val, " ")
+	out := make([]byte, 0, len(parts))
+	for _, p := range parts {
+		if len(p) != 1 || !isCharValue(p[0]) {
+			return nil, false
+		}
+		out = append(out, p[0])
+	}
+	return out, true
+}
+
+func parseMultipleString(val string) (any, bool) {
+	parts := strings.Split(val, " ")
+	for _, p := range parts {
+		if p == "" {
+			return nil, false
+		}
+	}
+	return parts, true
+}
+
+// parseTimestamp handles YYYYMMDD-HH:MM[:SS[.fff]] followed, for the TZ
+// variant, by a zone designator.
+func parseTimestamp(val string, needSeconds, zoned bool) (any, bool) {
+	date, rest, ok := parseDate(val)
+	if !ok || !strings.HasPrefix(rest, "-") {
+		return nil, false
+	}
+	c, rest, ok := parseClock(rest[1:], needSeconds)
+	if !ok {
+		return nil, false
+	}
+
+	loc := time.UTC
+	if zoned {
+		if loc, ok = parseZone(rest); !ok {
+			return nil, false
+		}
+	} else if rest != "" {
+		return nil, false
+	}
+
+	return time.Date(date.Year(), date.Month(), date.Day(), c.hour, c.min, c.sec, c.nsec, loc), true
+}
+
+func parseDateOnly(val string) (any, bool) {
+	date, rest, ok := parseDate(val)
+	if !ok || rest != "" {
+		return nil, false
+	}
+	return date, true
+}
+
+// parseTimeOnly handles HH:MM[:SS[.fff]] with an optional trailing zone.
+// UTCTIMEONLY and LOCALMKTTIME always carry seconds.
+func parseTimeOnly(val string, needSeconds, zoned bool) (any, bool) {
+	c, rest, ok := parseClock(val, needSeconds)
+	if !ok {
+		return nil, false
+	}
+
+	loc := time.UTC
+	if zoned {
+		if loc, ok = parseZone(rest); !ok {
+			return nil, false
+		}
+	} else if rest != "" {
+		return nil, false
+	}
+
+	return time.Date(0, time.January, 1, c.hour, c.min, c.sec, c.nsec, loc), true
+}
+
+func parseMonthYear(val string) (any, bool) {
+	year, rest, ok := number(val, 4, 0, 9999)
+	if !ok {
+		return nil, false
+	}
+	month, rest, ok := number(rest, 2, 1, 12)
+	if !ok {
+		return nil, false
+	}
+
+	my := MonthYear{Year: year, Month: time.Month(month)}
+	switch {
+	case rest == "":
+		return my, true
+	case strings.HasPrefix(rest, "w") || strings.HasPrefix(rest, "-w"):
+		rest = strings.TrimPrefix(strings.TrimPrefix(rest, "-"), "w")
+		if my.Week, rest, ok = number(rest, 1, 1, 5); !ok || rest != "" {
+			return nil, false
+		}
+		return my, true
+	default:
+		if my.Day, rest, ok = number(rest, 2, 1, daysIn(year, time.Month(month))); !ok || rest != "" {
+			return nil, false
+		}
+		return my, true
+	}
+}
+
+type clock struct {
+	hour, min, sec, nsec int
+}
+
+// parseClock consumes HH:MM[:SS[.fff]] from the front of s. Fractions may
+// carry 3, 6, 9 or 12 digits (milli- to picoseconds); picoseconds are
+// truncated to the nanosecond.
+func parseClock(s string, needSeconds bool) (clock, string, bool) {
+	var c clock
+	var ok bool
+
+	if c.hour, s, ok = number(s, 2, 0, 23); !ok {
+		return c, s, false
+	}
+	if s, ok = consume(s, ':'); !ok {
+		return c, s, false
+	}
+	if c.min, s, ok = number(s, 2, 0, 59); !ok {
+		return c, s, false
+	}
+
+	if rest, found := consume(s, ':'); found {
+		if c.sec, s, ok = number(rest, 2, 0, 59); !ok {
+			return c, s, false
+		}
+	} else if needSeconds {
+		return c, s, false
+	} else {
+		return c, s, true
+	}
+
+	if rest, found := consume(s, '.'); found {
+		n := 0
+		for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		if n != 3 && n != 6 && n != 9 && n != 12 {
+			return c, s, false
+		}
+		frac := rest[:min(n, 9)] + strings.Repeat("0", 9-min(n, 9))
+		c.nsec, _ = strconv.Atoi(frac)
+		s = rest[n:]
+	}
+	return c, s, true
+}
+
+// parseDate consumes YYYYMMDD from the front of s.
+func parseDate(s string) (time.Time, string, bool) {
+	year, s, ok := number(s, 4, 0, 9999)
+	if !ok {
+		return time.Time{}, s, false
+	}
+	month, s, ok := number(s, 2, 1, 12)
+	if !ok {
+		return time.Time{}, s, false
+	}
+	day, s, ok := number(s, 2, 1, daysIn(year, time.Month(month)))
+	if !ok {
+		return time.Time{}, s, false
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), s, true
+}
+
+// parseZone parses the whole of s as Z or ±hh[:mm].
+func parseZone(s string) (*time.Location, bool) {
+	if s == "Z" {
+		return time.UTC, true
+	}
+	if s == "" || (s[0] != '+' && s[0] != '-') {
+		return nil, false
+	}
+
+	hours, rest, ok := number(s[1:], 2, 0, 14)
+	if !ok {
+		return nil, false
+	}
+	mins := 0
+	if rest != "" {
+		if rest, ok = consume(rest, ':'); !ok {
+			return nil, false
+		}
+		if mins, rest, ok = number(rest, 2, 0, 59); !ok || rest != "" {
+			return nil, false
+		}
+	}
+
+	offset := (hours*60 + mins) * 60
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone(s, offset), true
+}
+
+// number consumes exactly n digits from s and checks the value lies in
+// [lo, hi].
+func number(s string, n, lo, hi int) (int, string, bool) {
+	if len(s) < n || !isDigits(s[:n], false) {
+		return 0, s, false
+	}
+	v, _ := strconv.Atoi(s[:n])
+	if v < lo || v > hi {
+		return 0, s, false
+	}
+	return v, s[n:], true
+}
+
+func consume(s string, c byte) (string, bool) {
+	if s == "" || s[0] != c {
+		return s, false
+	}
+	return s[1:], true
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func isSignedDigits(s string) bool {
+	return isDigits(strings.TrimPrefix(s, "-"), false)
+}
+
+// isDigits reports whether s is all ASCII digits; empty is allowed only
+// when allowEmpty is set.
+func isDigits(s string, allowEmpty bool) bool {
+	if s == "" {
+		return allowEmpty
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isCharValue reports whether c is a printable, non-space ASCII character.
+func isCharValue(c byte) bool {
+	return c > ' ' && c < 0x7f
+}
+
+func isUpper(c byte) bool        { return c >= 'A' && c <= 'Z' }
+func isLower(c byte) bool        { return c >= 'a' && c <= 'z' }
+func isUpperOrDigit(c byte) bool { return isUpper(c) || (c >= '0' && c <= '9') }
+
+func isCode(s string, minLen, maxLen int, ok func(byte) bool) bool {
+	if len(s) < minLen || len(s) > maxLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !ok(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWellFormedXML(s string) bool {
+	d := xml.NewDecoder(strings.NewReader(s))
+	elements := 0
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return elements > 0
+		}
+		if err != nil {
+			return false
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			elements++
+		}
+	}
+}
@@ -0,0 +1,296 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"math/big"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checkType asserts which values are accepted as typ.
+func checkType(t *testing.T, typ string, valid, invalid []string) {
+	t.Helper()
+	for _, v := range valid {
+		if !IsValidType(v, typ) {
+			t.Errorf("%s: expected %q to be valid", typ, v)
+		}
+	}
+	for _, v := range invalid {
+		if IsValidType(v, typ) {
+			t.Errorf("%s: expected %q to be invalid", typ, v)
+		}
+	}
+}
+
+func parsed(t *testing.T, val, typ string) any {
+	t.Helper()
+	v, err := ParseValue(val, typ)
+	if err != nil {
+		t.Fatalf("ParseValue(%q, %s) failed: %v", val, typ, err)
+	}
+	return v
+}
+
+func TestParseValueInt(t *testing.T) {
+	checkType(t, "INT", []string{"0", "42", "-7", "007"}, []string{"", "+1", "1.0", "1e3", "abc", "-"})
+	if got := parsed(t, "-42", "INT"); got != -42 {
+		t.Errorf("got %v, want -42", got)
+	}
+}
+
+func TestParseValueLength(t *testing.T) {
+	checkType(t, "LENGTH", []string{"0", "128"}, []string{"-1", "", "1.5"})
+}
+
+func TestParseValueSeqNum(t *testing.T) {
+	checkType(t, "SEQNUM", []string{"1", "999999"}, []string{"0", "-1", "x"})
+}
+
+func TestParseValueNumInGroup(t *testing.T) {
+	checkType(t, "NUMINGROUP", []string{"0", "3"}, []string{"-1", "two"})
+}
+
+func TestParseValueTagNum(t *testing.T) {
+	checkType(t, "TAGNUM", []string{"1", "9999"}, []string{"0", "035", "-5"})
+}
+
+func TestParseValueDayOfMonth(t *testing.T) {
+	checkType(t, "DAYOFMONTH", []string{"1", "15", "31"}, []string{"0", "32", "-1"})
+}
+
+func TestParseValueDecimal(t *testing.T) {
+	valid := []string{"0", "1.5", "-0.25", "100.", ".5", "00023.23"}
+	invalid := []string{"", "1e5", "1E-3", "+1.5", "1/2", "1.2.3", "-", ".", "NaN", "Inf", "1,000"}
+	for _, typ := range []string{"FLOAT", "QTY", "PRICE", "PRICEOFFSET", "AMT", "PERCENTAGE"} {
+		checkType(t, typ, valid, invalid)
+	}
+
+	r := parsed(t, "1.2345", "PRICE").(*big.Rat)
+	if r.Cmp(big.NewRat(12345, 10000)) != 0 {
+		t.Errorf("got %s, want 1.2345", r.FloatString(4))
+	}
+}
+
+func TestFormatDecimal(t *testing.T) {
+	cases := map[string]*big.Rat{
+		"10.5":    big.NewRat(21, 2),
+		"100":     big.NewRat(100, 1),
+		"-0.0025": big.NewRat(-1, 400),
+		"0":       new(big.Rat),
+	}
+	for want, r := range cases {
+		if got := FormatDecimal(r); got != want {
+			t.Errorf("FormatDecimal(%v) = %q, want %q", r, got, want)
+		}
+		if back := parsed(t, FormatDecimal(r), "PRICE").(*big.Rat); back.Cmp(r) != 0 {
+			t.Errorf("FormatDecimal(%v) does not parse back", r)
+		}
+	}
+}
+
+func TestFormatUTCTimestamp(t *testing.T) {
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"20250101-12:00:00":           base,
+		"20250101-12:00:00.120":       base.Add(120 * time.Millisecond),
+		"20250101-12:00:00.000123":    base.Add(123 * time.Microsecond),
+		"20250101-12:00:00.000000001": base.Add(time.Nanosecond),
+		"20250101-11:00:00":           base.In(time.FixedZone("CET", 3600)).Add(-time.Hour),
+	}
+	for want, ts := range cases {
+		if got := FormatUTCTimestamp(ts); got != want {
+			t.Errorf("FormatUTCTimestamp(%v) = %q, want %q", ts, got, want)
+		}
+	}
+}
+
+func TestParseValueBoolean(t *testing.T) {
+	checkType(t, "BOOLEAN", []string{"Y", "N"}, []string{"y", "n", "YES", "1", ""})
+	if got := parsed(t, "Y", "BOOLEAN"); got != true {
+		t.Errorf("got %v, want true", got)
+	}
+}
+
+func TestParseValueChar(t *testing.T) {
+	checkType(t, "CHAR", []string{"1", "A", "z", "@"}, []string{"", "AB", " ", "\x01"})
+	if got := parsed(t, "A", "CHAR"); got != byte('A') {
+		t.Errorf("got %v, want 'A'", got)
+	}
+}
+
+func TestParseValueMultipleCharValue(t *testing.T) {
+	checkType(t, "MULTIPLECHARVALUE", []string{"A", "2 A F"}, []string{"", "AB C", "A  B", "A "})
+	if got := parsed(t, "2 A F", "MULTIPLECHARVALUE").([]byte); string(got) != "2AF" {
+		t.Errorf("got %q, want 2AF", got)
+	}
+}
+
+func TestParseValueMultipleValueString(t *testing.T) {
+	for _, typ := range []string{"MULTIPLEVALUESTRING", "MULTIPLESTRINGVALUE"} {
+		checkType(t, typ, []string{"AV", "AV AN A"}, []string{"", "AV  AN", " AV"})
+	}
+	got := parsed(t, "AV AN A", "MULTIPLEVALUESTRING").([]string)
+	if !slices.Equal(got, []string{"AV", "AN", "A"}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestParseValueUTCTimestamp(t *testing.T) {
+	valid := []string{
+		"20250101-12:00:00",
+		"20250101-12:00:00.123",
+		"20250101-12:00:00.123456",
+		"20250101-12:00:00.123456789",
+		"20250101-12:00:00.123456789012",
+		"20240229-23:59:59",
+	}
+	invalid := []string{
+		"", "20250101-12:00", "20250101-24:00:00", "20250101-12:60:00", "20250101-12:00:60",
+		"20250101-12:00:00.1", "20250101-12:00:00.1234", "20250101-12:00:00Z",
+		"20250230-12:00:00", "20251301-12:00:00", "2025-01-01T12:00:00",
+	}
+	checkType(t, "UTCTIMESTAMP", valid, invalid)
+	checkType(t, "TIME", valid, invalid)
+
+	got := parsed(t, "20250101-12:00:00.123456789012", "UTCTIMESTAMP").(time.Time)
+	want := time.Date(2025, 1, 1, 12, 0, 0, 123456789, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseValueTZTimestamp(t *testing.T) {
+	checkType(t, "TZTIMESTAMP",
+		[]string{"20060901-07:39Z", "20060901-02:39-05", "20060901-15:39+08", "20060901-13:09:00.123+05:30"},
+		[]string{"20060901-07:39", "20060901-07:39:00", "20060901-07:39+5", "20060901-07:39+05:60", "20060901-07:39Z+01"})
+
+	got := parsed(t, "20060901-02:39-05", "TZTIMESTAMP").(time.Time)
+	if want := time.Date(2006, 9, 1, 7, 39, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseValueDateOnly(t *testing.T) {
+	for _, typ := range []string{"UTCDATEONLY", "UTCDATE", "DATE", "LOCALMKTDATE"} {
+		checkType(t, typ, []string{"20250704", "20240229"}, []string{"", "2025074", "20250732", "20230229", "2025-07-04"})
+	}
+	got := parsed(t, "20250704", "LOCALMKTDATE").(time.Time)
+	if want := time.Date(2025, 7, 4, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseValueUTCTimeOnly(t *testing.T) {
+	checkType(t, "UTCTIMEONLY",
+		[]string{"15:04:05", "15:04:05.000", "15:04:05.000000"},
+		[]string{"", "15:04", "3:04", "25:00:00", "15:04:05Z", "15:04:05.12"})
+}
+
+func TestParseValueLocalMktTime(t *testing.T) {
+	checkType(t, "LOCALMKTTIME", []string{"09:30:00", "16:00:00.000"}, []string{"09:30", "9:30:00"})
+}
+
+func TestParseValueTZTimeOnly(t *testing.T) {
+	checkType(t, "TZTIMEONLY",
+		[]string{"07:39Z", "02:39-05", "15:39+08", "13:09+05:30", "07:39:30.500Z"},
+		[]string{"07:39", "07:39+", "07:39+0530", "07:39 Z"})
+
+	got := parsed(t, "13:09+05:30", "TZTIMEONLY").(time.Time)
+	if h, m := got.UTC().Hour(), got.UTC().Minute(); h != 7 || m != 39 {
+		t.Errorf("got %02d:%02d UTC, want 07:39", h, m)
+	}
+}
+
+func TestParseValueMonthYear(t *testing.T) {
+	checkType(t, "MONTHYEAR",
+		[]string{"202407", "20240709", "202407w2", "202407-w2"},
+		[]string{"", "2024", "202413", "20240231", "202407w6", "202407-1", "07-2024"})
+
+	got := parsed(t, "202407w2", "MONTHYEAR").(MonthYear)
+	if got != (MonthYear{Year: 2024, Month: time.July, Week: 2}) {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestParseValueCodes(t *testing.T) {
+	checkType(t, "CURRENCY", []string{"USD", "EUR"}, []string{"usd", "US", "USDX", "U1D"})
+	checkType(t, "COUNTRY", []string{"US", "GB"}, []string{"USA", "us", "U"})
+	checkType(t, "EXCHANGE", []string{"XNYS", "N", "1"}, []string{"xnys", "XNYSE", ""})
+	checkType(t, "LANGUAGE", []string{"en", "ja"}, []string{"EN", "eng", ""})
+}
+
+func TestParseValueXID(t *testing.T) {
+	for _, typ := range []string{"XID", "XIDREF"} {
+		checkType(t, typ, []string{"PTY1"}, []string{"", "PTY 1"})
+	}
+}
+
+func TestParseValueXMLData(t *testing.T) {
+	checkType(t, "XMLDATA",
+		[]string{"<a/>", `<FIXML v="5.0"><Order ID="1"/></FIXML>`},
+		[]string{"", "plain text", "<a>", "<a></b>"})
+}
+
+func TestParseValueStringAndUnknown(t *testing.T) {
+	checkType(t, "STRING", []string{"anything", ""}, nil)
+	checkType(t, "DATA", []string{"\x01binary"}, nil)
+	checkType(t, "CUSTOM", []string{"whatever"}, nil)
+}
+
+func TestParseValueError(t *testing.T) {
+	_, err := ParseValue("1e5", "price")
+	if err == nil || !strings.Contains(err.Error(), `invalid PRICE value "1e5"`) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateAllowsEndSeqNoZero(t *testing.T) {
+	d := fix44Lookup(t)
+	fields := []FieldValue{{Tag: 7, Value: "0"}, {Tag: 16, Value: "0"}}
+
+	errs := validateFieldEnumsAndTypes(fields, d)
+	if len(errs) != 1 || !strings.Contains(errs[0].Text, "tag 7") {
+		t.Errorf("expected only BeginSeqNo to be rejected, got %v", errs)
+	}
+}
+
+func TestValidateAllowsFIX40CharStrings(t *testing.T) {
+	useRealDecoding(t)
+
+	for _, version := range []string{"FIX.4.0", "FIX.4.1"} {
+		msg := strings.Replace(framedFIX44("35=0|49=CLIENT|56=BROKER|34=1|52=20240101-00:00:00|"), "FIX.4.4", version, 1)
+		msg = withChecksum(msg)
+		for _, f := range ValidateFindings(msg, LoadDictionary(msg), DecodeOptions{}) {
+			if f.Code == CodeInvalidType {
+				t.Errorf("%s: expected CHAR strings to be valid, got %q", version, f.Text)
+			}
+		}
+	}
+
+	if IsValidType("CLIENT", "CHAR") {
+		t.Error("expected CHAR to stay one character outside FIX 4.0 and 4.1")
+	}
+}
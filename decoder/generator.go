@@ -0,0 +1,602 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GenerateOptions controls GenerateMessages.
+type GenerateOptions struct {
+	Messages     []string  // message names or MsgTypes; empty for all application messages
+	Count        int       // number of messages to write
+	Seed         int64     // random seed; the same seed gives the same output
+	InvalidRate  float64   // fraction of messages given one defect, 0 to 1
+	OptionalRate float64   // chance of including each optional field, group or component
+	MaxGroup     int       // largest number of instances per repeating group
+	SenderCompID string    // defaults to SENDER
+	TargetCompID string    // defaults to TARGET
+	Start        time.Time // SendingTime of the first message
+	Delimiter    string    // field delimiter, SOH when empty
+	Rules        []Rule    // rules to satisfy on top of the dictionary's
+}
+
+// Defects injected by GenerateOptions.InvalidRate.
+const (
+	DefectBadChecksum     = "bad-checksum"
+	DefectMissingRequired = "missing-required"
+	DefectWrongEnum       = "wrong-enum"
+)
+
+var defects = []string{DefectBadChecksum, DefectMissingRequired, DefectWrongEnum}
+
+var (
+	generatedCurrencies = []string{"USD", "EUR", "GBP", "JPY", "CHF"}
+	generatedCountries  = []string{"US", "GB", "DE", "FR", "JP"}
+	generatedExchanges  = []string{"XNYS", "XNAS", "XLON", "XPAR", "XETR"}
+	generatedLanguages  = []string{"en", "de", "fr", "ja"}
+)
+
+const generatedChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// coreHeaderTags are always generated, in this order, at the start of the
+// header. ApplVerID is added for FIX 5.0 messages.
+var coreHeaderTags = []int{8, 9, 35, 1128, 49, 56, 34, 52}
+
+type generator struct {
+	schema   SchemaTree
+	opts     GenerateOptions
+	rng      *rand.Rand
+	messages []MessageNode
+	byTag    map[int]Field
+	dict     *FixTagLookup // the schema's fields, for evaluating rules
+	rules    []Rule
+	seq      int
+	clock    time.Time
+}
+
+// GenerateMessages writes opts.Count random messages of the selected types,
+// one per line, with BodyLength, CheckSum and MsgSeqNum set. Each injected
+// defect is reported to defectsOut with its line number.
+func GenerateMessages(out, defectsOut io.Writer, schema SchemaTree, opts GenerateOptions) error {
+	g, err := newGenerator(schema, opts)
+	if err != nil {
+		return err
+	}
+
+	for i := 1; i <= g.opts.Count; i++ {
+		msg, defect := g.next()
+		fmt.Fprintln(out, msg)
+
+		if defect != "" {
+			fmt.Fprintf(defectsOut, "message %d (MsgSeqNum %d): %s\n", i, g.seq, defect)
+		}
+	}
+
+	return nil
+}
+
+func newGenerator(schema SchemaTree, opts GenerateOptions) (*generator, error) {
+	if opts.MaxGroup <= 0 {
+		opts.MaxGroup = 3
+	}
+	if opts.SenderCompID == "" {
+		opts.SenderCompID = "SENDER"
+	}
+	if opts.TargetCompID == "" {
+		opts.TargetCompID = "TARGET"
+	}
+	if opts.Start.IsZero() {
+		opts.Start = time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	}
+	if opts.Delimiter == "" {
+		opts.Delimiter = "\x01"
+	}
+
+	g := &generator{schema: schema, opts: opts, rng: rand.New(rand.NewSource(opts.Seed)), clock: opts.Start.UTC()}
+
+	g.byTag = make(map[int]Field, len(schema.Fields))
+	for _, f := range schema.Fields {
+		g.byTag[f.Number] = f
+	}
+
+	g.dict = schemaLookup(schema)
+	g.rules = append(schemaRules(schema), opts.Rules...)
+
+	if len(opts.Messages) == 0 {
+		for _, m := range schema.Messages {
+			if m.MsgCat == "app" {
+				g.messages = append(g.messages, m)
+			}
+		}
+		if len(g.messages) == 0 { // session dictionaries such as FIXT.1.1
+			for _, m := range schema.Messages {
+				g.messages = append(g.messages, m)
+			}
+		}
+		sort.Slice(g.messages, func(i, j int) bool { return g.messages[i].MsgType < g.messages[j].MsgType })
+	}
+
+	for _, want := range opts.Messages {
+		m, ok := findMessage(schema, strings.TrimSpace(want))
+		if !ok {
+			return nil, fmt.Errorf("message not found: %s", want)
+		}
+		g.messages = append(g.messages, m)
+	}
+
+	if len(g.messages) == 0 {
+		return nil, fmt.Errorf("no messages to generate")
+	}
+
+	return g, nil
+}
+
+func findMessage(schema SchemaTree, id string) (MessageNode, bool) {
+	for _, m := range schema.Messages {
+		if m.MsgType == id || strings.EqualFold(m.Name, id) {
+			return m, true
+		}
+	}
+	return MessageNode{}, false
+}
+
+// next returns the next message and the defect injected into it, if any.
+func (g *generator) next() (string, string) {
+	msgNode := g.messages[g.rng.Intn(len(g.messages))]
+	sections := skeletonSections(g.schema, msgNode)
+
+	g.seq++
+	g.clock = g.clock.Add(time.Duration(1+g.rng.Intn(1000)) * time.Millisecond)
+
+	m := &FixMessage{
+		Header:  &FieldMap{Fields: g.header(msgNode, sections[0])},
+		Body:    &FieldMap{Fields: g.members(sections[1], g.opts.OptionalRate)},
+		Trailer: &FieldMap{Fields: g.members(sections[2], 0)},
+	}
+	g.applyRules(m, msgNode.MsgType, sections[1])
+
+	defect := ""
+	if g.opts.InvalidRate > 0 && g.rng.Float64() < g.opts.InvalidRate {
+		defect = defects[g.rng.Intn(len(defects))]
+		defect = g.injectDefect(m, sections[1], defect)
+	}
+
+	encoded := m.Encode()
+	if defect == DefectBadChecksum {
+		encoded = corruptChecksum(encoded)
+	}
+
+	return strings.ReplaceAll(encoded, "\x01", g.opts.Delimiter), defect
+}
+
+// header builds the core header fields followed by any other required
+// header fields of the dictionary.
+func (g *generator) header(msg MessageNode, nodes []skeletonNode) []MessageField {
+	values := map[int]string{
+		8:  beginString(g.schema),
+		9:  "0",
+		35: msg.MsgType,
+		49: g.opts.SenderCompID,
+		56: g.opts.TargetCompID,
+		34: strconv.Itoa(g.seq),
+		52: g.clock.Format("20060102-15:04:05.000"),
+	}
+
+	if v := applVerID(g.schema); v != "" {
+		values[1128] = v
+	}
+
+	fields := make([]MessageField, 0, len(coreHeaderTags))
+	for _, tag := range coreHeaderTags {
+		if _, ok := values[tag]; !ok {
+			continue
+		}
+		fields = append(fields, MessageField{FieldValue: FieldValue{Tag: tag, Value: values[tag]}})
+	}
+
+	var rest []skeletonNode
+	for _, n := range nodes {
+		if n.kind != skeletonField || !slices.Contains(coreHeaderTags, n.tag) {
+			rest = append(rest, n)
+		}
+	}
+
+	return append(fields, g.members(rest, 0)...)
+}
+
+// members generates nodes, including each optional member with
+// probability optionalRate.
+func (g *generator) members(nodes []skeletonNode, optionalRate float64) []MessageField {
+	var out []MessageField
+
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		if !n.required && g.rng.Float64() >= optionalRate {
+			continue
+		}
+
+		switch {
+		case n.kind == skeletonComponent:
+			out = append(out, g.members(n.children, optionalRate)...)
+		case slices.ContainsFunc(out, func(mf MessageField) bool { return mf.Tag == n.tag }):
+			// listed twice, e.g. OwnershipType in FIX 4.3 RegistrationInstructions
+		case n.kind == skeletonGroup:
+			out = append(out, g.group(n, optionalRate))
+		case isDataType(n.field.Type):
+			// only generated with its preceding length field
+		case strings.EqualFold(n.field.Type, "LENGTH") && i+1 < len(nodes) && isDataType(nodes[i+1].field.Type):
+			data := g.randomString(8 + g.rng.Intn(16))
+			if strings.EqualFold(nodes[i+1].field.Type, "XMLDATA") {
+				data = "<data>" + data + "</data>"
+			}
+			out = append(out,
+				MessageField{FieldValue: FieldValue{Tag: n.tag, Value: strconv.Itoa(len(data))}},
+				MessageField{FieldValue: FieldValue{Tag: nodes[i+1].tag, Value: data}})
+			i++
+		case strings.EqualFold(n.field.Type, "NUMINGROUP"):
+			out = append(out, MessageField{FieldValue: FieldValue{Tag: n.tag, Value: "0"}})
+		default:
+			out = append(out, MessageField{FieldValue: FieldValue{Tag: n.tag, Value: g.value(n.field)}})
+		}
+	}
+
+	return out
+}
+
+// group generates between one and MaxGroup instances of n. The first member
+// of each instance is always present since it delimits the instance.
+func (g *generator) group(n skeletonNode, optionalRate float64) MessageField {
+	count := 1 + g.rng.Intn(g.opts.MaxGroup)
+
+	// Some counts are enumerated, e.g. NoSides is 1 or 2.
+	var allowed []int
+	for _, v := range n.field.Values {
+		if c, err := strconv.Atoi(v.Enum); err == nil && c >= 1 {
+			allowed = append(allowed, c)
+		}
+	}
+	if len(allowed) > 0 {
+		count = allowed[g.rng.Intn(len(allowed))]
+	}
+
+	mf := MessageField{FieldValue: FieldValue{Tag: n.tag, Value: strconv.Itoa(count)}}
+	children := requireFirst(n.children)
+
+	for range count {
+		mf.Groups = append(mf.Groups, &FieldMap{Fields: g.members(children, optionalRate)})
+	}
+
+	return mf
+}
+
+// applyRules adds the body fields that conditional rules require and drops
+// those they forbid, so that clean messages pass validation. A few passes
+// cover rules triggered by the fields another rule added.
+func (g *generator) applyRules(m *FixMessage, msgType string, body []skeletonNode) {
+	order := make(map[int]int)
+	numberTags(body, order)
+
+	for range 3 {
+		fieldMap, _ := buildFieldMap(ParseFix(m.Encode()))
+		changed := false
+
+		for _, r := range g.rules {
+			tags, violated := r.violated(msgType, fieldMap, g.dict)
+			if !violated {
+				continue
+			}
+
+			if r.Presence == "forbidden" {
+				m.Body.Fields = slices.DeleteFunc(m.Body.Fields, func(f MessageField) bool { return slices.Contains(tags, f.Tag) })
+				changed = true
+				continue
+			}
+
+			if f, ok := g.byTag[tags[0]]; ok {
+				insertOrdered(m.Body, MessageField{FieldValue: FieldValue{Tag: f.Number, Value: g.value(f)}}, order)
+				changed = true
+			}
+		}
+
+		if !changed {
+			return
+		}
+	}
+}
+
+// numberTags numbers the tags of nodes in message order, with components
+// expanded and groups by their NumInGroup tag.
+func numberTags(nodes []skeletonNode, order map[int]int) {
+	for _, n := range nodes {
+		if n.kind == skeletonComponent {
+			numberTags(n.children, order)
+		} else if _, seen := order[n.tag]; !seen {
+			order[n.tag] = len(order)
+		}
+	}
+}
+
+// schemaLookup holds the field names, types and enums of schema, which is
+// all rules need to be checked against a message.
+func schemaLookup(schema SchemaTree) *FixTagLookup {
+	d := &FixTagLookup{
+		tagToName:  make(map[int]string, len(schema.Fields)),
+		nameToTag:  make(map[string]int, len(schema.Fields)),
+		enumMap:    make(map[int]map[string]string),
+		fieldTypes: make(map[int]string, len(schema.Fields)),
+	}
+
+	for _, f := range schema.Fields {
+		d.tagToName[f.Number] = f.Name
+		d.nameToTag[f.Name] = f.Number
+		d.fieldTypes[f.Number] = f.Type
+
+		if len(f.Values) > 0 {
+			d.enumMap[f.Number] = make(map[string]string, len(f.Values))
+			for _, v := range f.Values {
+				d.enumMap[f.Number][v.Enum] = v.Description
+			}
+		}
+	}
+
+	return d
+}
+
+// schemaRules returns the standard rule pack for the schema's version and
+// the presence rules on each message's fields, including its components.
+func schemaRules(schema SchemaTree) []Rule {
+	major, minor, _ := strings.Cut(schema.Version, ".")
+	rules := standardRules(major, minor)
+
+	var walk func(msgType string, fields []FieldNode, comps []ComponentNode, depth int)
+	walk = func(msgType string, fields []FieldNode, comps []ComponentNode, depth int) {
+		if depth > maxStructureDepth {
+			return
+		}
+		for _, f := range fields {
+			rules = append(rules, presenceRules(msgType, f.Ref)...)
+		}
+		for _, c := range comps {
+			walk(msgType, c.Fields, c.Components, depth+1)
+		}
+	}
+
+	names := make([]string, 0, len(schema.Messages))
+	for name := range schema.Messages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := schema.Messages[name]
+		walk(m.MsgType, m.Fields, m.Components, 0)
+	}
+
+	return rules
+}
+
+// insertOrdered adds mf before the first field that the message's field
+// order puts after it.
+func insertOrdered(fm *FieldMap, mf MessageField, order map[int]int) {
+	idx, ok := order[mf.Tag]
+	if ok {
+		for i, f := range fm.Fields {
+			if o, known := order[f.Tag]; known && o > idx {
+				fm.Fields = slices.Insert(fm.Fields, i, mf)
+				return
+			}
+		}
+	}
+	fm.Fields = append(fm.Fields, mf)
+}
+
+func requireFirst(nodes []skeletonNode) []skeletonNode {
+	if len(nodes) == 0 {
+		return nodes
+	}
+
+	out := append([]skeletonNode(nil), nodes...)
+	out[0].required = true
+	if out[0].kind == skeletonComponent {
+		out[0].children = requireFirst(out[0].children)
+	}
+
+	return out
+}
+
+func isDataType(typ string) bool {
+	return strings.EqualFold(typ, "DATA") || strings.EqualFold(typ, "XMLDATA")
+}
+
+// value returns a random enum value of f, or a random value of its type.
+func (g *generator) value(f Field) string {
+	if values := typedEnums(f); len(values) > 0 {
+		return values[g.rng.Intn(len(values))]
+	}
+
+	ts := g.clock.Add(time.Duration(g.rng.Intn(3600)) * time.Second)
+
+	switch strings.ToUpper(f.Type) {
+	case "INT":
+		return strconv.Itoa(g.rng.Intn(1000))
+	case "LENGTH":
+		return strconv.Itoa(g.rng.Intn(100))
+	case "SEQNUM", "TAGNUM":
+		return strconv.Itoa(1 + g.rng.Intn(100000))
+	case "DAYOFMONTH":
+		return strconv.Itoa(1 + g.rng.Intn(31))
+	case "QTY":
+		return strconv.Itoa(1 + g.rng.Intn(10000))
+	case "PRICE", "AMT":
+		return strconv.FormatFloat(1+g.rng.Float64()*999, 'f', 2, 64)
+	case "PRICEOFFSET":
+		return strconv.FormatFloat(g.rng.Float64()*10-5, 'f', 2, 64)
+	case "FLOAT":
+		return strconv.FormatFloat(g.rng.Float64()*1000, 'f', 4, 64)
+	case "PERCENTAGE":
+		return strconv.FormatFloat(g.rng.Float64(), 'f', 4, 64)
+	case "CHAR", "MULTIPLECHARVALUE":
+		return string(generatedChars[g.rng.Intn(26)])
+	case "BOOLEAN":
+		return [2]string{"Y", "N"}[g.rng.Intn(2)]
+	case "UTCTIMESTAMP", "TIME":
+		return ts.Format("20060102-15:04:05.000")
+	case "UTCTIMEONLY", "LOCALMKTTIME":
+		return ts.Format("15:04:05")
+	case "UTCDATEONLY", "UTCDATE", "DATE", "LOCALMKTDATE":
+		return ts.Format("20060102")
+	case "MONTHYEAR":
+		return ts.Format("200601")
+	case "TZTIMEONLY":
+		return ts.Format("15:04:05Z")
+	case "TZTIMESTAMP":
+		return ts.Format("20060102-15:04:05Z")
+	case "CURRENCY":
+		return generatedCurrencies[g.rng.Intn(len(generatedCurrencies))]
+	case "COUNTRY":
+		return generatedCountries[g.rng.Intn(len(generatedCountries))]
+	case "EXCHANGE":
+		return generatedExchanges[g.rng.Intn(len(generatedExchanges))]
+	case "LANGUAGE":
+		return generatedLanguages[g.rng.Intn(len(generatedLanguages))]
+	default:
+		return g.randomString(8)
+	}
+}
+
+// typedEnums returns the enum values of f that are valid for its type; some
+// dictionaries list multi-character enums for CHAR fields. When none are
+// valid all enum values are returned.
+func typedEnums(f Field) []string {
+	var all, typed []string
+	for _, v := range f.Values {
+		all = append(all, v.Enum)
+		if IsValidType(v.Enum, f.Type) {
+			typed = append(typed, v.Enum)
+		}
+	}
+
+	if len(typed) > 0 {
+		return typed
+	}
+	return all
+}
+
+func (g *generator) randomString(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = generatedChars[g.rng.Intn(len(generatedChars))]
+	}
+	return string(b)
+}
+
+// injectDefect applies defect to m, falling back to a bad checksum when the
+// message has nothing the defect could apply to. It returns the defect used.
+func (g *generator) injectDefect(m *FixMessage, body []skeletonNode, defect string) string {
+	switch defect {
+	case DefectMissingRequired:
+		var required []int
+		for _, n := range body {
+			if n.kind == skeletonField && n.required && !isDataType(n.field.Type) {
+				required = append(required, n.tag)
+			}
+		}
+
+		if len(required) > 0 {
+			tag := required[g.rng.Intn(len(required))]
+			m.Body.Fields = removeField(m.Body.Fields, tag)
+			return defect
+		}
+	case DefectWrongEnum:
+		var candidates []*MessageField
+		for _, fm := range m.sections() {
+			candidates = appendEnumFields(candidates, fm, g.byTag)
+		}
+
+		if len(candidates) > 0 {
+			f := candidates[g.rng.Intn(len(candidates))]
+			f.Value = invalidEnum(g.byTag[f.Tag])
+			return defect
+		}
+	}
+
+	return DefectBadChecksum
+}
+
+func removeField(fields []MessageField, tag int) []MessageField {
+	out := fields[:0]
+	for _, f := range fields {
+		if f.Tag != tag {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// appendEnumFields collects the enumerated fields of fm other than MsgType
+// and group counts.
+func appendEnumFields(out []*MessageField, fm *FieldMap, byTag map[int]Field) []*MessageField {
+	for i := range fm.Fields {
+		f := &fm.Fields[i]
+		for _, inst := range f.Groups {
+			out = appendEnumFields(out, inst, byTag)
+		}
+
+		if f.Tag != 35 && len(f.Groups) == 0 && len(byTag[f.Tag].Values) > 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// invalidEnum returns a value of the same length class that is not one of
+// f's enum values.
+func invalidEnum(f Field) string {
+	valid := make(map[string]bool, len(f.Values))
+	for _, v := range f.Values {
+		valid[v.Enum] = true
+	}
+
+	for _, c := range generatedChars {
+		if !valid[string(c)] {
+			return string(c)
+		}
+	}
+
+	return "INVALID"
+}
+
+// corruptChecksum changes the CheckSum of an encoded message.
+func corruptChecksum(msg string) string {
+	i := strings.LastIndex(msg, "\x0110=")
+	sum, _ := strconv.Atoi(msg[i+4 : i+7])
+	return fmt.Sprintf("%s\x0110=%03d\x01", msg[:i], (sum+1)%256)
+}
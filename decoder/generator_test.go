@@ -0,0 +1,170 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+func generate(t *testing.T, opts GenerateOptions) ([]string, string) {
+	t.Helper()
+
+	var out, defects bytes.Buffer
+	if err := GenerateMessages(&out, &defects, embeddedSchema(t, "44"), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"), defects.String()
+}
+
+func TestGenerateMessagesAreValid(t *testing.T) {
+	useRealDecoding(t)
+
+	msgs, defects := generate(t, GenerateOptions{Count: 300, Seed: 7, OptionalRate: 0.3})
+	if len(msgs) != 300 || defects != "" {
+		t.Fatalf("expected 300 clean messages, got %d (defects %q)", len(msgs), defects)
+	}
+
+	types := map[string]bool{}
+	for i, msg := range msgs {
+		m, err := ParseMessage(msg)
+		if err != nil {
+			t.Fatalf("message %d does not parse: %v", i+1, err)
+		}
+		types[m.MsgType()] = true
+
+		if seq, _ := m.Header.GetInt(34); seq != i+1 {
+			t.Errorf("message %d: expected MsgSeqNum %d, got %d", i+1, i+1, seq)
+		}
+
+		if errs := ValidateFixMessage(msg, LoadDictionary(msg)); len(errs) != 0 {
+			t.Errorf("message %d invalid: %v\n%s", i+1, errs, visibleSOH(msg))
+		}
+	}
+
+	if len(types) < 20 {
+		t.Errorf("expected a spread of message types, got %d", len(types))
+	}
+}
+
+func TestGenerateMessagesAreValidForEveryVersion(t *testing.T) {
+	useRealDecoding(t)
+
+	for _, version := range strings.Split(fix.SupportedFixVersions(), ",") {
+		var out, defects bytes.Buffer
+		if err := GenerateMessages(&out, &defects, embeddedSchema(t, version), GenerateOptions{Count: 100, Seed: 11, OptionalRate: 0.5}); err != nil {
+			t.Fatalf("FIX %s: %v", version, err)
+		}
+
+		for i, msg := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+			if errs := ValidateFixMessage(msg, LoadDictionary(msg)); len(errs) != 0 {
+				t.Errorf("FIX %s message %d invalid: %v\n%s", version, i+1, errs, visibleSOH(msg))
+				break
+			}
+		}
+	}
+}
+
+func TestGenerateMessagesSelectedTypesAndGroups(t *testing.T) {
+	useRealDecoding(t)
+
+	msgs, _ := generate(t, GenerateOptions{Messages: []string{"D", "ExecutionReport"}, Count: 50, Seed: 1, OptionalRate: 1, MaxGroup: 4, Delimiter: "|"})
+
+	counts := map[int]bool{}
+	for _, msg := range msgs {
+		if !strings.Contains(msg, "|35=D|") && !strings.Contains(msg, "|35=8|") {
+			t.Fatalf("unexpected message type in %q", msg)
+		}
+
+		m, err := ParseMessage(NormaliseDelimiters(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, err := m.Body.GetInt(453); err == nil {
+			counts[n] = true
+			if len(m.Body.GetGroup(453)) != n {
+				t.Errorf("NoPartyIDs=%d but %d instances", n, len(m.Body.GetGroup(453)))
+			}
+		}
+	}
+
+	if len(counts) < 2 {
+		t.Errorf("expected random group counts, got %v", counts)
+	}
+}
+
+func TestGenerateMessagesInvalidAndReproducible(t *testing.T) {
+	useRealDecoding(t)
+
+	opts := GenerateOptions{Messages: []string{"D"}, Count: 60, Seed: 42, InvalidRate: 1}
+	msgs, defects := generate(t, opts)
+
+	for _, d := range []string{DefectBadChecksum, DefectMissingRequired, DefectWrongEnum} {
+		if !strings.Contains(defects, d) {
+			t.Errorf("expected %s defects, got:\n%s", d, defects)
+		}
+	}
+	if strings.Count(defects, "\n") != 60 {
+		t.Errorf("expected every message to have a defect, got:\n%s", defects)
+	}
+
+	for i, msg := range msgs {
+		if errs := ValidateFixMessage(msg, LoadDictionary(msg)); len(errs) == 0 {
+			t.Errorf("message %d: expected validation errors", i+1)
+		}
+	}
+
+	again, againDefects := generate(t, opts)
+	if strings.Join(again, "\n") != strings.Join(msgs, "\n") || againDefects != defects {
+		t.Error("expected the same seed to reproduce the same messages")
+	}
+}
+
+func TestGenerateMessagesUnknownType(t *testing.T) {
+	var out bytes.Buffer
+	if err := GenerateMessages(&out, &out, embeddedSchema(t, "44"), GenerateOptions{Messages: []string{"Nope"}, Count: 1}); err == nil {
+		t.Error("expected unknown message error")
+	}
+}
+
+func TestGenerateMessagesFIX50(t *testing.T) {
+	useRealDecoding(t)
+
+	var out bytes.Buffer
+	if err := GenerateMessages(&out, &out, embeddedSchema(t, "50SP1"), GenerateOptions{Count: 50, Seed: 3, OptionalRate: 0.3}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.Contains(msg, "\x011128=8\x01") {
+			t.Fatalf("expected ApplVerID for FIX.5.0SP1 in %q", visibleSOH(msg))
+		}
+		if errs := ValidateFixMessage(msg, LoadDictionary(msg)); len(errs) != 0 {
+			t.Errorf("invalid message %v\n%s", errs, visibleSOH(msg))
+		}
+	}
+}
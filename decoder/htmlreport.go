@@ -0,0 +1,228 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+type htmlFile struct {
+	ID       int
+	Name     string
+	Index    []htmlIndexEntry
+	Messages int
+	Invalid  int
+}
+
+type htmlLine struct {
+	No       int
+	Segments []htmlSegment
+	Messages []htmlMessage
+}
+
+type htmlSegment struct {
+	Text  string
+	IsFix bool
+}
+
+type htmlMessage struct {
+	Anchor string
+	DecodedMessage
+}
+
+type htmlIndexEntry struct {
+	MsgType string
+	MsgName string
+	Anchors []string
+}
+
+// htmlWriter executes the report's templates in turn, remembering the
+// first write error and skipping everything after it.
+type htmlWriter struct {
+	out io.Writer
+	err error
+}
+
+func (w *htmlWriter) execute(name string, data any) {
+	if w.err == nil {
+		w.err = htmlReportTemplate.ExecuteTemplate(w.out, name, data)
+	}
+}
+
+// WriteHTMLReport decodes each path (stdin for "-") and writes a single
+// self-contained HTML report to out: the original lines, an expandable
+// field table per message and a per-file index by MsgType. Lines are
+// written as they are decoded; each file's summary and index follow its
+// lines and are moved above them by the stylesheet.
+func WriteHTMLReport(paths []string, out io.Writer, errOut io.Writer, opts LogOptions) int {
+	w := &htmlWriter{out: out}
+	w.execute("head", "fixdecoder report")
+
+	files := 0
+	ok := forEachInput(paths, errOut, func(name string, r io.Reader) error {
+		files++
+		return writeHTMLFile(w, files-1, name, r, opts, errOut)
+	})
+
+	w.execute("foot", nil)
+
+	if w.err != nil {
+		fmt.Fprintln(errOut, "Error writing HTML report:"+w.err.Error())
+		return 1
+	}
+
+	if !ok {
+		return 1
+	}
+
+	return 0
+}
+
+func writeHTMLFile(w *htmlWriter, id int, name string, r io.Reader, opts LogOptions, errOut io.Writer) error {
+	file := htmlFile{ID: id, Name: name}
+	index := make(map[string]*htmlIndexEntry)
+	w.execute("fileStart", file)
+
+	scanner := newLineScanner(r)
+	for lineNo := 1; w.err == nil && scanner.Scan(); lineNo++ {
+		dl := DecodeLogLine(scanner.Text(), opts, errOut)
+		opts.Report.AddLine(name, scanner.Offset(), dl)
+		line := htmlLine{No: lineNo, Segments: htmlSegments(dl)}
+
+		for _, dm := range dl.Messages {
+			anchor := fmt.Sprintf("f%d-m%d", id, file.Messages)
+			file.Messages++
+
+			if len(dm.Errors) > 0 {
+				file.Invalid++
+			}
+
+			entry, found := index[dm.MsgType]
+			if !found {
+				entry = &htmlIndexEntry{MsgType: dm.MsgType, MsgName: dm.MsgName}
+				index[dm.MsgType] = entry
+			}
+			entry.Anchors = append(entry.Anchors, anchor)
+
+			line.Messages = append(line.Messages, htmlMessage{Anchor: anchor, DecodedMessage: dm})
+		}
+
+		w.execute("line", line)
+	}
+
+	for _, entry := range index {
+		file.Index = append(file.Index, *entry)
+	}
+
+	sort.Slice(file.Index, func(i, j int) bool { return file.Index[i].MsgType < file.Index[j].MsgType })
+	w.execute("fileEnd", file)
+
+	return scanner.Err()
+}
+
+func htmlSegments(dl DecodedLine) []htmlSegment {
+	var (
+		segments  []htmlSegment
+		lastIndex int
+	)
+
+	for _, span := range dl.Spans {
+		if span[0] > lastIndex {
+			segments = append(segments, htmlSegment{Text: dl.Text[lastIndex:span[0]]})
+		}
+		segments = append(segments, htmlSegment{Text: dl.Text[span[0]:span[1]], IsFix: true})
+		lastIndex = span[1]
+	}
+
+	if lastIndex < len(dl.Text) || len(segments) == 0 {
+		segments = append(segments, htmlSegment{Text: dl.Text[lastIndex:]})
+	}
+
+	return segments
+}
+
+// visibleSOH shows SOH delimiters as '|' so raw messages are readable.
+func visibleSOH(s string) string {
+	return strings.ReplaceAll(s, "\x01", "|")
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"soh": visibleSOH,
+	"inc": func(i int) int { return i + 1 },
+}).Parse(`{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 1.5em; color: #222; }
+h1 { font-size: 1.4em; } h2 { font-size: 1.2em; border-bottom: 1px solid #ccc; }
+section { display: flex; flex-direction: column; } section > h2 { order: -2; } .summary { order: -1; }
+.index { margin: 0.5em 0 1em; } .index li { margin: 0.2em 0; }
+.index a { margin-right: 0.4em; font-family: monospace; }
+.line { font-family: monospace; white-space: pre-wrap; word-break: break-all; margin: 0.2em 0; }
+.line .no { color: #999; display: inline-block; min-width: 4em; }
+.line .text { color: #777; } .line .fix { color: #000; font-weight: bold; }
+details { margin: 0.2em 0 0.6em 4em; } summary { cursor: pointer; font-family: monospace; }
+details.invalid > summary { color: #b00; }
+table.fields { border-collapse: collapse; font-family: monospace; margin: 0.3em 0; }
+table.fields td { border: 1px solid #ddd; padding: 0.1em 0.5em; vertical-align: top; }
+td.tag { text-align: right; color: #06c; } td.name { color: #070; } td.value { color: #850; } td.enum { color: #a50; }
+.group { border-left: 3px solid #9cf; margin: 0.3em 0; padding-left: 0.5em; }
+.instance { color: #06c; font-size: 0.85em; }
+ul.errors { color: #b00; font-family: monospace; margin: 0.3em 0; }
+</style>
+</head>
+<body>
+<h1>{{.}}</h1>
+{{end}}
+{{define "fileStart"}}<section id="f{{.ID}}">
+<h2>{{.Name}}</h2>
+{{end}}
+{{define "line"}}<div class="line"><span class="no">{{.No}}</span>{{range .Segments}}{{if .IsFix}}<span class="fix">{{soh .Text}}</span>{{else}}<span class="text">{{.Text}}</span>{{end}}{{end}}</div>
+{{range .Messages}}<details id="{{.Anchor}}"{{if .Errors}} class="invalid"{{end}}>
+<summary>{{.MsgType}} {{.MsgName}}{{if .Errors}} — {{len .Errors}} validation error(s){{end}}</summary>
+{{if .Errors}}<ul class="errors">{{range .Errors}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{template "fields" .Fields}}
+</details>
+{{end}}{{end}}
+{{define "fileEnd"}}<div class="summary">
+<p>{{.Messages}} message(s), {{.Invalid}} with validation errors.</p>
+{{if .Index}}<ul class="index">
+{{range .Index}}<li><strong>{{.MsgType}}</strong> {{.MsgName}} ({{len .Anchors}}): {{range $i, $a := .Anchors}}<a href="#{{$a}}">{{inc $i}}</a>{{end}}</li>
+{{end}}</ul>{{end}}
+</div>
+</section>
+{{end}}
+{{define "foot"}}</body>
+</html>
+{{end}}
+{{define "fields"}}<table class="fields">{{range .}}
+<tr><td class="tag">{{.Tag}}</td><td class="name">{{.Name}}</td><td class="value">{{.Value}}</td><td class="enum">{{.Description}}</td></tr>{{if .Groups}}
+<tr><td></td><td colspan="3">{{range $i, $g := .Groups}}<div class="group"><div class="instance">#{{inc $i}}</div>{{template "fields" $g}}</div>{{end}}</td></tr>{{end}}{{end}}
+</table>{{end}}
+`))
@@ -0,0 +1,150 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+func TestWriteHTMLReport(t *testing.T) {
+	useRealDecoding(t)
+
+	path := filepath.Join(t.TempDir(), "session.log")
+	content := "starting <session>\n" +
+		"OUT " + newOrderWithParties + "\n" +
+		"IN 8=FIX.4.4|9=5|35=0|10=000|\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	code := WriteHTMLReport([]string{path}, &out, &errOut, LogOptions{DecodeOptions: DecodeOptions{Validate: true}, Obfuscator: fix.CreateObfuscator(nil, false)})
+	if code != 0 {
+		t.Fatalf("expected success, got %d: %s", code, errOut.String())
+	}
+
+	html := out.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"starting &lt;session&gt;",  // free text escaped
+		"8=FIX.4.4|9=0|35=D|",       // raw message with visible delimiters
+		`<a href="#f0-m0">1</a>`,    // index entry
+		"NewOrderSingle",            // MsgType name
+		`<td class="name">PartyID`,  // nested group field
+		`class="invalid"`,           // validation highlight
+		"Checksum mismatch",         // validation error text
+		`<td class="enum">BUY</td>`, // enum description
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("expected %q in report", want)
+		}
+	}
+
+	if strings.Contains(html, "\x01") {
+		t.Error("expected no raw SOH characters in report")
+	}
+}
+
+func TestWriteHTMLReportMissingFile(t *testing.T) {
+	var out, errOut bytes.Buffer
+	code := WriteHTMLReport([]string{"/path/does/not/exist"}, &out, &errOut, LogOptions{})
+
+	if code != 1 || !strings.Contains(errOut.String(), "Cannot open file") {
+		t.Errorf("expected open failure, got %d: %s", code, errOut.String())
+	}
+
+	if !strings.Contains(out.String(), "</html>") {
+		t.Error("expected a complete report even when an input fails")
+	}
+}
+
+func TestWriteHTMLReportStreamsLines(t *testing.T) {
+	useRealDecoding(t)
+
+	path := filepath.Join(t.TempDir(), "session.log")
+	if err := os.WriteFile(path, []byte("first\nIN 8=FIX.4.4|9=5|35=0|10=000|\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, errOut bytes.Buffer
+	if code := WriteHTMLReport([]string{path}, &out, &errOut, LogOptions{}); code != 0 {
+		t.Fatalf("expected success, got %d: %s", code, errOut.String())
+	}
+
+	html := out.String()
+	first, second := strings.Index(html, ">first<"), strings.Index(html, `id="f0-m0"`)
+	summary := strings.Index(html, `<div class="summary">`)
+	if first < 0 || second < first || summary < second || !strings.Contains(html[summary:], "1 message(s)") {
+		t.Errorf("expected the lines in order followed by the summary, got:\n%s", html)
+	}
+}
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n++; w.n > 1 {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestWriteHTMLReportWriteError(t *testing.T) {
+	useRealDecoding(t)
+
+	path := filepath.Join(t.TempDir(), "session.log")
+	if err := os.WriteFile(path, []byte("first\nsecond\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &failingWriter{}
+	var errOut bytes.Buffer
+	if code := WriteHTMLReport([]string{path}, w, &errOut, LogOptions{}); code != 1 {
+		t.Errorf("expected failure, got %d", code)
+	}
+	if !strings.Contains(errOut.String(), "Error writing HTML report:disk full") {
+		t.Errorf("unexpected errors %q", errOut.String())
+	}
+	if w.n != 2 {
+		t.Errorf("expected writing to stop at the first error, got %d writes", w.n)
+	}
+}
+
+func TestHTMLSegments(t *testing.T) {
+	dl := DecodedLine{Text: "a FIX b", Spans: [][]int{{2, 5}}}
+	got := htmlSegments(dl)
+
+	if len(got) != 3 || !got[1].IsFix || got[1].Text != "FIX" || got[2].Text != " b" {
+		t.Errorf("unexpected segments %+v", got)
+	}
+
+	if got := htmlSegments(DecodedLine{}); len(got) != 1 {
+		t.Errorf("expected a single empty segment for an empty line, got %+v", got)
+	}
+}
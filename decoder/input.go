@@ -0,0 +1,108 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// forEachInput opens every path in turn (stdin for "-" or when paths is
+// empty) and hands it to fn with a display name. Errors are reported to
+// errOut; it returns false if any input failed.
+func forEachInput(paths []string, errOut io.Writer, fn func(name string, r io.Reader) error) bool {
+	et := themeOf(errOut)
+	ok := true
+
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	for _, path := range paths {
+		name, r, opened := openInput(path, errOut)
+		if !opened {
+			ok = false
+			continue
+		}
+
+		if err := fn(name, r); err != nil {
+			what := "Error reading file:"
+			if path == "-" {
+				what = "Error reading input:"
+			}
+			fmt.Fprintln(errOut, et.Paint(et.Error, what+err.Error()))
+			ok = false
+		}
+
+		r.Close()
+	}
+
+	return ok
+}
+
+// openInput opens path, or stdin for "-", and returns its display name.
+// Closing stdin's reader leaves stdin open. A file that cannot be opened
+// is reported to errOut.
+func openInput(path string, errOut io.Writer) (string, io.ReadCloser, bool) {
+	if path == "-" {
+		return "(stdin)", io.NopCloser(os.Stdin), true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		et := themeOf(errOut)
+		fmt.Fprintln(errOut, et.Paint(et.Error, "Cannot open file:"+err.Error()))
+		return "", nil, false
+	}
+
+	return path, f, true
+}
+
+// lineScanner is a bufio.Scanner over lines that also tracks the byte
+// offset of the current line.
+type lineScanner struct {
+	*bufio.Scanner
+	start, next int64
+}
+
+// newLineScanner returns a scanner that copes with long log lines.
+func newLineScanner(r io.Reader) *lineScanner {
+	ls := &lineScanner{Scanner: bufio.NewScanner(r)}
+	ls.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	ls.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if token != nil {
+			ls.start = ls.next
+		}
+		ls.next += int64(advance)
+		return advance, token, err
+	})
+	return ls
+}
+
+// Offset returns the byte offset of the line last returned by Scan.
+func (ls *lineScanner) Offset() int64 {
+	return ls.start
+}
@@ -0,0 +1,398 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Latency clocks: which timestamps a request and its response are timed by.
+const (
+	ClockAuto    = "auto"    // log-line timestamps when both lines have one, else SendingTime
+	ClockSending = "sending" // SendingTime (52)
+	ClockLog     = "log"     // log-line timestamps
+)
+
+// latencyPair describes how a request is answered: by the first message of
+// one of the Responses types sent back with the same value of IDTag.
+type latencyPair struct {
+	IDTag     int
+	Responses []string
+}
+
+var latencyPairs = map[string]latencyPair{
+	"D": {IDTag: 11, Responses: []string{"8"}},             // NewOrderSingle → ExecutionReport
+	"F": {IDTag: 11, Responses: []string{"8", "9"}},        // OrderCancelRequest → ExecutionReport or OrderCancelReject
+	"G": {IDTag: 11, Responses: []string{"8", "9"}},        // OrderCancelReplaceRequest → ack or OrderCancelReject
+	"R": {IDTag: 131, Responses: []string{"S", "AG", "b"}}, // QuoteRequest → Quote, QuoteRequestReject or QuoteAcknowledgement
+	"V": {IDTag: 262, Responses: []string{"W", "Y"}},       // MarketDataRequest → snapshot or MarketDataRequestReject
+	"1": {IDTag: 112, Responses: []string{"0"}},            // TestRequest → Heartbeat
+}
+
+// latencyBuckets are the histogram upper bounds; the last bucket is open.
+var latencyBuckets = []time.Duration{
+	100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// Millis is a duration written to JSON as fractional milliseconds.
+type Millis time.Duration
+
+func (d Millis) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)), nil
+}
+
+func (d Millis) String() string {
+	return time.Duration(d).String()
+}
+
+// MsgRef points at one message of a log.
+type MsgRef struct {
+	File   string `json:"file"`
+	Index  int    `json:"index"`  // 1-based message number within File
+	Offset int64  `json:"offset"` // byte offset of the message within File
+}
+
+func (r MsgRef) String() string {
+	return fmt.Sprintf("%s message %d (offset %d)", r.File, r.Index, r.Offset)
+}
+
+// LatencySample is one request paired with its response.
+type LatencySample struct {
+	ID       string `json:"id"` // the value of the pairing tag, e.g. the ClOrdID
+	Latency  Millis `json:"latencyMs"`
+	Request  MsgRef `json:"request"`
+	Response MsgRef `json:"response"`
+}
+
+// LatencyBucket counts the samples up to Upper, or beyond the last bound
+// when Upper is zero.
+type LatencyBucket struct {
+	Upper Millis `json:"upperMs,omitempty"`
+	Count int    `json:"count"`
+}
+
+// LatencyStats summarises the latency of one request MsgType within one
+// session, named SenderCompID->TargetCompID of the requests. Outliers lie
+// beyond the upper Tukey fence, Q3 + 1.5 × IQR.
+type LatencyStats struct {
+	Session    string          `json:"session"`
+	MsgType    string          `json:"msgType"`
+	Count      int             `json:"count"`
+	Unanswered int             `json:"unanswered"`
+	Min        Millis          `json:"minMs"`
+	Mean       Millis          `json:"meanMs"`
+	P50        Millis          `json:"p50Ms"`
+	P90        Millis          `json:"p90Ms"`
+	P99        Millis          `json:"p99Ms"`
+	Max        Millis          `json:"maxMs"`
+	Fence      Millis          `json:"outlierFenceMs"`
+	Histogram  []LatencyBucket `json:"histogram,omitempty"`
+	Outliers   []LatencySample `json:"outliers,omitempty"`
+}
+
+type latencyKey struct{ session, msgType string }
+
+type pendingRequest struct {
+	key     latencyKey
+	id      string
+	ref     MsgRef
+	sent    time.Time
+	logged  time.Time
+	answers []string
+}
+
+// LatencyAnalyzer pairs requests with their responses. Add messages in log
+// order, then read Stats.
+type LatencyAnalyzer struct {
+	Clock string
+
+	pending map[string]*pendingRequest
+	samples map[latencyKey][]LatencySample
+	asked   map[latencyKey]int
+}
+
+// NewLatencyAnalyzer returns an analyzer timing pairs by clock.
+func NewLatencyAnalyzer(clock string) *LatencyAnalyzer {
+	return &LatencyAnalyzer{
+		Clock:   clock,
+		pending: make(map[string]*pendingRequest),
+		samples: make(map[latencyKey][]LatencySample),
+		asked:   make(map[latencyKey]int),
+	}
+}
+
+// Add records m, logged at logged (zero when the line has no timestamp).
+func (a *LatencyAnalyzer) Add(m *FixMessage, logged time.Time, ref MsgRef) {
+	sender, _ := m.Header.Get(49)
+	target, _ := m.Header.Get(56)
+	sent, _ := m.Header.GetUTCTimestamp(52)
+	msgType := m.MsgType()
+
+	if pair, ok := latencyPairs[msgType]; ok {
+		if id, ok := m.Get(pair.IDTag); ok {
+			key := latencyKey{session: sender + "->" + target, msgType: msgType}
+			a.asked[key]++
+			a.pending[pendingKey(target, sender, pair.IDTag, id)] = &pendingRequest{
+				key: key, id: id, ref: ref, sent: sent, logged: logged, answers: pair.Responses,
+			}
+		}
+	}
+
+	for _, tag := range responseTags(msgType) {
+		id, ok := m.Get(tag)
+		if !ok {
+			continue
+		}
+		pk := pendingKey(sender, target, tag, id)
+		req, ok := a.pending[pk]
+		if !ok || !slices.Contains(req.answers, msgType) {
+			continue
+		}
+		delete(a.pending, pk)
+
+		latency, ok := a.latency(req.sent, req.logged, sent, logged)
+		if !ok {
+			continue
+		}
+		a.samples[req.key] = append(a.samples[req.key], LatencySample{
+			ID: id, Latency: Millis(latency), Request: req.ref, Response: ref,
+		})
+	}
+}
+
+// latency times a pair by the analyzer's clock.
+func (a *LatencyAnalyzer) latency(reqSent, reqLogged, respSent, respLogged time.Time) (time.Duration, bool) {
+	logged := !reqLogged.IsZero() && !respLogged.IsZero()
+	sent := !reqSent.IsZero() && !respSent.IsZero()
+
+	switch {
+	case a.Clock != ClockSending && logged:
+		return respLogged.Sub(reqLogged), true
+	case a.Clock != ClockLog && sent:
+		return respSent.Sub(reqSent), true
+	default:
+		return 0, false
+	}
+}
+
+// pendingKey identifies a request by the CompIDs its response carries.
+func pendingKey(sender, target string, tag int, id string) string {
+	return sender + "\x00" + target + "\x00" + strconv.Itoa(tag) + "\x00" + id
+}
+
+// responseTags returns the pairing tags of the requests msgType answers.
+func responseTags(msgType string) []int {
+	var tags []int
+	for _, pair := range latencyPairs {
+		if slices.Contains(pair.Responses, msgType) && !slices.Contains(tags, pair.IDTag) {
+			tags = append(tags, pair.IDTag)
+		}
+	}
+	return tags
+}
+
+// Stats returns the latency of each session and request MsgType, sorted by
+// session then MsgType. Requests still waiting for a response count as
+// unanswered.
+func (a *LatencyAnalyzer) Stats() []LatencyStats {
+	unanswered := make(map[latencyKey]int)
+	for _, req := range a.pending {
+		unanswered[req.key]++
+	}
+
+	keys := make([]latencyKey, 0, len(a.asked))
+	for key := range a.asked {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(x, y latencyKey) int {
+		if c := strings.Compare(x.session, y.session); c != 0 {
+			return c
+		}
+		return strings.Compare(x.msgType, y.msgType)
+	})
+
+	stats := make([]LatencyStats, 0, len(keys))
+	for _, key := range keys {
+		stats = append(stats, latencyStats(key, a.samples[key], unanswered[key]))
+	}
+	return stats
+}
+
+func latencyStats(key latencyKey, samples []LatencySample, unanswered int) LatencyStats {
+	s := LatencyStats{Session: key.session, MsgType: key.msgType, Count: len(samples), Unanswered: unanswered}
+	if len(samples) == 0 {
+		return s
+	}
+
+	sorted := make([]time.Duration, len(samples))
+	var total time.Duration
+	for i, sample := range samples {
+		sorted[i] = time.Duration(sample.Latency)
+		total += sorted[i]
+	}
+	slices.Sort(sorted)
+
+	s.Min, s.Max = Millis(sorted[0]), Millis(sorted[len(sorted)-1])
+	s.Mean = Millis(total / time.Duration(len(sorted)))
+	s.P50, s.P90, s.P99 = Millis(percentile(sorted, 50)), Millis(percentile(sorted, 90)), Millis(percentile(sorted, 99))
+
+	q1, q3 := percentile(sorted, 25), percentile(sorted, 75)
+	fence := q3 + (q3-q1)*3/2
+	s.Fence = Millis(fence)
+
+	s.Histogram = make([]LatencyBucket, len(latencyBuckets)+1)
+	for i, upper := range latencyBuckets {
+		s.Histogram[i].Upper = Millis(upper)
+	}
+	for _, sample := range samples {
+		d := time.Duration(sample.Latency)
+		i, _ := slices.BinarySearch(latencyBuckets, d)
+		s.Histogram[i].Count++
+		if d > fence {
+			s.Outliers = append(s.Outliers, sample)
+		}
+	}
+
+	return s
+}
+
+// percentile is the nearest-rank p-th percentile of sorted.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+// AnalyzeLatency pairs the requests and responses in paths ("-" for stdin)
+// and returns their latency, decoded with opts.Dictionary. Log-line
+// timestamps are read with opts.Prefix, or "auto" when nil. The bool is
+// false when an input could not be read.
+func AnalyzeLatency(paths []string, clock string, opts DecodeOptions, errOut io.Writer) ([]LatencyStats, bool) {
+	a := NewLatencyAnalyzer(clock)
+	parser := prefixParserOrAuto(opts.Prefix)
+
+	ok := forEachInput(paths, errOut, func(name string, r io.Reader) error {
+		scanner := newLineScanner(r)
+		index, last := 0, 0
+
+		for scanner.Scan() {
+			line := scanner.Text()
+			last = 0
+			for _, span := range findFixMessageIndices(line) {
+				index++
+				lp, _ := parser.Parse(line[last:span[0]])
+				last = span[1]
+
+				msg := NormaliseDelimiters(line[span[0]:span[1]])
+				m, err := NewMessage(msg, opts.Dictionary.Lookup(msg))
+				if err != nil {
+					continue
+				}
+				a.Add(m, lp.Time, MsgRef{File: name, Index: index, Offset: scanner.Offset() + int64(span[0])})
+			}
+		}
+
+		return scanner.Err()
+	})
+
+	return a.Stats(), ok
+}
+
+// WriteLatency writes stats as text: a summary line, the percentiles, the
+// non-empty stretch of the histogram and the outliers of each group.
+func WriteLatency(w io.Writer, stats []LatencyStats) {
+	if len(stats) == 0 {
+		fmt.Fprintln(w, "No requests found")
+		return
+	}
+
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s %s (%s): %d answered, %d unanswered\n", s.Session, s.MsgType, requestName(s.MsgType), s.Count, s.Unanswered)
+		if s.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  min %v  mean %v  p50 %v  p90 %v  p99 %v  max %v\n", s.Min, s.Mean, s.P50, s.P90, s.P99, s.Max)
+		writeHistogram(w, s.Histogram)
+		if len(s.Outliers) > 0 {
+			fmt.Fprintf(w, "  outliers (> %v):\n", s.Fence)
+			for _, o := range s.Outliers {
+				fmt.Fprintf(w, "    %v %s, request %s\n", o.Latency, o.ID, o.Request)
+			}
+		}
+	}
+}
+
+func writeHistogram(w io.Writer, buckets []LatencyBucket) {
+	const width = 40
+
+	first, last, most := -1, -1, 0
+	for i, b := range buckets {
+		if b.Count > 0 {
+			if first < 0 {
+				first = i
+			}
+			last, most = i, max(most, b.Count)
+		}
+	}
+	if first < 0 {
+		return
+	}
+
+	for _, b := range buckets[first : last+1] {
+		label := "> " + latencyBuckets[len(latencyBuckets)-1].String()
+		if b.Upper != 0 {
+			label = "<= " + b.Upper.String()
+		}
+		bar := strings.Repeat("#", (b.Count*width+most-1)/most)
+		fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("  %-10s %6d %s", label, b.Count, bar), " "))
+	}
+}
+
+// requestName names a request MsgType after the FIX 4.4 dictionary.
+func requestName(msgType string) string {
+	if d := loadDictionary("8=FIX.4.4\x01"); d != nil {
+		if def, ok := d.Messages[msgType]; ok {
+			return def.Name
+		}
+	}
+	return msgType
+}
+
+// WriteLatencyJSON writes stats as an indented JSON array.
+func WriteLatencyJSON(w io.Writer, stats []LatencyStats) error {
+	if stats == nil {
+		stats = []LatencyStats{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
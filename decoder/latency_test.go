@@ -0,0 +1,143 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// latencyLog writes lines of "LOGTIME BODY" to a log, framing each body
+// as a FIX 4.4 message.
+func latencyLog(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	var sb strings.Builder
+	for _, line := range lines {
+		prefix, body, _ := strings.Cut(line, " 35=")
+		sb.WriteString(prefix + " " + strings.ReplaceAll(framedFIX44("35="+body), "\x01", "|") + "\n")
+	}
+
+	path := filepath.Join(t.TempDir(), "session.log")
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestAnalyzeLatency(t *testing.T) {
+	useRealDecoding(t)
+
+	log := latencyLog(t,
+		"2024-01-02 10:00:00.000 35=D|49=C|56=V|34=1|52=20240102-10:00:00.000|11=A|",
+		"2024-01-02 10:00:00.002 35=D|49=C|56=V|34=2|52=20240102-10:00:00.001|11=B|",
+		"2024-01-02 10:00:00.005 35=8|49=V|56=C|34=1|52=20240102-10:00:00.004|11=A|",
+		"2024-01-02 10:00:00.007 35=8|49=V|56=C|34=2|52=20240102-10:00:00.006|11=A|",
+		"2024-01-02 10:00:00.010 35=D|49=C|56=V|34=3|52=20240102-10:00:00.010|11=Z|",
+		"2024-01-02 10:00:00.012 35=1|49=C|56=V|34=4|52=20240102-10:00:00.012|112=T1|",
+		"2024-01-02 10:00:00.013 35=0|49=V|56=C|34=3|52=20240102-10:00:00.013|112=T1|",
+		"2024-01-02 10:00:01.002 35=8|49=V|56=C|34=4|52=20240102-10:00:01.000|11=B|",
+	)
+
+	stats, ok := AnalyzeLatency([]string{log}, ClockAuto, DecodeOptions{}, &bytes.Buffer{})
+	if !ok || len(stats) != 2 {
+		t.Fatalf("expected two groups, got %+v", stats)
+	}
+
+	orders := stats[1]
+	if orders.Session != "C->V" || orders.MsgType != "D" || orders.Count != 2 || orders.Unanswered != 1 {
+		t.Errorf("unexpected order stats %+v", orders)
+	}
+	if orders.Min != Millis(5*time.Millisecond) || orders.Max != Millis(time.Second) || orders.P50 != orders.Min {
+		t.Errorf("expected log-line latencies of 5ms and 1s, got %+v", orders)
+	}
+	if heartbeats := stats[0]; heartbeats.MsgType != "1" || heartbeats.Count != 1 || heartbeats.Min != Millis(time.Millisecond) {
+		t.Errorf("unexpected TestRequest stats %+v", heartbeats)
+	}
+
+	stats, _ = AnalyzeLatency([]string{log}, ClockSending, DecodeOptions{}, &bytes.Buffer{})
+	if stats[1].Min != Millis(4*time.Millisecond) || stats[1].Max != Millis(999*time.Millisecond) {
+		t.Errorf("expected SendingTime latencies of 4ms and 999ms, got %+v", stats[1])
+	}
+}
+
+func TestLatencyStatsOutliers(t *testing.T) {
+	var samples []LatencySample
+	for i := 1; i <= 20; i++ {
+		samples = append(samples, LatencySample{ID: "ok", Latency: Millis(time.Duration(i) * time.Millisecond)})
+	}
+	samples = append(samples, LatencySample{ID: "slow", Latency: Millis(2 * time.Second)})
+
+	s := latencyStats(latencyKey{"C->V", "D"}, samples, 0)
+	if len(s.Outliers) != 1 || s.Outliers[0].ID != "slow" {
+		t.Errorf("expected the slow request as the only outlier, got %+v", s.Outliers)
+	}
+	if s.P50 != Millis(11*time.Millisecond) || s.P99 != Millis(2*time.Second) {
+		t.Errorf("unexpected percentiles p50=%v p99=%v", s.P50, s.P99)
+	}
+	if got := s.Histogram[len(s.Histogram)-4].Count; got != 1 { // <= 2.5s
+		t.Errorf("expected the slow request in the 2.5s bucket, got %+v", s.Histogram)
+	}
+}
+
+func TestWriteLatency(t *testing.T) {
+	useRealDecoding(t)
+
+	samples := []LatencySample{
+		{ID: "A", Latency: Millis(3 * time.Millisecond)},
+		{ID: "B", Latency: Millis(4 * time.Millisecond)},
+	}
+	stats := []LatencyStats{latencyStats(latencyKey{"C->V", "D"}, samples, 1)}
+
+	var out bytes.Buffer
+	WriteLatency(&out, stats)
+	for _, want := range []string{
+		"C->V D (NewOrderSingle): 2 answered, 1 unanswered",
+		"min 3ms  mean 3.5ms  p50 3ms  p90 4ms  p99 4ms  max 4ms",
+		"<= 5ms          2 ########################################",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := WriteLatencyJSON(&out, stats); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || decoded[0]["p90Ms"] != 4.0 {
+		t.Errorf("unexpected JSON %s (%v)", out.String(), err)
+	}
+
+	out.Reset()
+	WriteLatency(&out, nil)
+	if out.String() != "No requests found\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,232 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// mergeBuffer is how many lines each reader may get ahead of the merge.
+const mergeBuffer = 256
+
+// mergeLine is one log line read by a merge source. The last item a
+// source sends may carry only a read error.
+type mergeLine struct {
+	source int
+	seq    int
+	text   string
+	offset int64
+	at     time.Time
+	err    error
+}
+
+type mergeSource struct {
+	name  string
+	lines chan mergeLine
+	mark  time.Time // latest time read so far
+	open  bool
+}
+
+// MergeFiles decodes paths ("-" for stdin) as one timeline: each input is
+// read concurrently and its lines are written in timestamp order, tagged
+// with their file. A line's time is its log-prefix time, read with
+// opts.Prefix (or auto), else the SendingTime (52) of its first message;
+// lines without one keep the time of the line before. Lines with no time
+// before them, such as every line of an input without times, are written
+// as soon as they are read.
+//
+// Inputs may be out of order by up to window. A line is written once
+// every input still open has read past its time plus window, so only
+// about a window's worth of lines is held in memory.
+func MergeFiles(paths []string, out, errOut io.Writer, opts LogOptions, window time.Duration) int {
+	t, et := themeOf(out), themeOf(errOut)
+	hadError := false
+
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var sources []*mergeSource
+	for _, path := range paths {
+		name, r, ok := openInput(path, errOut)
+		if !ok {
+			hadError = true
+			continue
+		}
+
+		src := &mergeSource{name: name, lines: make(chan mergeLine, mergeBuffer), open: true}
+		go readMergeSource(len(sources), r, opts.Prefix, src.lines)
+		sources = append(sources, src)
+	}
+
+	names := make([]string, len(sources))
+	for i, src := range sources {
+		names[i] = t.Paint(t.File, src.name)
+	}
+	fmt.Fprint(out, "Merging: ", strings.Join(names, ", "), "\n\n")
+
+	separator := t.Paint(t.Title, strings.Repeat("=", getTerminalWidth())) + "\n"
+	pending := &mergeHeap{}
+
+	emit := func(l mergeLine) {
+		src := sources[l.source]
+		fmt.Fprint(out, t.Paint(t.File, "["+src.name+"]"), " ")
+		dl := handleLogLine(l.text, out, errOut, separator, opts)
+		opts.Report.AddLine(src.name, l.offset, dl)
+	}
+
+	for {
+		for pending.Len() > 0 && mergeReady((*pending)[0], sources, window) {
+			emit(heap.Pop(pending).(mergeLine))
+		}
+
+		src := laggingSource(sources)
+		if src == nil {
+			break
+		}
+
+		l, ok := <-src.lines
+		switch {
+		case !ok:
+			src.open = false
+		case l.err != nil:
+			fmt.Fprintln(errOut, et.Paint(et.Error, "Error reading file:"+l.err.Error()))
+			hadError = true
+		case l.at.IsZero():
+			emit(l) // no time to order it by
+		default:
+			if l.at.After(src.mark) {
+				src.mark = l.at
+			}
+			heap.Push(pending, l)
+		}
+	}
+
+	for pending.Len() > 0 {
+		emit(heap.Pop(pending).(mergeLine))
+	}
+
+	if hadError {
+		return 1
+	}
+	return 0
+}
+
+// readMergeSource sends the lines of r, timed, and closes lines and r.
+func readMergeSource(source int, r io.ReadCloser, parser PrefixParser, lines chan<- mergeLine) {
+	defer close(lines)
+	defer r.Close()
+
+	scanner := newLineScanner(r)
+	var last time.Time
+
+	for seq := 0; scanner.Scan(); seq++ {
+		text := scanner.Text()
+		if at, ok := lineTime(text, parser); ok {
+			last = at
+		}
+		lines <- mergeLine{source: source, seq: seq, text: text, offset: scanner.Offset(), at: last}
+	}
+
+	if err := scanner.Err(); err != nil {
+		lines <- mergeLine{source: source, err: err}
+	}
+}
+
+// lineTime is the log-prefix time of the line's first message, else its
+// SendingTime.
+func lineTime(line string, parser PrefixParser) (time.Time, bool) {
+	spans := findFixMessageIndices(line)
+	if len(spans) == 0 {
+		return time.Time{}, false
+	}
+
+	if lp, ok := prefixParserOrAuto(parser).Parse(line[:spans[0][0]]); ok && !lp.Time.IsZero() {
+		return lp.Time, true
+	}
+
+	msg := NormaliseDelimiters(line[spans[0][0]:spans[0][1]])
+	for _, fv := range ParseFix(msg) {
+		if fv.Tag == 52 {
+			if t, ok := parseTimestamp(fv.Value, true, false); ok {
+				return t.(time.Time), true
+			}
+			break
+		}
+	}
+	return time.Time{}, false
+}
+
+// mergeReady reports whether no open source can still produce a line
+// earlier than l.
+func mergeReady(l mergeLine, sources []*mergeSource, window time.Duration) bool {
+	for _, src := range sources {
+		if src.open && src.mark.Before(l.at.Add(window)) {
+			return false
+		}
+	}
+	return true
+}
+
+// laggingSource is the open source that has read the least far, or nil
+// once all are done.
+func laggingSource(sources []*mergeSource) *mergeSource {
+	var lag *mergeSource
+	for _, src := range sources {
+		if src.open && (lag == nil || src.mark.Before(lag.mark)) {
+			lag = src
+		}
+	}
+	return lag
+}
+
+// mergeHeap orders lines by time, then input, then position in the input.
+type mergeHeap []mergeLine
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if !a.at.Equal(b.at) {
+		return a.at.Before(b.at)
+	}
+	if a.source != b.source {
+		return a.source < b.source
+	}
+	return a.seq < b.seq
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(mergeLine)) }
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	l := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return l
+}
@@ -0,0 +1,234 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mergeLog writes lines to name in a temp dir, framing each "35=..." tail
+// as a FIX 4.4 message.
+func mergeLog(t *testing.T, dir, name string, lines ...string) string {
+	t.Helper()
+
+	var sb strings.Builder
+	for _, line := range lines {
+		if prefix, body, ok := strings.Cut(line, "35="); ok {
+			line = prefix + strings.ReplaceAll(framedFIX44("35="+body), "\x01", "|")
+		}
+		sb.WriteString(line + "\n")
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// mergedOrder lists the "[dir/file.log] ... 112=ID" test request IDs in output
+// order, or the text of lines without one.
+func mergedOrder(out string) []string {
+	idPattern := regexp.MustCompile(`^\[[^\]]*?(\w+)\.log\] (?:.*112=(\w+)|(.*))`)
+
+	var order []string
+	for _, line := range strings.Split(out, "\n") {
+		if m := idPattern.FindStringSubmatch(line); m != nil {
+			order = append(order, m[1]+":"+m[2]+m[3])
+		}
+	}
+	return order
+}
+
+func TestMergeFiles(t *testing.T) {
+	useRealDecoding(t)
+	dir := t.TempDir()
+
+	oms := mergeLog(t, dir, "oms.log",
+		"2025-01-01 09:00:00.000 OUT 35=1|49=A|56=B|34=1|52=20250101-09:00:00|112=O1|",
+		"  stack trace belonging to O1",
+		"2025-01-01 09:00:03.000 OUT 35=1|49=A|56=B|34=2|52=20250101-09:00:03|112=O3|",
+		"2025-01-01 09:00:02.500 OUT 35=1|49=A|56=B|34=3|52=20250101-09:00:02|112=O2|",
+	)
+	// No log-line times: ordered by SendingTime.
+	gateway := mergeLog(t, dir, "gateway.log",
+		"IN 35=1|49=B|56=A|34=1|52=20250101-09:00:01.000|112=G1|",
+		"IN 35=1|49=B|56=A|34=2|52=20250101-09:00:04.000|112=G2|",
+	)
+
+	var out, errOut bytes.Buffer
+	code := MergeFiles([]string{oms, gateway}, WithTheme(&out, NoTheme), &errOut, LogOptions{}, time.Second)
+	if code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, errOut.String())
+	}
+
+	want := []string{"oms:O1", "oms:  stack trace belonging to O1", "gateway:G1", "oms:O2", "oms:O3", "gateway:G2"}
+	if got := mergedOrder(out.String()); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("merged order = %v, want %v", got, want)
+	}
+	if !strings.HasPrefix(out.String(), "Merging: "+oms+", "+gateway+"\n") {
+		t.Errorf("expected the merged inputs to be listed, got %q", out.String())
+	}
+
+	// Without a window, out-of-order lines stay where they are.
+	out.Reset()
+	MergeFiles([]string{oms, gateway}, WithTheme(&out, NoTheme), &errOut, LogOptions{}, 0)
+	if got := mergedOrder(out.String()); strings.Join(got, ",") != "oms:O1,oms:  stack trace belonging to O1,gateway:G1,oms:O3,oms:O2,gateway:G2" {
+		t.Errorf("unexpected order without a window: %v", got)
+	}
+}
+
+func TestMergeFilesReport(t *testing.T) {
+	r, opts := useReport(t, FailOn{"error": true})
+	dir := t.TempDir()
+
+	oms := mergeLog(t, dir, "oms.log",
+		"2025-01-01 09:00:00.000 OUT 35=1|49=A|56=B|34=1|52=20250101-09:00:00|112=O1|",
+		"2025-01-01 09:00:02.000 OUT 35=1|49=A|56=B|34=2|52=20250101-09:00:02|112=O2|",
+	)
+	gateway := mergeLog(t, dir, "gateway.log",
+		"2025-01-01 09:00:01.000 IN 35=1|49=B|56=A|34=1|52=20250101-09:00:01|112=G1|",
+		"2025-01-01 09:00:03.000 IN 35=1|49=B|56=A|34=2|52=20250101-09:00:03|112=G2|",
+	)
+
+	var errOut bytes.Buffer
+	if code := MergeFiles([]string{oms, gateway}, io.Discard, &errOut, opts, time.Second); code != 0 {
+		t.Fatalf("unexpected exit code %d: %s", code, errOut.String())
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, "junit"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var got junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+
+	if got.Tests != 4 || len(got.Suites) != 2 {
+		t.Fatalf("expected one suite per merged file, got %s", buf.String())
+	}
+	for i, name := range []string{oms, gateway} {
+		suite := got.Suites[i]
+		if suite.Name != name || len(suite.Cases) != 2 ||
+			!strings.HasPrefix(suite.Cases[0].Name, "message 1 ") || !strings.HasPrefix(suite.Cases[1].Name, "message 2 ") {
+			t.Errorf("unexpected suite %+v", suite)
+		}
+	}
+}
+
+func TestMergeFilesReportsMissingInput(t *testing.T) {
+	useRealDecoding(t)
+	dir := t.TempDir()
+	oms := mergeLog(t, dir, "oms.log", "2025-01-01 09:00:00.000 OUT 35=1|49=A|56=B|34=1|52=20250101-09:00:00|112=O1|")
+
+	var out, errOut bytes.Buffer
+	code := MergeFiles([]string{filepath.Join(dir, "missing.log"), oms}, WithTheme(&out, NoTheme), &errOut, LogOptions{}, time.Second)
+	if code != 1 || !strings.Contains(errOut.String(), "Cannot open file") {
+		t.Errorf("expected an open error, got code=%d err=%q", code, errOut.String())
+	}
+	if got := mergedOrder(out.String()); len(got) != 1 || got[0] != "oms:O1" {
+		t.Errorf("expected the readable input to be merged, got %v", got)
+	}
+}
+
+func TestMergeFilesHoldsOnlyTheWindow(t *testing.T) {
+	lines := make(chan mergeLine, 1)
+	sources := []*mergeSource{
+		{name: "a", open: true, mark: time.Date(2025, 1, 1, 9, 0, 5, 0, time.UTC)},
+		{name: "b", open: true, mark: time.Date(2025, 1, 1, 9, 0, 1, 0, time.UTC), lines: lines},
+	}
+
+	early := mergeLine{at: time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)}
+	if !mergeReady(early, sources, time.Second) {
+		t.Error("expected a line a window behind every input to be ready")
+	}
+	if late := (mergeLine{at: time.Date(2025, 1, 1, 9, 0, 0, 500, time.UTC)}); mergeReady(late, sources, time.Second) {
+		t.Error("expected a line within the window of an input to wait")
+	}
+	if laggingSource(sources) != sources[1] {
+		t.Error("expected the input furthest behind to be read next")
+	}
+
+	sources[1].open = false
+	if laggingSource(sources) != sources[0] {
+		t.Error("expected closed inputs to be skipped")
+	}
+}
+
+func TestMergeFilesWritesUndatedLinesAsRead(t *testing.T) {
+	useRealDecoding(t)
+	oms := mergeLog(t, t.TempDir(), "oms.log", "2025-01-01 09:00:00.000 OUT 35=1|49=A|56=B|34=1|52=20250101-09:00:00|112=O1|")
+
+	// An input without times that stays open.
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origStdin := os.Stdin
+	os.Stdin = stdinR
+	t.Cleanup(func() { os.Stdin = origStdin; stdinR.Close() })
+
+	outR, outW := io.Pipe()
+	done := make(chan int, 1)
+	go func() {
+		done <- MergeFiles([]string{oms, "-"}, WithTheme(outW, NoTheme), io.Discard, LogOptions{}, time.Second)
+		outW.Close()
+	}()
+
+	fmt.Fprintln(stdinW, "IN 35=1|49=B|56=A|34=1|112=N1|")
+
+	written := make(chan bool, 1)
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			if strings.Contains(scanner.Text(), "112=N1") {
+				written <- true
+				break
+			}
+		}
+		io.Copy(io.Discard, outR)
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the undated line to be written before its input ends")
+	}
+
+	stdinW.Close()
+	if code := <-done; code != 0 {
+		t.Errorf("unexpected exit code %d", code)
+	}
+}
@@ -0,0 +1,440 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrFieldNotFound is returned by the typed getters when a tag is absent.
+var ErrFieldNotFound = errors.New("field not found")
+
+// FixMessage is a decoded FIX message split into header, body and trailer.
+// Repeating groups are nested under their NumInGroup field.
+type FixMessage struct {
+	Header  *FieldMap
+	Body    *FieldMap
+	Trailer *FieldMap
+	dict    *FixTagLookup
+}
+
+// FieldMap is an ordered run of fields: a message section or one instance
+// of a repeating group.
+type FieldMap struct {
+	Fields []MessageField
+	dict   *FixTagLookup
+}
+
+// MessageField is a single tag=value pair. For a NumInGroup field, Groups
+// holds the parsed group instances that follow it on the wire.
+type MessageField struct {
+	FieldValue
+	Groups []*FieldMap
+}
+
+// ParseMessage decodes raw using the dictionary selected by its BeginString.
+func ParseMessage(raw string) (*FixMessage, error) {
+	raw = NormaliseDelimiters(raw)
+	return NewMessage(raw, loadDictionary(raw))
+}
+
+// NewMessage decodes raw against dict.
+func NewMessage(raw string, dict *FixTagLookup) (*FixMessage, error) {
+	if dict == nil {
+		return nil, errors.New("no dictionary supplied")
+	}
+
+	fields := parseFix(NormaliseDelimiters(raw))
+	if len(fields) == 0 {
+		return nil, errors.New("no FIX fields found")
+	}
+
+	return NewMessageFromFields(fields, dict), nil
+}
+
+// NewMessageFromFields builds a FixMessage from already parsed fields.
+func NewMessageFromFields(fields []FieldValue, dict *FixTagLookup) *FixMessage {
+	m := &FixMessage{
+		Header:  &FieldMap{dict: dict},
+		Body:    &FieldMap{dict: dict},
+		Trailer: &FieldMap{dict: dict},
+		dict:    dict,
+	}
+
+	p := &messageParser{fields: fields, dict: dict}
+
+	p.parseSection(m.Header, func(tag int) bool { return !dict.IsHeaderField(tag) })
+	p.msgType = m.MsgType()
+	p.parseSection(m.Body, dict.IsTrailerField)
+	p.parseSection(m.Trailer, func(int) bool { return false })
+
+	return m
+}
+
+// Dictionary returns the lookup the message was decoded with.
+func (m *FixMessage) Dictionary() *FixTagLookup {
+	return m.dict
+}
+
+// MsgType returns tag 35, or "" when absent.
+func (m *FixMessage) MsgType() string {
+	v, _ := m.Header.Get(35)
+	return v
+}
+
+// Get searches the header, body and trailer (top level only) for tag.
+func (m *FixMessage) Get(tag int) (string, bool) {
+	for _, fm := range m.sections() {
+		if v, ok := fm.Get(tag); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// GetByName is Get using the dictionary field name.
+func (m *FixMessage) GetByName(name string) (string, bool) {
+	tag, ok := m.dict.GetFieldTag(name)
+	if !ok {
+		return "", false
+	}
+	return m.Get(tag)
+}
+
+// Section returns the section holding tag at its top level, or nil.
+func (m *FixMessage) Section(tag int) *FieldMap {
+	for _, fm := range m.sections() {
+		if fm.Has(tag) {
+			return fm
+		}
+	}
+	return nil
+}
+
+// Fields returns every field in wire order, group instances flattened.
+func (m *FixMessage) Fields() []FieldValue {
+	var out []FieldValue
+	for _, fm := range m.sections() {
+		out = fm.appendFlat(out)
+	}
+	return out
+}
+
+// String serialises the message exactly as parsed, SOH-delimited.
+func (m *FixMessage) String() string {
+	var sb strings.Builder
+	for _, fv := range m.Fields() {
+		writeField(&sb, fv)
+	}
+	return sb.String()
+}
+
+// Encode serialises the message after recomputing BodyLength (9) and
+// CheckSum (10).
+func (m *FixMessage) Encode() string {
+	var body strings.Builder
+	for _, fv := range m.Fields() {
+		switch fv.Tag {
+		case 8, 9, 10:
+			continue
+		}
+		writeField(&body, fv)
+	}
+
+	begin, _ := m.Header.Get(8)
+
+	var sb strings.Builder
+	writeField(&sb, FieldValue{Tag: 8, Value: begin})
+	writeField(&sb, FieldValue{Tag: 9, Value: strconv.Itoa(body.Len())})
+	sb.WriteString(body.String())
+	writeField(&sb, FieldValue{Tag: 10, Value: fmt.Sprintf("%03d", checksumOf(sb.String()))})
+
+	return sb.String()
+}
+
+func (m *FixMessage) sections() []*FieldMap {
+	return []*FieldMap{m.Header, m.Body, m.Trailer}
+}
+
+// checksumOf is the FIX CheckSum of everything up to and including the SOH
+// preceding tag 10.
+func checksumOf(s string) int {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum += int(s[i])
+	}
+	return sum % 256
+}
+
+func writeField(sb *strings.Builder, fv FieldValue) {
+	sb.WriteString(strconv.Itoa(fv.Tag))
+	sb.WriteByte('=')
+	sb.WriteString(fv.Value)
+	sb.WriteByte('\x01')
+}
+
+// ---- FieldMap accessors ----
+
+// Has reports whether tag is present at the top level of the map.
+func (fm *FieldMap) Has(tag int) bool {
+	_, ok := fm.Get(tag)
+	return ok
+}
+
+// Get returns the first top-level value for tag.
+func (fm *FieldMap) Get(tag int) (string, bool) {
+	for _, f := range fm.Fields {
+		if f.Tag == tag {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// GetByName is Get using the dictionary field name.
+func (fm *FieldMap) GetByName(name string) (string, bool) {
+	if fm.dict == nil {
+		return "", false
+	}
+
+	tag, ok := fm.dict.GetFieldTag(name)
+	if !ok {
+		return "", false
+	}
+	return fm.Get(tag)
+}
+
+// Set replaces the first top-level value for tag, or appends the field.
+func (fm *FieldMap) Set(tag int, value string) {
+	for i := range fm.Fields {
+		if fm.Fields[i].Tag == tag {
+			fm.Fields[i].Value = value
+			return
+		}
+	}
+	fm.Fields = append(fm.Fields, MessageField{FieldValue: FieldValue{Tag: tag, Value: value}})
+}
+
+// GetString returns the value for tag or ErrFieldNotFound.
+func (fm *FieldMap) GetString(tag int) (string, error) {
+	v, ok := fm.Get(tag)
+	if !ok {
+		return "", fmt.Errorf("tag %d: %w", tag, ErrFieldNotFound)
+	}
+	return v, nil
+}
+
+// GetInt parses an INT-like field (INT, LENGTH, SEQNUM, NUMINGROUP, ...).
+func (fm *FieldMap) GetInt(tag int) (int, error) {
+	v, err := fm.GetString(tag)
+	if err != nil {
+		return 0, err
+	}
+
+	n, ok := parseInt(v)
+	if !ok {
+		return 0, fmt.Errorf("tag %d: invalid int %q", tag, v)
+	}
+	return n.(int), nil
+}
+
+// GetDecimal parses a decimal field (PRICE, QTY, AMT, ...) exactly.
+func (fm *FieldMap) GetDecimal(tag int) (*big.Rat, error) {
+	v, err := fm.GetString(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := parseDecimal(v)
+	if !ok {
+		return nil, fmt.Errorf("tag %d: invalid decimal %q", tag, v)
+	}
+	return r.(*big.Rat), nil
+}
+
+// GetBool parses a BOOLEAN field (Y/N).
+func (fm *FieldMap) GetBool(tag int) (bool, error) {
+	v, err := fm.GetString(tag)
+	if err != nil {
+		return false, err
+	}
+
+	switch v {
+	case "Y":
+		return true, nil
+	case "N":
+		return false, nil
+	default:
+		return false, fmt.Errorf("tag %d: invalid boolean %q", tag, v)
+	}
+}
+
+// GetChar parses a CHAR field.
+func (fm *FieldMap) GetChar(tag int) (byte, error) {
+	v, err := fm.GetString(tag)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(v) != 1 {
+		return 0, fmt.Errorf("tag %d: invalid char %q", tag, v)
+	}
+	return v[0], nil
+}
+
+// GetUTCTimestamp parses a UTCTIMESTAMP field with second to picosecond
+// precision; picoseconds are truncated to the nanosecond.
+func (fm *FieldMap) GetUTCTimestamp(tag int) (time.Time, error) {
+	v, err := fm.GetString(tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, ok := parseTimestamp(v, true, false)
+	if !ok {
+		return time.Time{}, fmt.Errorf("tag %d: invalid UTCTimestamp %q", tag, v)
+	}
+	return t.(time.Time), nil
+}
+
+// GetValue parses tag using its dictionary type; see ParseValue for the Go
+// type returned for each FIX type.
+func (fm *FieldMap) GetValue(tag int) (any, error) {
+	v, err := fm.GetString(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	var typ string
+	if fm.dict != nil {
+		typ = fm.dict.GetFieldType(tag)
+	}
+
+	val, err := ParseValue(v, typ)
+	if err != nil {
+		return nil, fmt.Errorf("tag %d: %w", tag, err)
+	}
+	return val, nil
+}
+
+// GetGroup returns the instances of the repeating group counted by countTag.
+func (fm *FieldMap) GetGroup(countTag int) []*FieldMap {
+	for _, f := range fm.Fields {
+		if f.Tag == countTag {
+			return f.Groups
+		}
+	}
+	return nil
+}
+
+// GetGroupByName is GetGroup using the NumInGroup field name.
+func (fm *FieldMap) GetGroupByName(name string) []*FieldMap {
+	if fm.dict == nil {
+		return nil
+	}
+
+	tag, ok := fm.dict.GetFieldTag(name)
+	if !ok {
+		return nil
+	}
+	return fm.GetGroup(tag)
+}
+
+func (fm *FieldMap) appendFlat(out []FieldValue) []FieldValue {
+	for _, f := range fm.Fields {
+		out = append(out, f.FieldValue)
+		for _, g := range f.Groups {
+			out = g.appendFlat(out)
+		}
+	}
+	return out
+}
+
+// ---- parsing ----
+
+type messageParser struct {
+	fields  []FieldValue
+	pos     int
+	dict    *FixTagLookup
+	msgType string // selects the message's own group layouts
+}
+
+// parseSection consumes fields into fm until stop reports true.
+func (p *messageParser) parseSection(fm *FieldMap, stop func(int) bool) {
+	for p.pos < len(p.fields) && !stop(p.fields[p.pos].Tag) {
+		fv := p.fields[p.pos]
+		p.pos++
+		p.addField(fm, fv)
+	}
+}
+
+func (p *messageParser) addField(fm *FieldMap, fv FieldValue) {
+	mf := MessageField{FieldValue: fv}
+
+	if def, ok := p.dict.GetMessageGroupDef(p.msgType, fv.Tag); ok && len(def.FieldOrder) > 0 {
+		if count, err := strconv.Atoi(fv.Value); err == nil && count > 0 {
+			mf.Groups = p.parseGroup(def, count)
+		}
+	}
+
+	fm.Fields = append(fm.Fields, mf)
+}
+
+// parseGroup reads up to count instances. Each instance starts with the
+// group's delimiter (first) field and continues while tags belong to it.
+func (p *messageParser) parseGroup(def GroupDef, count int) []*FieldMap {
+	delimiter := def.FieldOrder[0]
+	members := make(map[int]bool, len(def.FieldOrder))
+	for _, tag := range def.FieldOrder {
+		members[tag] = true
+	}
+
+	var (
+		instances []*FieldMap
+		current   *FieldMap
+	)
+
+	for p.pos < len(p.fields) {
+		fv := p.fields[p.pos]
+
+		if fv.Tag == delimiter {
+			if len(instances) == count {
+				break
+			}
+			current = &FieldMap{dict: p.dict}
+			instances = append(instances, current)
+		} else if current == nil || !members[fv.Tag] || current.Has(fv.Tag) {
+			break
+		}
+
+		p.pos++
+		p.addField(current, fv)
+	}
+
+	return instances
+}
@@ -0,0 +1,195 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const newOrderWithParties = "8=FIX.4.4\x019=0\x0135=D\x0149=BUY\x0156=SELL\x0134=2\x0152=20250101-12:00:00.123456\x01" +
+	"11=ORD1\x01453=2\x01448=P1\x01447=D\x01452=1\x01448=P2\x01447=D\x01452=3\x01" +
+	"55=EUR/USD\x0154=1\x0138=1000\x0140=2\x0144=1.2345\x0160=20250101-12:00:00\x01" +
+	"10=000\x01"
+
+func TestNewMessageSectionsAndGroups(t *testing.T) {
+	useRealDecoding(t)
+
+	m, err := NewMessage(newOrderWithParties, LoadDictionary(newOrderWithParties))
+	if err != nil {
+		t.Fatalf("NewMessage failed: %v", err)
+	}
+
+	if m.MsgType() != "D" {
+		t.Errorf("expected MsgType D, got %q", m.MsgType())
+	}
+
+	if !m.Header.Has(49) || m.Body.Has(49) {
+		t.Error("expected SenderCompID in header only")
+	}
+
+	if !m.Trailer.Has(10) || m.Body.Has(10) {
+		t.Error("expected CheckSum in trailer only")
+	}
+
+	parties := m.Body.GetGroupByName("NoPartyIDs")
+	if len(parties) != 2 {
+		t.Fatalf("expected 2 party instances, got %d", len(parties))
+	}
+
+	if role, _ := parties[1].GetInt(452); role != 3 {
+		t.Errorf("expected second PartyRole 3, got %d", role)
+	}
+
+	if sym, ok := m.GetByName("Symbol"); !ok || sym != "EUR/USD" {
+		t.Errorf("expected Symbol EUR/USD, got %q", sym)
+	}
+
+	if m.Body.Has(448) {
+		t.Error("expected PartyID nested in group, not at body level")
+	}
+}
+
+func TestNewMessageGroupLayoutPerMessage(t *testing.T) {
+	useRealDecoding(t)
+
+	// FIX 4.2 declares NoRelatedSym inline with different members in
+	// QuoteRequest and MarketDataRequest.
+	for _, tc := range []struct {
+		msg    string
+		member int
+	}{
+		{"8=FIX.4.2\x019=0\x0135=R\x01131=Q1\x01146=1\x0155=IBM\x0154=1\x0138=100\x0110=000\x01", 38},
+		{"8=FIX.4.2\x019=0\x0135=V\x01262=M1\x01263=0\x01264=1\x01267=1\x01269=0\x01146=1\x0155=IBM\x0165=A\x0110=000\x01", 65},
+	} {
+		m, err := NewMessage(tc.msg, LoadDictionary(tc.msg))
+		if err != nil {
+			t.Fatalf("NewMessage failed: %v", err)
+		}
+
+		syms := m.Body.GetGroupByName("NoRelatedSym")
+		if len(syms) != 1 || !syms[0].Has(tc.member) {
+			t.Errorf("MsgType %s: expected tag %d inside NoRelatedSym, got %+v", m.MsgType(), tc.member, syms)
+		}
+	}
+}
+
+func TestMessageTypedGetters(t *testing.T) {
+	useRealDecoding(t)
+
+	m, err := ParseMessage(newOrderWithParties)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+
+	if qty, err := m.Body.GetInt(38); err != nil || qty != 1000 {
+		t.Errorf("GetInt(38) = %d, %v", qty, err)
+	}
+
+	px, err := m.Body.GetDecimal(44)
+	if err != nil || px.FloatString(4) != "1.2345" {
+		t.Errorf("GetDecimal(44) = %v, %v", px, err)
+	}
+
+	if side, err := m.Body.GetChar(54); err != nil || side != '1' {
+		t.Errorf("GetChar(54) = %q, %v", side, err)
+	}
+
+	ts, err := m.Header.GetUTCTimestamp(52)
+	want := time.Date(2025, 1, 1, 12, 0, 0, 123456000, time.UTC)
+	if err != nil || !ts.Equal(want) {
+		t.Errorf("GetUTCTimestamp(52) = %v, %v", ts, err)
+	}
+
+	if _, err := m.Body.GetInt(9999); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("expected ErrFieldNotFound, got %v", err)
+	}
+
+	if _, err := m.Body.GetInt(55); err == nil {
+		t.Error("expected error parsing Symbol as int")
+	}
+
+	if v, err := m.Body.GetValue(54); err != nil || v != byte('1') {
+		t.Errorf("GetValue(54) = %v, %v", v, err)
+	}
+	if v, err := m.Header.GetValue(52); err != nil || !v.(time.Time).Equal(want) {
+		t.Errorf("GetValue(52) = %v, %v", v, err)
+	}
+}
+
+func TestFieldMapGetBool(t *testing.T) {
+	fm := &FieldMap{}
+	fm.Set(43, "Y")
+	fm.Set(97, "X")
+
+	if v, err := fm.GetBool(43); err != nil || !v {
+		t.Errorf("GetBool(43) = %v, %v", v, err)
+	}
+
+	if _, err := fm.GetBool(97); err == nil {
+		t.Error("expected error for invalid boolean")
+	}
+
+	if _, err := fm.GetDecimal(97); err == nil {
+		t.Error("expected error for invalid decimal")
+	}
+
+	fm.Set(44, "1e5")
+	if _, err := fm.GetDecimal(44); err == nil {
+		t.Error("expected exponent notation to be rejected")
+	}
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	useRealDecoding(t)
+
+	m, err := ParseMessage(newOrderWithParties)
+	if err != nil {
+		t.Fatalf("ParseMessage failed: %v", err)
+	}
+
+	if got := m.String(); got != newOrderWithParties {
+		t.Errorf("round trip mismatch:\n got: %q\nwant: %q", got, newOrderWithParties)
+	}
+
+	encoded := m.Encode()
+	if errs := validateChecksumField(encoded, map[int]string{10: encoded[len(encoded)-4 : len(encoded)-1]}); errs != nil {
+		t.Errorf("expected valid checksum, got %v", errs)
+	}
+
+	if !strings.HasPrefix(encoded, "8=FIX.4.4\x019=") || strings.Contains(encoded, "\x019=0\x01") {
+		t.Errorf("expected recomputed BodyLength, got %q", encoded)
+	}
+}
+
+func TestNewMessageErrors(t *testing.T) {
+	if _, err := NewMessage("garbage", &FixTagLookup{}); err == nil {
+		t.Error("expected error for message without fields")
+	}
+
+	if _, err := NewMessage(newOrderWithParties, nil); err == nil {
+		t.Error("expected error for nil dictionary")
+	}
+}
@@ -0,0 +1,638 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// FIX Orchestra repositories describe the same fields, code sets,
+// components, groups and messages as a QuickFIX dictionary, referenced by
+// id rather than by name. They are converted to a FixDictionary so the
+// rest of the decoder never sees the difference.
+
+type orchestraRepository struct {
+	XMLName    xml.Name             `xml:"repository"`
+	Version    string               `xml:"version,attr"`
+	CodeSets   []orchestraCodeSet   `xml:"codeSets>codeSet"`
+	Fields     []orchestraField     `xml:"fields>field"`
+	Components []orchestraStructure `xml:"components>component"`
+	Groups     []orchestraStructure `xml:"groups>group"`
+	Messages   []orchestraMessage   `xml:"messages>message"`
+}
+
+type orchestraCodeSet struct {
+	Name     string `xml:"name,attr"`
+	Type     string `xml:"type,attr"`
+	Scenario string `xml:"scenario,attr"`
+	Codes    []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value,attr"`
+	} `xml:"code"`
+}
+
+type orchestraField struct {
+	ID       int    `xml:"id,attr"`
+	Name     string `xml:"name,attr"`
+	Type     string `xml:"type,attr"`
+	Scenario string `xml:"scenario,attr"`
+}
+
+// orchestraStructure is a component or a group; only groups have a
+// NumInGroup field.
+type orchestraStructure struct {
+	ID         int    `xml:"id,attr"`
+	Name       string `xml:"name,attr"`
+	Scenario   string `xml:"scenario,attr"`
+	NumInGroup struct {
+		ID int `xml:"id,attr"`
+	} `xml:"numInGroup"`
+	Members []orchestraRef `xml:",any"`
+}
+
+type orchestraMessage struct {
+	Name      string `xml:"name,attr"`
+	MsgType   string `xml:"msgType,attr"`
+	Category  string `xml:"category,attr"`
+	Scenario  string `xml:"scenario,attr"`
+	Structure struct {
+		Members []orchestraRef `xml:",any"`
+	} `xml:"structure"`
+}
+
+// orchestraRef is a fieldRef, componentRef or groupRef; other elements
+// (annotations and the like) are ignored.
+type orchestraRef struct {
+	XMLName  xml.Name
+	ID       int            `xml:"id,attr"`
+	Presence string         `xml:"presence,attr"`
+	Scenario string         `xml:"scenario,attr"`
+	Rules    []PresenceRule `xml:"rule"`
+}
+
+// orchestraVersion matches repository versions such as FIX.4.4 and
+// FIX.5.0SP2.
+var orchestraVersion = regexp.MustCompile(`^FIX\.(\d+)\.(\d+)(?:SP(\d+))?`)
+
+// ParseFixDictionary parses a QuickFIX dictionary or a FIX Orchestra
+// repository.
+func ParseFixDictionary(data []byte) (FixDictionary, error) {
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	dec.CharsetReader = charset.NewReaderLabel
+
+	if isOrchestra(data) {
+		var repo orchestraRepository
+		if err := dec.Decode(&repo); err != nil {
+			return FixDictionary{}, err
+		}
+		return repo.dictionary(), nil
+	}
+
+	var dict FixDictionary
+	if err := dec.Decode(&dict); err != nil {
+		return FixDictionary{}, err
+	}
+	return dict, nil
+}
+
+// isOrchestra reports whether the document element is an Orchestra
+// <repository>.
+func isOrchestra(data []byte) bool {
+	dec := xml.NewDecoder(strings.NewReader(string(data)))
+	dec.CharsetReader = charset.NewReaderLabel
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local == "repository"
+		}
+	}
+}
+
+// quickFIXXML returns xmlData in the QuickFIX layout, converting it first
+// if it is an Orchestra repository.
+func quickFIXXML(xmlData string) (string, error) {
+	if !isOrchestra([]byte(xmlData)) {
+		return xmlData, nil
+	}
+
+	dict, err := ParseFixDictionary([]byte(xmlData))
+	if err != nil {
+		return "", err
+	}
+
+	out, err := xml.Marshal(dict)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// orchestraIndex looks up repository elements by id, choosing the
+// requested scenario and falling back to the base one.
+type orchestraIndex struct {
+	codeSets   map[string]orchestraCodeSet
+	fields     map[string]orchestraField
+	components map[string]orchestraStructure
+	groups     map[string]orchestraStructure
+}
+
+func scenarioKey(id, scenario string) string {
+	if scenario == "" {
+		scenario = "base"
+	}
+	return id + "/" + scenario
+}
+
+func lookupScenario[V any](m map[string]V, id int, scenario string) (V, bool) {
+	key := strconv.Itoa(id)
+	if v, ok := m[scenarioKey(key, scenario)]; ok {
+		return v, true
+	}
+	v, ok := m[scenarioKey(key, "")]
+	return v, ok
+}
+
+func (repo orchestraRepository) index() orchestraIndex {
+	idx := orchestraIndex{
+		codeSets:   make(map[string]orchestraCodeSet, len(repo.CodeSets)),
+		fields:     make(map[string]orchestraField, len(repo.Fields)),
+		components: make(map[string]orchestraStructure, len(repo.Components)),
+		groups:     make(map[string]orchestraStructure, len(repo.Groups)),
+	}
+
+	for _, cs := range repo.CodeSets {
+		idx.codeSets[scenarioKey(cs.Name, cs.Scenario)] = cs
+	}
+	for _, f := range repo.Fields {
+		idx.fields[scenarioKey(strconv.Itoa(f.ID), f.Scenario)] = f
+	}
+	for _, c := range repo.Components {
+		idx.components[scenarioKey(strconv.Itoa(c.ID), c.Scenario)] = c
+	}
+	for _, g := range repo.Groups {
+		idx.groups[scenarioKey(strconv.Itoa(g.ID), g.Scenario)] = g
+	}
+
+	return idx
+}
+
+// dictionary converts the repository. Code sets become the enums of the
+// fields that use them, groups are inlined under their NumInGroup field,
+// and the StandardHeader and StandardTrailer components become the header
+// and trailer. Only the base scenario of each message is kept.
+func (repo orchestraRepository) dictionary() FixDictionary {
+	idx := repo.index()
+	var dict FixDictionary
+
+	if m := orchestraVersion.FindStringSubmatch(repo.Version); m != nil {
+		dict.Major, dict.Minor, dict.ServicePack = m[1], m[2], m[3]
+	}
+
+	for _, f := range repo.Fields {
+		if f.Scenario != "" && f.Scenario != "base" {
+			continue
+		}
+
+		field := Field{Name: f.Name, Number: f.ID, Type: strings.ToUpper(f.Type)}
+		if cs, ok := idx.codeSets[scenarioKey(f.Type, "")]; ok {
+			field.Type = strings.ToUpper(cs.Type)
+			for _, code := range cs.Codes {
+				field.Values = append(field.Values, Value{Enum: code.Value, Description: code.Name})
+			}
+		}
+		dict.Fields = append(dict.Fields, field)
+	}
+
+	for _, c := range repo.Components {
+		if c.Scenario != "" && c.Scenario != "base" {
+			continue
+		}
+
+		comp := idx.component(c.Name, c.Members, 0)
+		switch c.Name {
+		case "StandardHeader":
+			dict.Header = comp
+		case "StandardTrailer":
+			dict.Trailer = comp
+		default:
+			dict.Components = append(dict.Components, comp)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range repo.Messages {
+		if seen[m.MsgType] || (m.Scenario != "" && m.Scenario != "base" && hasBaseScenario(repo.Messages, m.MsgType)) {
+			continue
+		}
+		seen[m.MsgType] = true
+
+		comp := idx.component(m.Name, m.Structure.Members, 0)
+		dict.Messages = append(dict.Messages, Message{
+			Name:       m.Name,
+			MsgType:    m.MsgType,
+			MsgCat:     orchestraMsgCat(m.Category),
+			Fields:     comp.Fields,
+			Groups:     comp.Groups,
+			Components: comp.Components,
+			Order:      comp.Order,
+		})
+	}
+
+	return dict
+}
+
+func hasBaseScenario(msgs []orchestraMessage, msgType string) bool {
+	for _, m := range msgs {
+		if m.MsgType == msgType && (m.Scenario == "" || m.Scenario == "base") {
+			return true
+		}
+	}
+	return false
+}
+
+// orchestraMsgCat maps the Orchestra message category to the QuickFIX
+// msgcat: session messages are admin, everything else is app.
+func orchestraMsgCat(category string) string {
+	if strings.EqualFold(category, "Session") {
+		return "admin"
+	}
+	return "app"
+}
+
+// orchestraRequired maps an Orchestra presence to the QuickFIX required
+// flag. Forbidden members are dropped; conditional members are optional
+// and keep their rules.
+func orchestraRequired(presence string) (string, bool) {
+	switch presence {
+	case "required", "constant":
+		return "Y", true
+	case "forbidden":
+		return "", false
+	default:
+		return "N", true
+	}
+}
+
+func (idx orchestraIndex) component(name string, members []orchestraRef, depth int) Component {
+	comp := Component{Name: name}
+	if depth > maxStructureDepth {
+		return comp
+	}
+
+	for _, ref := range members {
+		required, ok := orchestraRequired(ref.Presence)
+		if !ok {
+			continue
+		}
+
+		switch ref.XMLName.Local {
+		case "fieldRef":
+			if f, ok := lookupScenario(idx.fields, ref.ID, ref.Scenario); ok {
+				comp.Fields = append(comp.Fields, FieldRef{Name: f.Name, Required: required, Rules: ref.Rules})
+				comp.Order = append(comp.Order, MemberRef{Kind: "field", Name: f.Name})
+			}
+		case "componentRef":
+			c, ok := lookupScenario(idx.components, ref.ID, ref.Scenario)
+			if ok && c.Name != "StandardHeader" && c.Name != "StandardTrailer" {
+				comp.Components = append(comp.Components, ComponentRef{Name: c.Name, Required: required})
+				comp.Order = append(comp.Order, MemberRef{Kind: "component", Name: c.Name})
+			}
+		case "groupRef":
+			if g, ok := idx.group(ref, required, depth+1); ok {
+				comp.Groups = append(comp.Groups, g)
+				comp.Order = append(comp.Order, MemberRef{Kind: "group", Name: g.Name})
+			}
+		}
+	}
+
+	return comp
+}
+
+// group inlines a group under the name of its NumInGroup field, as
+// QuickFIX dictionaries do.
+func (idx orchestraIndex) group(ref orchestraRef, required string, depth int) (Group, bool) {
+	g, ok := lookupScenario(idx.groups, ref.ID, ref.Scenario)
+	if !ok {
+		return Group{}, false
+	}
+
+	count, ok := lookupScenario(idx.fields, g.NumInGroup.ID, "")
+	if !ok {
+		return Group{}, false
+	}
+
+	members := idx.component(g.Name, g.Members, depth)
+	return Group{
+		Name:       count.Name,
+		Required:   required,
+		Fields:     members.Fields,
+		Groups:     members.Groups,
+		Components: members.Components,
+		Order:      members.Order,
+	}, true
+}
+
+// orchestraDatatypes maps QuickFIX types to Orchestra datatype names; each
+// upper-cases back to the QuickFIX type.
+var orchestraDatatypes = map[string]string{
+	"AMT":                 "Amt",
+	"BOOLEAN":             "Boolean",
+	"CHAR":                "char",
+	"COUNTRY":             "Country",
+	"CURRENCY":            "Currency",
+	"DATA":                "data",
+	"DATE":                "Date",
+	"DAYOFMONTH":          "DayOfMonth",
+	"EXCHANGE":            "Exchange",
+	"FLOAT":               "float",
+	"INT":                 "int",
+	"LANGUAGE":            "Language",
+	"LENGTH":              "Length",
+	"LOCALMKTDATE":        "LocalMktDate",
+	"LOCALMKTTIME":        "LocalMktTime",
+	"MONTHYEAR":           "MonthYear",
+	"MULTIPLECHARVALUE":   "MultipleCharValue",
+	"MULTIPLESTRINGVALUE": "MultipleStringValue",
+	"MULTIPLEVALUESTRING": "MultipleValueString",
+	"NUMINGROUP":          "NumInGroup",
+	"PERCENTAGE":          "Percentage",
+	"PRICE":               "Price",
+	"PRICEOFFSET":         "PriceOffset",
+	"QTY":                 "Qty",
+	"SEQNUM":              "SeqNum",
+	"STRING":              "String",
+	"TIME":                "Time",
+	"TZTIMEONLY":          "TZTimeOnly",
+	"TZTIMESTAMP":         "TZTimestamp",
+	"UTCDATE":             "UTCDate",
+	"UTCDATEONLY":         "UTCDateOnly",
+	"UTCTIMEONLY":         "UTCTimeOnly",
+	"UTCTIMESTAMP":        "UTCTimestamp",
+	"XMLDATA":             "XMLData",
+}
+
+func orchestraDatatype(fixType string) string {
+	if t, ok := orchestraDatatypes[fixType]; ok {
+		return t
+	}
+	return fixType
+}
+
+// The export types carry the fixr prefix in their element names; they are
+// only ever marshalled.
+type orchestraOut struct {
+	XMLName    xml.Name                `xml:"fixr:repository"`
+	Namespace  string                  `xml:"xmlns:fixr,attr"`
+	Name       string                  `xml:"name,attr"`
+	Version    string                  `xml:"version,attr"`
+	CodeSets   []orchestraCodeSetOut   `xml:"fixr:codeSets>fixr:codeSet"`
+	Fields     []orchestraFieldOut     `xml:"fixr:fields>fixr:field"`
+	Components []orchestraStructureOut `xml:"fixr:components>fixr:component"`
+	Groups     []orchestraStructureOut `xml:"fixr:groups>fixr:group"`
+	Messages   []orchestraMessageOut   `xml:"fixr:messages>fixr:message"`
+}
+
+type orchestraCodeSetOut struct {
+	Name  string             `xml:"name,attr"`
+	ID    int                `xml:"id,attr"`
+	Type  string             `xml:"type,attr"`
+	Codes []orchestraCodeOut `xml:"fixr:code"`
+}
+
+type orchestraCodeOut struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type orchestraFieldOut struct {
+	ID   int    `xml:"id,attr"`
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type orchestraStructureOut struct {
+	Name       string           `xml:"name,attr"`
+	ID         int              `xml:"id,attr"`
+	NumInGroup *orchestraRefOut `xml:"fixr:numInGroup"`
+	Members    []orchestraRefOut
+}
+
+type orchestraMessageOut struct {
+	Name      string `xml:"name,attr"`
+	ID        int    `xml:"id,attr"`
+	MsgType   string `xml:"msgType,attr"`
+	Category  string `xml:"category,attr,omitempty"`
+	Structure struct {
+		Members []orchestraRefOut
+	} `xml:"fixr:structure"`
+}
+
+type orchestraRefOut struct {
+	XMLName  xml.Name
+	ID       int                `xml:"id,attr"`
+	Presence string             `xml:"presence,attr,omitempty"`
+	Rules    []orchestraRuleOut `xml:"fixr:rule"`
+}
+
+type orchestraRuleOut struct {
+	Name     string `xml:"name,attr,omitempty"`
+	Presence string `xml:"presence,attr"`
+	When     string `xml:"fixr:when"`
+}
+
+const (
+	orchestraNamespace = "http://fixprotocol.io/2020/orchestra/repository"
+	orchestraHeaderID  = 1024
+	orchestraTrailerID = 1025
+)
+
+// orchestraWriter assigns ids to components and to the distinct groups
+// found inline in the schema.
+type orchestraWriter struct {
+	schema     SchemaTree
+	repo       orchestraOut
+	components map[string]int
+	groups     map[string]int // group structure → id
+	groupNames map[string]int // NumInGroup name → variants seen
+	nextID     int
+}
+
+// writeOrchestra writes schema as a FIX Orchestra repository that
+// ParseFixDictionary reads back. Each distinct layout of a QuickFIX inline
+// group becomes an Orchestra group, named after its NumInGroup field.
+func writeOrchestra(w io.Writer, schema SchemaTree) error {
+	ow := &orchestraWriter{
+		schema:     schema,
+		components: map[string]int{"Header": orchestraHeaderID, "Trailer": orchestraTrailerID},
+		groups:     make(map[string]int),
+		groupNames: make(map[string]int),
+		nextID:     2000,
+	}
+
+	version := "FIX." + schema.Version
+	if hasServicePack(schema) {
+		version += "SP" + schema.ServicePack
+	}
+	ow.repo = orchestraOut{Namespace: orchestraNamespace, Name: version, Version: version}
+
+	ow.writeFields()
+
+	comps := sortedComponents(schema)
+	for _, c := range comps {
+		if _, ok := ow.components[c.Name]; !ok {
+			ow.components[c.Name] = ow.nextID
+			ow.nextID++
+		}
+	}
+
+	for _, c := range comps {
+		name := c.Name
+		switch name {
+		case "Header":
+			name = "StandardHeader"
+		case "Trailer":
+			name = "StandardTrailer"
+		}
+		ow.repo.Components = append(ow.repo.Components, orchestraStructureOut{
+			Name:    name,
+			ID:      ow.components[c.Name],
+			Members: ow.members(c.Fields, c.Components, c.Groups),
+		})
+	}
+
+	for i, m := range sortedMessages(schema) {
+		members := []orchestraRefOut{newOrchestraRef("componentRef", orchestraHeaderID, "required")}
+		members = append(members, ow.members(m.Fields, m.Components, m.Groups)...)
+		members = append(members, newOrchestraRef("componentRef", orchestraTrailerID, "required"))
+
+		msg := orchestraMessageOut{Name: m.Name, ID: i + 1, MsgType: m.MsgType}
+		msg.Structure.Members = members
+		if m.MsgCat == "admin" {
+			msg.Category = "Session"
+		}
+		ow.repo.Messages = append(ow.repo.Messages, msg)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(ow.repo); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (ow *orchestraWriter) writeFields() {
+	for _, f := range sortedFields(ow.schema) {
+		field := orchestraFieldOut{ID: f.Number, Name: f.Name, Type: orchestraDatatype(f.Type)}
+
+		if len(f.Values) > 0 {
+			cs := orchestraCodeSetOut{Name: f.Name + "CodeSet", ID: f.Number, Type: field.Type}
+			for _, v := range f.Values {
+				cs.Codes = append(cs.Codes, orchestraCodeOut{Name: v.Description, Value: v.Enum})
+			}
+			ow.repo.CodeSets = append(ow.repo.CodeSets, cs)
+			field.Type = cs.Name
+		}
+
+		ow.repo.Fields = append(ow.repo.Fields, field)
+	}
+}
+
+func newOrchestraRef(kind string, id int, presence string) orchestraRefOut {
+	return orchestraRefOut{XMLName: xml.Name{Local: "fixr:" + kind}, ID: id, Presence: presence}
+}
+
+func orchestraPresence(required string) string {
+	if required == "Y" {
+		return "required"
+	}
+	return ""
+}
+
+func (ow *orchestraWriter) members(fields []FieldNode, comps []ComponentNode, groups []GroupNode) []orchestraRefOut {
+	var out []orchestraRefOut
+
+	for _, f := range fields {
+		ref := newOrchestraRef("fieldRef", f.Field.Number, orchestraPresence(f.Ref.Required))
+		for _, r := range f.Ref.Rules {
+			ref.Rules = append(ref.Rules, orchestraRuleOut(r))
+		}
+		if len(ref.Rules) > 0 && ref.Presence == "" {
+			ref.Presence = "conditional"
+		}
+		out = append(out, ref)
+	}
+
+	for _, c := range comps {
+		out = append(out, newOrchestraRef("componentRef", ow.components[c.Name], orchestraPresence(c.Required)))
+	}
+
+	for _, g := range groups {
+		out = append(out, newOrchestraRef("groupRef", ow.group(g), orchestraPresence(g.Required)))
+	}
+
+	return out
+}
+
+// group returns the id of the Orchestra group for g, adding it the first
+// time its layout is seen.
+func (ow *orchestraWriter) group(g GroupNode) int {
+	members := ow.members(g.Fields, g.Components, g.Groups)
+
+	key := fmt.Sprint(g.Name, members)
+	if id, ok := ow.groups[key]; ok {
+		return id
+	}
+
+	id := ow.nextID
+	ow.nextID++
+	ow.groups[key] = id
+
+	name := g.Name
+	if n := ow.groupNames[g.Name]; n > 0 {
+		name = fmt.Sprintf("%s%d", g.Name, n+1)
+	}
+	ow.groupNames[g.Name]++
+
+	count := newOrchestraRef("numInGroup", ow.schema.Fields[g.Name].Number, "")
+	ow.repo.Groups = append(ow.repo.Groups, orchestraStructureOut{Name: name, ID: id, NumInGroup: &count, Members: members})
+
+	return id
+}
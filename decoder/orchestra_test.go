@@ -0,0 +1,220 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+const orchestraXML = `<?xml version="1.0" encoding="UTF-8"?>
+<fixr:repository xmlns:fixr="http://fixprotocol.io/2020/orchestra/repository" name="Venue" version="FIX.4.4">
+ <fixr:codeSets>
+  <fixr:codeSet name="SideCodeSet" id="54" type="char">
+   <fixr:code name="Buy" id="54001" value="1"/>
+   <fixr:code name="Sell" id="54002" value="2"/>
+  </fixr:codeSet>
+  <fixr:codeSet name="OrdTypeCodeSet" id="40" type="char">
+   <fixr:code name="Market" id="40001" value="1"/>
+   <fixr:code name="Limit" id="40002" value="2"/>
+  </fixr:codeSet>
+ </fixr:codeSets>
+ <fixr:fields>
+  <fixr:field id="8" name="BeginString" type="String"/>
+  <fixr:field id="9" name="BodyLength" type="Length"/>
+  <fixr:field id="35" name="MsgType" type="String"/>
+  <fixr:field id="10" name="CheckSum" type="String"/>
+  <fixr:field id="11" name="ClOrdID" type="String"/>
+  <fixr:field id="40" name="OrdType" type="OrdTypeCodeSet"/>
+  <fixr:field id="44" name="Price" type="Price"/>
+  <fixr:field id="54" name="Side" type="SideCodeSet"/>
+  <fixr:field id="58" name="Text" type="String"/>
+  <fixr:field id="448" name="PartyID" type="String"/>
+  <fixr:field id="453" name="NoPartyIDs" type="NumInGroup"/>
+  <fixr:field id="5001" name="VenueTag" type="String"/>
+ </fixr:fields>
+ <fixr:components>
+  <fixr:component name="StandardHeader" id="1024">
+   <fixr:fieldRef id="8" presence="required"/>
+   <fixr:fieldRef id="9" presence="required"/>
+   <fixr:fieldRef id="35" presence="required"/>
+  </fixr:component>
+  <fixr:component name="StandardTrailer" id="1025">
+   <fixr:fieldRef id="10" presence="required"/>
+  </fixr:component>
+  <fixr:component name="OrderDetail" id="2000">
+   <fixr:annotation><fixr:documentation>Order fields</fixr:documentation></fixr:annotation>
+   <fixr:fieldRef id="54" presence="required"/>
+   <fixr:fieldRef id="5001"/>
+  </fixr:component>
+ </fixr:components>
+ <fixr:groups>
+  <fixr:group name="Parties" id="1012">
+   <fixr:numInGroup id="453"/>
+   <fixr:fieldRef id="448" presence="required"/>
+  </fixr:group>
+ </fixr:groups>
+ <fixr:messages>
+  <fixr:message name="NewOrderSingle" id="14" msgType="D" category="SingleGeneralOrderHandling">
+   <fixr:structure>
+    <fixr:componentRef id="1024" presence="required"/>
+    <fixr:fieldRef id="11" presence="required"/>
+    <fixr:fieldRef id="40" presence="required"/>
+    <fixr:fieldRef id="44" presence="conditional">
+     <fixr:rule name="PriceForLimit" presence="required"><fixr:when>OrdType == ^Limit</fixr:when></fixr:rule>
+    </fixr:fieldRef>
+    <fixr:fieldRef id="58" presence="forbidden"/>
+    <fixr:componentRef id="2000" presence="required"/>
+    <fixr:groupRef id="1012" presence="optional"/>
+    <fixr:componentRef id="1025" presence="required"/>
+   </fixr:structure>
+  </fixr:message>
+  <fixr:message name="NewOrderSingle" id="14" msgType="D" scenario="Limit" category="SingleGeneralOrderHandling">
+   <fixr:structure><fixr:fieldRef id="44" presence="required"/></fixr:structure>
+  </fixr:message>
+  <fixr:message name="Heartbeat" id="1" msgType="0" category="Session">
+   <fixr:structure><fixr:fieldRef id="58"/></fixr:structure>
+  </fixr:message>
+ </fixr:messages>
+</fixr:repository>`
+
+func TestParseFixDictionaryOrchestra(t *testing.T) {
+	dict, err := ParseFixDictionary([]byte(orchestraXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := BuildSchema(dict)
+	if schema.Version != "4.4" || len(schema.Messages) != 2 {
+		t.Fatalf("unexpected schema version=%s messages=%d", schema.Version, len(schema.Messages))
+	}
+
+	side := schema.Fields["Side"]
+	if side.Number != 54 || side.Type != "CHAR" || !reflect.DeepEqual(side.Values, []Value{{"1", "Buy"}, {"2", "Sell"}}) {
+		t.Errorf("unexpected Side field %+v", side)
+	}
+	if schema.Fields["BodyLength"].Type != "LENGTH" {
+		t.Errorf("expected datatypes to be upper-cased, got %q", schema.Fields["BodyLength"].Type)
+	}
+
+	order := schema.Messages["NewOrderSingle"]
+	if order.MsgType != "D" || order.MsgCat != "app" || schema.Messages["Heartbeat"].MsgCat != "admin" {
+		t.Errorf("unexpected message attributes %+v", order)
+	}
+
+	var names []string
+	for _, f := range order.Fields {
+		names = append(names, f.Field.Name+"="+f.Ref.Required)
+	}
+	if want := []string{"ClOrdID=Y", "OrdType=Y", "Price=N"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("fields: got %v, want %v", names, want)
+	}
+
+	wantRule := []PresenceRule{{Name: "PriceForLimit", Presence: "required", When: "OrdType == ^Limit"}}
+	if !reflect.DeepEqual(order.Fields[2].Ref.Rules, wantRule) {
+		t.Errorf("rules: got %+v, want %+v", order.Fields[2].Ref.Rules, wantRule)
+	}
+
+	if len(order.Components) != 1 || order.Components[0].Name != "OrderDetail" || order.Components[0].Required != "Y" {
+		t.Errorf("expected only the OrderDetail component, got %+v", order.Components)
+	}
+
+	if len(order.Groups) != 1 || order.Groups[0].Name != "NoPartyIDs" || order.Groups[0].Fields[0].Field.Name != "PartyID" {
+		t.Errorf("expected the Parties group inlined as NoPartyIDs, got %+v", order.Groups)
+	}
+
+	wantOrder := []MemberRef{{"field", "ClOrdID"}, {"field", "OrdType"}, {"field", "Price"}, {"component", "OrderDetail"}, {"group", "NoPartyIDs"}}
+	if !reflect.DeepEqual(order.Order, wantOrder) {
+		t.Errorf("order: got %v, want %v", order.Order, wantOrder)
+	}
+
+	if h := schema.Components["Header"]; len(h.Fields) != 3 || h.Fields[2].Field.Name != "MsgType" {
+		t.Errorf("unexpected header %+v", h)
+	}
+	if tr := schema.Components["Trailer"]; len(tr.Fields) != 1 || tr.Fields[0].Field.Name != "CheckSum" {
+		t.Errorf("unexpected trailer %+v", tr)
+	}
+}
+
+func TestParseFixDictionaryQuickFIX(t *testing.T) {
+	dict, err := ParseFixDictionary([]byte(fix.ChooseEmbeddedXML("42")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dict.Major != "4" || dict.Minor != "2" || len(dict.Messages) == 0 {
+		t.Errorf("unexpected dictionary %s.%s with %d messages", dict.Major, dict.Minor, len(dict.Messages))
+	}
+
+	if _, err := ParseFixDictionary([]byte("<fixr:repository")); err == nil {
+		t.Error("expected an error for truncated XML")
+	}
+}
+
+func TestParseDictionaryOrchestra(t *testing.T) {
+	d, err := parseDictionary(orchestraXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !d.IsHeaderField(35) || !d.IsTrailerField(10) || d.GetFieldName(5001) != "VenueTag" {
+		t.Error("expected header, trailer and fields from the repository")
+	}
+	if d.GetEnumDescription(54, "2") != "Sell" || d.GetEnumDescription(35, "D") != "NewOrderSingle" {
+		t.Error("expected enums from code sets and MsgType descriptions")
+	}
+	if def, ok := d.GetGroupDef(453); !ok || !reflect.DeepEqual(def.FieldOrder, []int{448}) {
+		t.Errorf("unexpected group definition %+v", def)
+	}
+	if msg := d.Messages["D"]; !reflect.DeepEqual(msg.Required, []int{11, 40}) {
+		t.Errorf("unexpected required fields %v", msg.Required)
+	}
+}
+
+func TestCustomDictionary(t *testing.T) {
+	if _, err := NewCustomDictionary("<fix"); err == nil {
+		t.Fatal("expected an error for invalid XML")
+	}
+
+	custom, err := NewCustomDictionary(orchestraXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := "8=FIX.4.4\x019=5\x0135=D\x015001=X\x0155=IBM\x0110=000\x01"
+	d := custom.Lookup(msg)
+	if d.GetFieldName(5001) != "VenueTag" || d.GetFieldName(55) != "Symbol" {
+		t.Errorf("expected custom fields backed by the embedded dictionary, got %q and %q", d.GetFieldName(5001), d.GetFieldName(55))
+	}
+	if _, ok := d.Messages["8"]; !ok {
+		t.Error("expected messages missing from the custom dictionary to come from the embedded one")
+	}
+
+	if custom, err = NewCustomDictionary(""); err != nil || custom != nil {
+		t.Fatalf("expected no custom dictionary for empty XML, got %v, %v", custom, err)
+	}
+	if custom.Lookup(msg).GetFieldName(5001) != "5001" {
+		t.Error("expected the embedded dictionary without a custom one")
+	}
+}
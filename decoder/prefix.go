@@ -0,0 +1,337 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message directions as seen by whoever wrote the log.
+const (
+	DirectionIn  = "IN"
+	DirectionOut = "OUT"
+)
+
+// prefixParserOrAuto returns p, or the "auto" parser when p is nil.
+func prefixParserOrAuto(p PrefixParser) PrefixParser {
+	if p != nil {
+		return p
+	}
+	return autoPrefixParser{}
+}
+
+// LinePrefix is what the text before a message on its log line says
+// about it. Fields the text does not give are left zero.
+type LinePrefix struct {
+	Time      time.Time `json:"time,omitzero"`
+	Direction string    `json:"direction,omitempty"` // DirectionIn, DirectionOut or ""
+	Session   string    `json:"session,omitempty"`
+}
+
+// IsZero reports whether the prefix gave nothing away.
+func (p LinePrefix) IsZero() bool {
+	return p.Time.IsZero() && p.Direction == "" && p.Session == ""
+}
+
+func (p LinePrefix) String() string {
+	var parts []string
+	if !p.Time.IsZero() {
+		parts = append(parts, p.Time.Format("2006-01-02 15:04:05.999999999"))
+	}
+	if p.Direction != "" {
+		parts = append(parts, p.Direction)
+	}
+	if p.Session != "" {
+		parts = append(parts, p.Session)
+	}
+	return strings.Join(parts, " ")
+}
+
+// PrefixParser reads the text before a message on a log line, reporting
+// false when the text is not in its format.
+type PrefixParser interface {
+	Name() string
+	Parse(prefix string) (LinePrefix, bool)
+}
+
+// regexPrefixParser matches the prefix against each pattern in turn. The
+// named groups time, dir and session give the fields; dir or session
+// missing from a pattern are looked for anywhere in the prefix.
+type regexPrefixParser struct {
+	name     string
+	patterns []*regexp.Regexp
+	layouts  []string // time layouts tried before the common log formats
+	in, out  []string // dir values meaning IN and OUT; defaults when empty
+}
+
+func (p *regexPrefixParser) Name() string { return p.name }
+
+func (p *regexPrefixParser) Parse(prefix string) (LinePrefix, bool) {
+	for _, re := range p.patterns {
+		m := re.FindStringSubmatch(prefix)
+		if m == nil {
+			continue
+		}
+
+		var lp LinePrefix
+		group := func(name string) (string, bool) {
+			i := re.SubexpIndex(name)
+			return strings.TrimSpace(m[max(i, 0)]), i >= 0
+		}
+
+		if s, _ := group("time"); s != "" {
+			lp.Time, _ = p.parseTime(s)
+		}
+		if s, ok := group("dir"); ok {
+			lp.Direction = p.direction(s)
+		} else {
+			lp.Direction = prefixDirection(prefix)
+		}
+		if s, ok := group("session"); ok {
+			lp.Session = s
+		} else {
+			lp.Session = sessionIDPattern.FindString(prefix)
+		}
+
+		return lp, !lp.IsZero()
+	}
+	return LinePrefix{}, false
+}
+
+func (p *regexPrefixParser) parseTime(s string) (time.Time, bool) {
+	for _, layout := range p.layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return parseLogTime(s)
+}
+
+func (p *regexPrefixParser) direction(s string) string {
+	if len(p.in) == 0 && len(p.out) == 0 {
+		return detectDirection(s)
+	}
+	for _, v := range p.in {
+		if strings.EqualFold(s, v) {
+			return DirectionIn
+		}
+	}
+	for _, v := range p.out {
+		if strings.EqualFold(s, v) {
+			return DirectionOut
+		}
+	}
+	return ""
+}
+
+var (
+	// logTimePattern finds a date and time such as 2024-01-02 03:04:05.678
+	// or 20240102-03:04:05.678.
+	logTimePattern = regexp.MustCompile(`(\d{4})-?(\d{2})-?(\d{2})[T -](\d{2}):(\d{2}):(\d{2})(?:[.,](\d{1,9}))?`)
+
+	// sessionIDPattern finds a QuickFIX session ID, BEGINSTRING:SENDER->TARGET.
+	sessionIDPattern = regexp.MustCompile(`FIXT?\.\d\.\d(?:SP\d)?:[^\s,:>]+->[^\s,:>]+`)
+)
+
+// parseLogTime parses the last date and time in s, taken as UTC.
+func parseLogTime(s string) (time.Time, bool) {
+	all := logTimePattern.FindAllStringSubmatch(s, -1)
+	if len(all) == 0 {
+		return time.Time{}, false
+	}
+	m := all[len(all)-1]
+
+	n := make([]int, 6)
+	for i := range n {
+		n[i], _ = strconv.Atoi(m[i+1])
+	}
+	nsec, _ := strconv.Atoi((m[7] + "000000000")[:9])
+
+	return time.Date(n[0], time.Month(n[1]), n[2], n[3], n[4], n[5], nsec, time.UTC), true
+}
+
+// prefixDirection is detectDirection ignoring the arrow in a session ID.
+func prefixDirection(prefix string) string {
+	return detectDirection(sessionIDPattern.ReplaceAllString(prefix, ""))
+}
+
+// directionPattern finds direction markers in the log text before a message.
+var directionPattern = regexp.MustCompile(`(?i)\b(in|out|incoming|outgoing|inbound|outbound|recv|received|sent|send)\b|<-|->|<<|>>`)
+
+// detectDirection returns IN or OUT from the marker closest to the message.
+func detectDirection(prefix string) string {
+	matches := directionPattern.FindAllString(prefix, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	switch strings.ToLower(matches[len(matches)-1]) {
+	case "in", "incoming", "inbound", "recv", "received", "<-", "<<":
+		return DirectionIn
+	default:
+		return DirectionOut
+	}
+}
+
+const qfTime = `\d{8}-\d{2}:\d{2}:\d{2}(?:\.\d{1,9})?`
+
+var builtinPrefixParsers = map[string]PrefixParser{
+	// QuickFIX and QuickFIX/J FileLog: "20240102-03:04:05.678 : 8=FIX...".
+	"quickfix": &regexPrefixParser{name: "quickfix", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^(?P<time>` + qfTime + `) ?: $`),
+	}},
+	// QuickFIX/J ScreenLog, "<time, session, incoming> (8=FIX...", and
+	// SLF4JLog through the quickfixj.msg.incoming/outgoing categories.
+	"quickfixj": &regexPrefixParser{name: "quickfixj", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`<(?P<time>` + qfTime + `), (?P<session>[^,]+), (?P<dir>incoming|outgoing)> \($`),
+		regexp.MustCompile(`quickfixj\.msg\.(?P<dir>incoming|outgoing)`),
+	}},
+	// log4j and logback default patterns: "2024-01-02 03:04:05,678 INFO ...".
+	"log4j": &regexPrefixParser{name: "log4j", patterns: []*regexp.Regexp{
+		regexp.MustCompile(`^\[?(?P<time>\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(?:[.,]\d{1,9})?)`),
+	}},
+}
+
+// autoPrefixParser tries each built-in format, then looks for a time,
+// direction marker and session ID anywhere in the prefix.
+type autoPrefixParser struct{}
+
+var autoPrefixOrder = []string{"quickfixj", "quickfix", "log4j"}
+
+func (autoPrefixParser) Name() string { return "auto" }
+
+func (autoPrefixParser) Parse(prefix string) (LinePrefix, bool) {
+	for _, name := range autoPrefixOrder {
+		if lp, ok := builtinPrefixParsers[name].Parse(prefix); ok {
+			return lp, true
+		}
+	}
+
+	var lp LinePrefix
+	lp.Time, _ = parseLogTime(prefix)
+	lp.Direction = prefixDirection(prefix)
+	lp.Session = sessionIDPattern.FindString(prefix)
+	return lp, !lp.IsZero()
+}
+
+// PrefixParserNames lists the built-in prefix parsers, "auto" first.
+func PrefixParserNames() []string {
+	names := make([]string, 0, len(builtinPrefixParsers))
+	for name := range builtinPrefixParsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{"auto"}, names...)
+}
+
+// LookupPrefixParser returns a built-in prefix parser by name.
+func LookupPrefixParser(name string) (PrefixParser, bool) {
+	name = strings.ToLower(name)
+	if name == "auto" {
+		return autoPrefixParser{}, true
+	}
+	p, ok := builtinPrefixParsers[name]
+	return p, ok
+}
+
+// ResolvePrefixParser returns the built-in parser named spec, or loads
+// spec as a prefix config file.
+func ResolvePrefixParser(spec string) (PrefixParser, error) {
+	if p, ok := LookupPrefixParser(spec); ok {
+		return p, nil
+	}
+
+	p, err := LoadPrefixFile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("unknown prefix parser %q (built-in: %s): %w", spec, strings.Join(PrefixParserNames(), ", "), err)
+	}
+	return p, nil
+}
+
+// LoadPrefixFile reads a prefix config. Each line is "key = value":
+// "pattern = REGEX" (repeatable) with the named groups time, dir and
+// session, "time = LAYOUT" in Go's time layout syntax (repeatable), and
+// "in = A,B" and "out = C,D" for the dir values meaning each direction.
+// Blank lines and '#' comments are ignored.
+func LoadPrefixFile(path string) (PrefixParser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parsePrefixConfig(path, f)
+}
+
+func parsePrefixConfig(name string, r io.Reader) (PrefixParser, error) {
+	p := &regexPrefixParser{name: name}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("prefix config line %d: expected key = value", lineNo)
+		}
+		val = strings.TrimSpace(val)
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "pattern":
+			re, err := regexp.Compile(val)
+			if err != nil {
+				return nil, fmt.Errorf("prefix config line %d: %w", lineNo, err)
+			}
+			p.patterns = append(p.patterns, re)
+		case "time":
+			p.layouts = append(p.layouts, val)
+		case "in":
+			p.in = append(p.in, splitList(val)...)
+		case "out":
+			p.out = append(p.out, splitList(val)...)
+		default:
+			return nil, fmt.Errorf("prefix config line %d: unknown key %q", lineNo, strings.TrimSpace(key))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p.patterns) == 0 {
+		return nil, fmt.Errorf("prefix config %s: no pattern", name)
+	}
+	return p, nil
+}
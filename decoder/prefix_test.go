@@ -0,0 +1,156 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuiltinPrefixParsers(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)
+
+	for _, tc := range []struct {
+		parser, prefix string
+		want           LinePrefix
+	}{
+		{"quickfix", "20240102-03:04:05.678 : ", LinePrefix{Time: at}},
+		{"quickfix", "20240102-03:04:05.678: ", LinePrefix{Time: at}},
+		{"quickfixj", "<20240102-03:04:05.678, FIX.4.4:BANZAI->EXEC, incoming> (", LinePrefix{Time: at, Direction: DirectionIn, Session: "FIX.4.4:BANZAI->EXEC"}},
+		{"quickfixj", "2024-01-02 03:04:05,678 INFO  quickfixj.msg.outgoing - FIX.4.4:BANZAI->EXEC: ", LinePrefix{Direction: DirectionOut, Session: "FIX.4.4:BANZAI->EXEC"}},
+		{"log4j", "2024-01-02 03:04:05,678 [main] INFO  FixSession - IN ", LinePrefix{Time: at, Direction: DirectionIn}},
+		{"auto", "2024-01-02 03:04:05,678 INFO  quickfixj.msg.outgoing - FIX.4.4:BANZAI->EXEC: ", LinePrefix{Direction: DirectionOut, Session: "FIX.4.4:BANZAI->EXEC"}},
+		{"auto", "20240102-03:04:05.678 : ", LinePrefix{Time: at}},
+		{"auto", "Jan 2 app[1]: >> FIX.4.2:A->B ", LinePrefix{Direction: DirectionOut, Session: "FIX.4.2:A->B"}},
+	} {
+		p, ok := LookupPrefixParser(tc.parser)
+		if !ok {
+			t.Fatalf("no %s parser", tc.parser)
+		}
+		got, ok := p.Parse(tc.prefix)
+		if !ok || !got.Time.Equal(tc.want.Time) || got.Direction != tc.want.Direction || got.Session != tc.want.Session {
+			t.Errorf("%s.Parse(%q) = %+v, %v; want %+v", tc.parser, tc.prefix, got, ok, tc.want)
+		}
+	}
+
+	quickfix, _ := LookupPrefixParser("quickfix")
+	if _, ok := quickfix.Parse("2024-01-02 03:04:05,678 INFO "); ok {
+		t.Error("expected quickfix not to parse a log4j prefix")
+	}
+	auto, _ := LookupPrefixParser("auto")
+	if _, ok := auto.Parse("noise "); ok {
+		t.Error("expected nothing from a prefix without a time, direction or session")
+	}
+}
+
+func TestParseLogTime(t *testing.T) {
+	for s, want := range map[string]time.Time{
+		"2024-01-02 03:04:05.678 IN ": time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC),
+		"20240102-03:04:05,5 ":        time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC),
+		"2024-01-02T03:04:05 ":        time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	} {
+		if got, ok := parseLogTime(s); !ok || !got.Equal(want) {
+			t.Errorf("parseLogTime(%q) = %v, %v; want %v", s, got, ok, want)
+		}
+	}
+
+	if _, ok := parseLogTime("IN "); ok {
+		t.Error("expected no timestamp")
+	}
+}
+
+func TestPrefixConfig(t *testing.T) {
+	p, err := parsePrefixConfig("venue", strings.NewReader(`# venue gateway log
+pattern = ^(?P<time>\d{2}/\d{2}/\d{4} \d{2}:\d{2}:\d{2}\.\d{3}) \[(?P<session>[^\]]+)\] (?P<dir>RX|TX)
+time = 02/01/2006 15:04:05.000
+in = RX
+out = TX
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, ok := p.Parse("02/01/2024 03:04:05.678 [LSE-1] TX ")
+	want := LinePrefix{Time: time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC), Direction: DirectionOut, Session: "LSE-1"}
+	if !ok || got != want {
+		t.Errorf("Parse = %+v, %v; want %+v", got, ok, want)
+	}
+	if p.Name() != "venue" {
+		t.Errorf("unexpected name %q", p.Name())
+	}
+
+	for config, want := range map[string]string{
+		"pattern = (":      "prefix config line 1:",
+		"colour = red":     `prefix config line 1: unknown key "colour"`,
+		"pattern":          "prefix config line 1: expected key = value",
+		"# only a comment": "no pattern",
+	} {
+		if _, err := parsePrefixConfig("bad", strings.NewReader(config)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("config %q: expected error %q, got %v", config, want, err)
+		}
+	}
+}
+
+func TestResolvePrefixParser(t *testing.T) {
+	if p, err := ResolvePrefixParser("QuickFIXJ"); err != nil || p.Name() != "quickfixj" {
+		t.Errorf("expected the quickfixj parser, got %v, %v", p, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "gateway.prefix")
+	_ = os.WriteFile(path, []byte("pattern = ^(?P<dir>RX|TX) \nin = RX\nout = TX\n"), 0644)
+	if p, err := ResolvePrefixParser(path); err != nil || p.Name() != path {
+		t.Errorf("expected the config file parser, got %v, %v", p, err)
+	}
+
+	if _, err := ResolvePrefixParser("nosuch"); err == nil || !strings.Contains(err.Error(), "built-in: auto, log4j, quickfix, quickfixj") {
+		t.Errorf("expected an unknown parser error, got %v", err)
+	}
+}
+
+func TestDecodedOutputShowsPrefix(t *testing.T) {
+	useRealDecoding(t)
+
+	auto, _ := LookupPrefixParser("auto")
+	opts := LogOptions{DecodeOptions: DecodeOptions{Prefix: auto}}
+
+	line := "<20240102-03:04:05.678, FIX.4.4:A->B, outgoing> (" + strings.ReplaceAll(framedFIX44("35=0|49=A|56=B|34=1|52=20240102-03:04:05.678|"), "\x01", "|") + ")"
+
+	dl := DecodeLogLine(line, opts, &bytes.Buffer{})
+	if len(dl.Messages) != 1 || dl.Messages[0].Prefix == nil || dl.Messages[0].Prefix.Direction != DirectionOut {
+		t.Fatalf("expected a parsed prefix, got %+v", dl.Messages)
+	}
+
+	var out bytes.Buffer
+	writeDecodedLine(dl, WithTheme(&out, NoTheme), "\n", opts)
+	if !strings.Contains(out.String(), "-- 2024-01-02 03:04:05.678 OUT FIX.4.4:A->B\n") {
+		t.Errorf("expected the prefix in the output, got %q", out.String())
+	}
+
+	if dl := DecodeLogLine(line, LogOptions{}, &bytes.Buffer{}); dl.Messages[0].Prefix != nil {
+		t.Error("expected no prefix without a parser")
+	}
+}
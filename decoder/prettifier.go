@@ -23,7 +23,6 @@ code of your version.
 package decoder
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
@@ -35,37 +34,19 @@ import (
 )
 
 var (
-	loadDictionary   = LoadDictionary
-	parseFix         = ParseFix
-	streamLogFunc    = streamLog
-	getTermSize      = term.GetSize // allow override in tests
-	enableValidation = false        // controlled by -validate flag
+	loadDictionary = LoadDictionary
+	parseFix       = ParseFix
+	streamLogFunc  = streamLog
+	getTermSize    = term.GetSize // allow override in tests
 )
 
-var (
-	ColourReset = "\033[0m"
-	ColourLine  = "\033[38;5;244m"
-	ColourTag   = "\033[38;5;81m"
-	ColourName  = "\033[38;5;151m"
-	ColourValue = "\033[38;5;228m"
-	ColourEnum  = "\033[38;5;214m"
-	ColourFile  = "\033[95m"
-	ColourError = "\033[31m"
-	ColourMsg   = "\033[97m"
-	ColourTitle = "\033[31m"
-)
-
-func DisableColours() {
-	ColourReset = ""
-	ColourLine = ""
-	ColourTag = ""
-	ColourName = ""
-	ColourValue = ""
-	ColourEnum = ""
-	ColourFile = ""
-	ColourError = ""
-	ColourMsg = ""
-	ColourTitle = ""
+// LogOptions controls how log files are decoded and written.
+type LogOptions struct {
+	DecodeOptions
+	Obfuscator *fix.Obfuscator // hides sensitive values and scrubs free text; nil leaves lines as they are
+	Report     *Report         // collects the findings of every line; nil collects none
+	Wide       bool            // packed column layout
+	Expand     bool            // with Wide, do not truncate long values
 }
 
 func PrettifySimple(msg string) string {
@@ -73,21 +54,29 @@ func PrettifySimple(msg string) string {
 	return Prettify(msg, dict)
 }
 
+// Prettify renders msg one field per line using DarkTheme.
 func Prettify(msg string, dict *FixTagLookup) string {
-	var sb strings.Builder
+	return PrettifyWithTheme(msg, dict, DarkTheme)
+}
 
-	for _, fv := range parseFix(msg) {
-		name := dict.GetFieldName(fv.Tag)
-		desc := dict.GetEnumDescription(fv.Tag, fv.Value)
+// PrettifyWithTheme renders msg one field per line using theme t.
+func PrettifyWithTheme(msg string, dict *FixTagLookup, t Theme) string {
+	return renderDecodedFields(DecodeMessage(msg, dict).Flat(), t)
+}
 
-		sb.WriteString(fmt.Sprintf("    %s%4d%s (%s%s%s): %s%s%s",
-			ColourTag, fv.Tag, ColourReset,
-			ColourName, name, ColourReset,
-			ColourValue, fv.Value, ColourReset,
+func renderDecodedFields(fields []DecodedField, t Theme) string {
+	var sb strings.Builder
+	width := tagWidth(fields)
+
+	for _, f := range fields {
+		sb.WriteString(fmt.Sprintf("    %s (%s): %s",
+			t.Paint(t.Tag, fmt.Sprintf("%*d", width, f.Tag)),
+			t.Paint(t.FieldName, f.Name),
+			t.Paint(t.Value, f.Value),
 		))
 
-		if desc != "" {
-			sb.WriteString(fmt.Sprintf(" (%s%s%s)", ColourEnum, desc, ColourReset))
+		if f.Description != "" {
+			sb.WriteString(fmt.Sprintf(" (%s)", t.Paint(t.Enum, f.Description)))
 		}
 
 		sb.WriteString("\n")
@@ -96,13 +85,16 @@ func Prettify(msg string, dict *FixTagLookup) string {
 	return sb.String()
 }
 
-func PrettifyFiles(paths []string, out io.Writer, errOut io.Writer, obfuscator *fix.Obfuscator) int {
+// PrettifyFiles decodes each path (or stdin for "-") to out. Colours come
+// from the themes attached to out and errOut (see WithTheme).
+func PrettifyFiles(paths []string, out io.Writer, errOut io.Writer, opts LogOptions) int {
 	hadError := false
+	t, et := themeOf(out), themeOf(errOut)
 
 	// If no paths at all, default to stdin (unchanged behaviour)
 	if len(paths) == 0 {
-		if err := streamLogFunc(os.Stdin, out, errOut, obfuscator); err != nil {
-			fmt.Fprintln(errOut, ColourError+"Error reading input:"+err.Error()+ColourReset)
+		if err := streamLogFunc("(stdin)", os.Stdin, out, errOut, opts); err != nil {
+			fmt.Fprintln(errOut, et.Paint(et.Error, "Error reading input:"+err.Error()))
 			return 1
 		}
 
@@ -112,37 +104,24 @@ func PrettifyFiles(paths []string, out io.Writer, errOut io.Writer, obfuscator *
 	// Otherwise, iterate over every supplied path.
 	// Treat the single dash "-" as a synonym for stdin.
 	for _, path := range paths {
-		var (
-			r   io.Reader
-			c   io.Closer // nil when reading stdin
-			err error
-		)
-
 		if path == "-" {
 			fmt.Fprint(out, "Processing: (stdin)\n\n")
-			r = os.Stdin // read from pipe/tty
 		} else {
-			fmt.Fprint(out, "Processing: ", ColourFile, path, ColourReset, "\n\n")
-
-			var f *os.File
-			f, err = os.Open(path)
-			if err != nil {
-				fmt.Fprintln(errOut, ColourError+"Cannot open file:"+err.Error()+ColourReset)
-				hadError = true
-				continue
-			}
-
-			r, c = f, f // will close after streaming
+			fmt.Fprint(out, "Processing: ", t.Paint(t.File, path), "\n\n")
 		}
 
-		if err = streamLogFunc(r, out, errOut, obfuscator); err != nil {
-			fmt.Fprintln(errOut, ColourError+"Error reading file:"+err.Error()+ColourReset)
+		name, r, ok := openInput(path, errOut)
+		if !ok {
 			hadError = true
+			continue
 		}
 
-		if c != nil {
-			c.Close()
+		if err := streamLogFunc(name, r, out, errOut, opts); err != nil {
+			fmt.Fprintln(errOut, et.Paint(et.Error, "Error reading file:"+err.Error()))
+			hadError = true
 		}
+
+		r.Close()
 	}
 
 	if hadError {
@@ -152,55 +131,111 @@ func PrettifyFiles(paths []string, out io.Writer, errOut io.Writer, obfuscator *
 	return 0
 }
 
-func streamLog(in io.Reader, out io.Writer, errOut io.Writer, obfuscator *fix.Obfuscator) error {
-	scanner := bufio.NewScanner(in)
+// streamLog decodes in, read from the input called name, line by line.
+func streamLog(name string, in io.Reader, out io.Writer, errOut io.Writer, opts LogOptions) error {
+	scanner := newLineScanner(in)
 	termWidth := getTerminalWidth()
-	separator := ColourTitle + strings.Repeat("=", termWidth) + ColourReset + "\n"
+	t := themeOf(out)
+	separator := t.Paint(t.Title, strings.Repeat("=", termWidth)) + "\n"
 
 	for scanner.Scan() {
-		line := obfuscator.Enabled(scanner.Text(), errOut)
-		handleLogLine(line, out, separator)
+		dl := handleLogLine(scanner.Text(), out, errOut, separator, opts)
+		opts.Report.AddLine(name, scanner.Offset(), dl)
 	}
 
 	return scanner.Err()
 }
 
-func handleLogLine(line string, out io.Writer, separator string) {
-	matches := findFixMessageIndices(line)
+func handleLogLine(line string, out io.Writer, errOut io.Writer, separator string, opts LogOptions) DecodedLine {
+	dl := DecodeLogLine(line, opts, errOut)
+	writeDecodedLine(dl, out, separator, opts)
+	return dl
+}
 
-	if len(matches) == 0 {
-		fmt.Fprint(out, ColourLine, line, ColourReset, "\n")
+func writeDecodedLine(dl DecodedLine, out io.Writer, separator string, opts LogOptions) {
+	t := themeOf(out)
+
+	if len(dl.Spans) == 0 {
+		fmt.Fprint(out, t.Paint(t.Line, dl.Text), "\n")
 		return
 	}
 
-	fixMessages, colouredLine := extractFixMessagesAndFormat(line, matches)
+	_, colouredLine := extractFixMessagesAndFormat(dl.Text, dl.Spans, t)
 	fmt.Fprint(out, colouredLine)
 	fmt.Fprint(out, separator)
 
-	for _, msg := range fixMessages {
-		processFixMessage(msg, out, separator)
+	for _, dm := range dl.Messages {
+		writeDecodedMessage(dm, out, separator, opts)
 	}
 }
 
-func processFixMessage(msg string, out io.Writer, separator string) {
-	dict := loadDictionary(msg)
-	fmt.Fprint(out, Prettify(msg, dict))
+// sanitiseLine obfuscates each FIX message span and scrubs the free text
+// around them, returning the rewritten line and the new message spans.
+func sanitiseLine(line string, matches [][]int, obfuscator *fix.Obfuscator, errOut io.Writer) (string, [][]int) {
+	if obfuscator == nil {
+		return line, matches
+	}
+
+	var (
+		sb        strings.Builder
+		lastIndex int
+		spans     = make([][]int, 0, len(matches))
+	)
+
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		sb.WriteString(obfuscator.ScrubText(line[lastIndex:start], errOut))
+
+		msgStart := sb.Len()
+		sb.WriteString(obfuscator.Enabled(line[start:end], errOut))
+		spans = append(spans, []int{msgStart, sb.Len()})
+		lastIndex = end
+	}
 
-	// Validation
-	if enableValidation {
-		errors := ValidateFixMessage(msg, dict)
-		if len(errors) > 0 {
-			fmt.Fprint(out, separator)
+	sb.WriteString(obfuscator.ScrubText(line[lastIndex:], errOut))
 
-			for _, err := range errors {
-				fmt.Fprintf(out, "%s== %s%s\n", ColourError, err, ColourReset)
-			}
+	return sb.String(), spans
+}
+
+func writeDecodedMessage(dm DecodedMessage, out io.Writer, separator string, opts LogOptions) {
+	t := themeOf(out)
+
+	if dm.Prefix != nil {
+		fmt.Fprintln(out, t.Paint(t.Line, "-- "+dm.Prefix.String()))
+	}
+
+	if opts.Wide {
+		fmt.Fprint(out, renderWideFields(dm.Fields, getTerminalWidth(), opts.Expand, t))
+	} else {
+		fmt.Fprint(out, renderDecodedFields(dm.Flat(), t))
+	}
+
+	if len(dm.Errors) > 0 {
+		fmt.Fprint(out, separator)
+
+		for _, err := range dm.Errors {
+			fmt.Fprintln(out, t.Paint(t.Error, "== "+err))
+		}
+
+		if opts.Rejects {
+			writeReject(dm, out, t)
 		}
 	}
 
 	fmt.Fprint(out, separator)
 }
 
+// writeReject prints the reply a counterparty would send, pipe-delimited
+// for pasting into test scripts.
+func writeReject(dm DecodedMessage, out io.Writer, t Theme) {
+	switch {
+	case dm.Reject != "":
+		fmt.Fprintln(out, t.Paint(t.Error, "== Reply: "+strings.ReplaceAll(dm.Reject, "\x01", "|")))
+	case IsGarbled(dm.Findings):
+		fmt.Fprintln(out, t.Paint(t.Error, "== No reply: a counterparty would discard the message as garbled"))
+	}
+}
+
 func getTerminalWidth() int {
 	if w, _, err := getTermSize(int(os.Stdout.Fd())); err == nil {
 		return w
@@ -208,12 +243,15 @@ func getTerminalWidth() int {
 	return 80
 }
 
+// fixMessagePattern frames SOH- or pipe-delimited messages from BeginString
+// up to and including the CheckSum field.
+var fixMessagePattern = regexp.MustCompile(`8=FIX.*?[\x01|]10=\d{3}[\x01|]`)
+
 func findFixMessageIndices(line string) [][]int {
-	re := regexp.MustCompile(`8=FIX.*?10=\d{3}\x01`)
-	return re.FindAllStringIndex(line, -1)
+	return fixMessagePattern.FindAllStringIndex(line, -1)
 }
 
-func extractFixMessagesAndFormat(line string, matches [][]int) ([]string, string) {
+func extractFixMessagesAndFormat(line string, matches [][]int, t Theme) ([]string, string) {
 	var (
 		output      strings.Builder
 		lastIndex   int
@@ -225,17 +263,13 @@ func extractFixMessagesAndFormat(line string, matches [][]int) ([]string, string
 		before := line[lastIndex:start]
 		fixPart := line[start:end]
 
-		output.WriteString(ColourLine + before + ColourMsg + fixPart)
+		output.WriteString(t.Line + before + t.Message + fixPart)
 		fixMessages = append(fixMessages, fixPart)
 		lastIndex = end
 	}
 
 	// Append remaining part of the line after last FIX message
-	output.WriteString(ColourLine + line[lastIndex:] + ColourReset + "\n")
+	output.WriteString(t.Line + line[lastIndex:] + t.Reset + "\n")
 
 	return fixMessages, output.String()
 }
-
-func SetValidation(enabled bool) {
-	enableValidation = enabled
-}
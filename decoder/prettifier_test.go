@@ -27,6 +27,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -72,7 +73,7 @@ func TestStreamLogWithFixMatch(t *testing.T) {
 	in := strings.NewReader("INFO 8=FIX.4.4\x0135=A\x0110=123\x01 more")
 	var out bytes.Buffer
 
-	err := streamLog(in, &out, os.Stderr, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	err := streamLog("(stdin)", in, &out, os.Stderr, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
@@ -86,7 +87,7 @@ func TestStreamLogWithFixMatch(t *testing.T) {
 func TestStreamLogNoMatch(t *testing.T) {
 	in := strings.NewReader("Just a regular log line")
 	var out bytes.Buffer
-	err := streamLog(in, &out, os.Stderr, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	err := streamLog("(stdin)", in, &out, os.Stderr, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 
 	if err != nil {
 		t.Errorf("Expected no error, got: %v", err)
@@ -117,7 +118,7 @@ func TestPrettifyFilesStdin(t *testing.T) {
 	w.Close()
 
 	var out, errOut bytes.Buffer
-	code := PrettifyFiles([]string{}, &out, &errOut, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	code := PrettifyFiles([]string{}, &out, &errOut, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 
 	if code != 0 {
 		t.Errorf("Expected return code 0, got %d", code)
@@ -131,7 +132,7 @@ func TestPrettifyFilesStdin(t *testing.T) {
 func TestPrettifyFileslsInvalidPath(t *testing.T) {
 	var out, errOut bytes.Buffer
 
-	code := PrettifyFiles([]string{"/path/does/not/exist"}, &out, &errOut, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	code := PrettifyFiles([]string{"/path/does/not/exist"}, &out, &errOut, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 
 	if code != 1 {
 		t.Errorf("Expected return code 1 on error, got %d", code)
@@ -153,7 +154,7 @@ func TestPrettifyFilesErrorReadingStdin(t *testing.T) {
 	// Force error from streamLogFunc
 	original := streamLogFunc
 
-	streamLogFunc = func(in io.Reader, out io.Writer, errOut io.Writer, obfuscator *fix.Obfuscator) error {
+	streamLogFunc = func(_ string, in io.Reader, out io.Writer, errOut io.Writer, opts LogOptions) error {
 		return errors.New("mocked streamLog error")
 	}
 
@@ -161,7 +162,7 @@ func TestPrettifyFilesErrorReadingStdin(t *testing.T) {
 
 	var out, errOut bytes.Buffer
 
-	code := PrettifyFiles([]string{}, &out, &errOut, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	code := PrettifyFiles([]string{}, &out, &errOut, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 
 	if code != 1 {
 		t.Errorf("Expected exit code 1, got %d", code)
@@ -192,7 +193,7 @@ func TestPrettifyFilesReadFromDash(t *testing.T) {
 		return []FieldValue{{Tag: 35, Value: "A"}}
 	}
 
-	code := PrettifyFiles([]string{"-"}, &out, &errOut, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	code := PrettifyFiles([]string{"-"}, &out, &errOut, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 	if code != 0 {
 		t.Errorf("Expected code 0, got %d", code)
 	}
@@ -209,14 +210,14 @@ func TestPrettifyFilesStreamLogErrorOnFile(t *testing.T) {
 
 	// Override streamLog to force an error
 	original := streamLogFunc
-	streamLogFunc = func(r io.Reader, w io.Writer, errOut io.Writer, obfuscator *fix.Obfuscator) error {
+	streamLogFunc = func(_ string, r io.Reader, w io.Writer, errOut io.Writer, opts LogOptions) error {
 		return errors.New("mock error")
 	}
 
 	defer func() { streamLogFunc = original }()
 
 	var out, errOut bytes.Buffer
-	code := PrettifyFiles([]string{tmpFile.Name()}, &out, &errOut, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	code := PrettifyFiles([]string{tmpFile.Name()}, &out, &errOut, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 
 	if code != 1 {
 		t.Errorf("Expected error code 1, got %d", code)
@@ -247,7 +248,7 @@ func TestPrettifyFilesSuccessCase(t *testing.T) {
 		return []FieldValue{{Tag: 35, Value: "A"}}
 	}
 
-	code := PrettifyFiles([]string{tmpFile.Name()}, &out, &errOut, fix.CreateObfuscator(fix.SensitiveTagNames, false))
+	code := PrettifyFiles([]string{tmpFile.Name()}, &out, &errOut, LogOptions{Obfuscator: fix.CreateObfuscator(fix.SensitiveTagNames, false)})
 	if code != 0 {
 		t.Errorf("Expected return code 0, got %d", code)
 	}
@@ -257,10 +258,8 @@ func TestPrettifyFilesSuccessCase(t *testing.T) {
 	}
 }
 
-func TestProcessFixMessageValidationTriggered(t *testing.T) {
-	enableValidation = true
-	defer func() { enableValidation = false }()
-
+func TestPrettifyFilesValidationTriggered(t *testing.T) {
+	useRealDecoding(t)
 	dict := &FixTagLookup{
 		Messages: map[string]MessageDef{
 			"D": {
@@ -282,26 +281,25 @@ func TestProcessFixMessageValidationTriggered(t *testing.T) {
 			10: "STRING",
 		},
 	}
-
-	// Override loadDictionary to return our test dictionary
-	original := loadDictionary
-	loadDictionary = func(_ string) *FixTagLookup { return dict }
-	defer func() { loadDictionary = original }()
+	loadDictionary = func(string) *FixTagLookup { return dict }
 
 	// FIX message missing required tag 11 (ClOrdID)
-	msg := "8=FIX.4.4\x019=23\x0135=D\x0155=EUR/USD\x0110=123\x01"
-	var out bytes.Buffer
-	separator := "--\n"
-
-	processFixMessage(msg, &out, separator)
+	path := filepath.Join(t.TempDir(), "orders.log")
+	if err := os.WriteFile(path, []byte("8=FIX.4.4\x019=23\x0135=D\x0155=EUR/USD\x0110=123\x01\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
 
-	output := out.String()
+	var out, errOut bytes.Buffer
+	PrettifyFiles([]string{path}, &out, &errOut, LogOptions{DecodeOptions: DecodeOptions{Validate: true}})
 
-	if !strings.Contains(output, separator) {
-		t.Errorf("Expected separator to be printed:\n%s", output)
+	if !strings.Contains(out.String(), "== Missing required tag 11 (ClOrdID)") {
+		t.Errorf("Expected validation error in output:\n%s", out.String())
 	}
-	if !strings.Contains(output, "== Missing required tag 11 (ClOrdID)") {
-		t.Errorf("Expected validation error in output:\n%s", output)
+
+	out.Reset()
+	PrettifyFiles([]string{path}, &out, &errOut, LogOptions{})
+	if strings.Contains(out.String(), "== Missing") {
+		t.Errorf("Expected no validation without Validate:\n%s", out.String())
 	}
 }
 
@@ -318,3 +316,29 @@ func TestGetTerminalWidthFirstBranch(t *testing.T) {
 		t.Errorf("Expected width 123, got %d", width)
 	}
 }
+
+func TestSanitiseLineOnlyObfuscatesFixSpans(t *testing.T) {
+	obfuscator := fix.CreateObfuscator(map[int]string{49: "SenderCompID"}, true)
+	obfuscator.SetScrubber(fix.CreateScrubber(fix.DefaultScrubRules()))
+
+	line := "49=PREFIX from 10.0.0.1 8=FIX.4.4|49=ABC|10=000| tail"
+	got, spans := sanitiseLine(line, findFixMessageIndices(line), obfuscator, io.Discard)
+
+	want := "49=PREFIX from IP0001 8=FIX.4.4|49=SenderCompID0001|10=000| tail"
+	if got != want {
+		t.Fatalf("sanitiseLine:\n got: %q\nwant: %q", got, want)
+	}
+
+	if len(spans) != 1 || got[spans[0][0]:spans[0][1]] != "8=FIX.4.4|49=SenderCompID0001|10=000|" {
+		t.Fatalf("unexpected spans %v for %q", spans, got)
+	}
+}
+
+func TestFindFixMessageIndicesPipeDelimited(t *testing.T) {
+	line := "IN 8=FIX.4.2|35=0|10=123| OUT 8=FIX.4.2\x0135=0\x0110=123\x01"
+	matches := findFixMessageIndices(line)
+
+	if len(matches) != 2 {
+		t.Fatalf("expected two messages, got %v", matches)
+	}
+}
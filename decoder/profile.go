@@ -0,0 +1,270 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Profile restricts the dictionary to one counterparty's rules of
+// engagement. It applies to messages whose SenderCompID or TargetCompID is
+// one of CompIDs, or to every message when forced by NewProfileSet.
+type Profile struct {
+	Name      string
+	CompIDs   []string
+	MsgTypes  []string            // allowed MsgTypes; empty allows all
+	Enums     map[string][]string // field name or tag → allowed values
+	Required  map[string][]string // MsgType or "*" → extra required fields
+	Forbidden map[string][]string // MsgType or "*" → forbidden fields
+	Rules     []Rule
+}
+
+// ProfileSet holds the profiles validation selects from by CompID.
+type ProfileSet struct {
+	list   []Profile
+	forced *Profile
+}
+
+// NewProfileSet returns the profiles in list. A non-empty forced names the
+// profile to apply to every message instead of selecting by CompID.
+func NewProfileSet(list []Profile, forced string) (*ProfileSet, error) {
+	s := &ProfileSet{list: append([]Profile(nil), list...)}
+
+	if forced != "" {
+		i := slices.IndexFunc(s.list, func(p Profile) bool { return strings.EqualFold(p.Name, forced) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown profile %q", forced)
+		}
+		s.forced = &s.list[i]
+	}
+
+	return s, nil
+}
+
+// For returns the profile for a message with the given CompIDs, or nil
+// when none applies.
+func (s *ProfileSet) For(sender, target string) *Profile {
+	if s == nil {
+		return nil
+	}
+
+	if s.forced != nil {
+		return s.forced
+	}
+
+	for i, p := range s.list {
+		if slices.Contains(p.CompIDs, sender) || slices.Contains(p.CompIDs, target) {
+			return &s.list[i]
+		}
+	}
+
+	return nil
+}
+
+// LoadProfiles reads a profile file, or every *.profile file in a
+// directory in name order.
+func LoadProfiles(path string) ([]Profile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{path}
+	if info.IsDir() {
+		if paths, err = filepath.Glob(filepath.Join(path, "*.profile")); err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+	}
+
+	var list []Profile
+	for _, p := range paths {
+		profile, err := LoadProfile(p)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, profile)
+	}
+
+	return list, nil
+}
+
+// LoadProfile reads one profile. Each line is "key = value":
+//
+//	name = LSE                      (default: the file name without extension)
+//	sender = LSE, LSEUAT            (CompIDs, matched as sender or target)
+//	target = LSE
+//	msgtypes = D,F,G,8,9,0,1,2,3,4,5,A
+//	enum OrdType = 1,2
+//	required D,G = Account, OrderCapacity
+//	forbidden * = ExecInst
+//	rule LSE_STOP = D StopPx forbidden when OrdType == 1
+//
+// Fields are names or tags. Blank lines and '#' comments are ignored.
+func LoadProfile(path string) (Profile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	defer f.Close()
+
+	p, err := parseProfile(f)
+	if err != nil {
+		return Profile{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if p.Name == "" {
+		p.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return p, nil
+}
+
+func parseProfile(r io.Reader) (Profile, error) {
+	p := Profile{
+		Enums:     make(map[string][]string),
+		Required:  make(map[string][]string),
+		Forbidden: make(map[string][]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return Profile{}, fmt.Errorf("profile line %d: expected key = value", lineNo)
+		}
+
+		kind, arg, _ := strings.Cut(strings.TrimSpace(key), " ")
+		arg = strings.TrimSpace(arg)
+		val = strings.TrimSpace(val)
+
+		if err := p.set(strings.ToLower(kind), arg, val); err != nil {
+			return Profile{}, fmt.Errorf("profile line %d: %w", lineNo, err)
+		}
+	}
+
+	return p, scanner.Err()
+}
+
+func (p *Profile) set(kind, arg, val string) error {
+	switch kind {
+	case "name":
+		p.Name = val
+	case "sender", "target":
+		p.CompIDs = append(p.CompIDs, splitList(val)...)
+	case "msgtypes":
+		p.MsgTypes = append(p.MsgTypes, splitList(val)...)
+	case "enum", "required", "forbidden":
+		if arg == "" {
+			return fmt.Errorf("%s needs a field or MsgTypes before '='", kind)
+		}
+		target := map[string]map[string][]string{"enum": p.Enums, "required": p.Required, "forbidden": p.Forbidden}[kind]
+		for _, k := range splitList(arg) {
+			target[k] = append(target[k], splitList(val)...)
+		}
+	case "rule":
+		rule, err := ParseRule(arg + " = " + val)
+		if err != nil {
+			return err
+		}
+		p.Rules = append(p.Rules, rule)
+	default:
+		return fmt.Errorf("unknown key %q", kind)
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// validateProfile checks a message against the profile for its CompIDs,
+// prefixing each error with the profile name.
+func validateProfile(profiles *ProfileSet, fields []FieldValue, fieldMap map[int]string, dict *FixTagLookup) []Finding {
+	p := profiles.For(fieldMap[49], fieldMap[56])
+	if p == nil {
+		return nil
+	}
+
+	msgType := fieldMap[35]
+	var findings []Finding
+	report := func(tag int, rejectMsgType string, reason int, format string, args ...any) {
+		f := newFinding(CodeProfile, tag, "[%s] %s", p.Name, fmt.Sprintf(format, args...))
+		findings = append(findings, f.rejectWith(rejectMsgType, reason))
+	}
+
+	if len(p.MsgTypes) > 0 && !slices.Contains(p.MsgTypes, msgType) {
+		report(35, "j", BusinessRejectUnsupportedMsgType, "MsgType %s not allowed", msgType)
+	}
+
+	for _, name := range sortedNames(p.Enums, nil) {
+		tag, ok := ruleField(name, dict)
+		if !ok {
+			continue
+		}
+		for _, fv := range fields {
+			if fv.Tag == tag && !slices.Contains(p.Enums[name], fv.Value) {
+				report(tag, "3", RejectValueIncorrect, "Value '%s' not allowed for tag %d (%s)", fv.Value, tag, dict.GetFieldName(tag))
+			}
+		}
+	}
+
+	for _, name := range slices.Concat(p.Required["*"], p.Required[msgType]) {
+		if tag, ok := ruleField(name, dict); ok {
+			if _, present := fieldMap[tag]; !present {
+				report(tag, "3", RejectRequiredTagMissing, "Missing required tag %d (%s)", tag, dict.GetFieldName(tag))
+			}
+		}
+	}
+
+	for _, name := range slices.Concat(p.Forbidden["*"], p.Forbidden[msgType]) {
+		if tag, ok := ruleField(name, dict); ok {
+			if _, present := fieldMap[tag]; present {
+				report(tag, "3", RejectTagNotDefinedForMsgType, "Tag %d (%s) not allowed", tag, dict.GetFieldName(tag))
+			}
+		}
+	}
+
+	return append(findings, validateRules(p.Rules, msgType, fieldMap, dict)...)
+}
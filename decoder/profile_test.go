@@ -0,0 +1,183 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const venueProfile = `# Venue rules of engagement
+name = VENUE
+sender = VENUE, VENUEUAT
+target = VENUE
+msgtypes = D, F, G, 8, 0, A, 5
+enum OrdType = 1, 2
+enum 59 = 0
+required D,G = Account
+forbidden * = ExecInst
+rule VENUE_STOP = D StopPx forbidden when OrdType == 1
+`
+
+func writeProfile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadProfile(t *testing.T) {
+	p, err := LoadProfile(writeProfile(t, t.TempDir(), "venue.profile", venueProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Name != "VENUE" || !slices.Equal(p.CompIDs, []string{"VENUE", "VENUEUAT", "VENUE"}) || len(p.MsgTypes) != 7 {
+		t.Errorf("unexpected profile %+v", p)
+	}
+	if !reflect.DeepEqual(p.Enums, map[string][]string{"OrdType": {"1", "2"}, "59": {"0"}}) {
+		t.Errorf("unexpected enums %v", p.Enums)
+	}
+	if !slices.Equal(p.Required["D"], []string{"Account"}) || !slices.Equal(p.Required["G"], []string{"Account"}) || !slices.Equal(p.Forbidden["*"], []string{"ExecInst"}) {
+		t.Errorf("unexpected required/forbidden %v %v", p.Required, p.Forbidden)
+	}
+	if len(p.Rules) != 1 || p.Rules[0].ID != "VENUE_STOP" {
+		t.Errorf("unexpected rules %+v", p.Rules)
+	}
+
+	unnamed, err := LoadProfile(writeProfile(t, t.TempDir(), "lse.profile", "sender = LSE\n"))
+	if err != nil || unnamed.Name != "lse" {
+		t.Errorf("expected the name to default to the file name, got %q (%v)", unnamed.Name, err)
+	}
+}
+
+func TestLoadProfileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := map[string]string{
+		"no value":                         "profile line 1: expected key = value",
+		"colour = red":                     `unknown key "colour"`,
+		"# ok\nenum = 1,2":                 "profile line 2: enum needs a field",
+		"rule X = D Price required when (": "rule X",
+	}
+
+	for content, want := range cases {
+		_, err := LoadProfile(writeProfile(t, dir, "bad.profile", content))
+		if err == nil || !strings.Contains(err.Error(), want) || !strings.Contains(err.Error(), "bad.profile") {
+			t.Errorf("LoadProfile(%q) = %v, want error containing %q", content, err, want)
+		}
+	}
+
+	if _, err := LoadProfile(filepath.Join(dir, "missing.profile")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestLoadProfilesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeProfile(t, dir, "b.profile", "sender = B\n")
+	writeProfile(t, dir, "a.profile", "sender = A\n")
+	writeProfile(t, dir, "notes.txt", "not a profile")
+
+	list, err := LoadProfiles(dir)
+	if err != nil || len(list) != 2 || list[0].Name != "a" || list[1].Name != "b" {
+		t.Fatalf("unexpected profiles %+v (%v)", list, err)
+	}
+
+	single, err := LoadProfiles(filepath.Join(dir, "b.profile"))
+	if err != nil || len(single) != 1 {
+		t.Errorf("expected one profile from a file, got %+v (%v)", single, err)
+	}
+
+	writeProfile(t, dir, "c.profile", "bogus")
+	if _, err := LoadProfiles(dir); err == nil {
+		t.Error("expected an error for an invalid profile")
+	}
+	if _, err := LoadProfiles(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+}
+
+func TestValidateFixMessageProfile(t *testing.T) {
+	d := fix44Lookup(t)
+
+	p, err := parseProfile(strings.NewReader(venueProfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profiles, err := NewProfileSet([]Profile{p}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate := func(msg string) []string {
+		return findingTexts(ValidateFindings(msg, d, DecodeOptions{Profiles: profiles}))
+	}
+
+	order := sohMessage("8=FIX.4.4|9=0|35=D|49=CLIENT|56=VENUE|34=1|52=20240101-00:00:00|11=1|21=1|18=G|55=IBM|54=1|60=20240101-00:00:00|38=100|40=3|99=9|59=1|")
+	errs := validate(order)
+
+	for _, want := range []string{
+		"[VENUE] Value '3' not allowed for tag 40 (OrdType)",
+		"[VENUE] Value '1' not allowed for tag 59 (TimeInForce)",
+		"[VENUE] Missing required tag 1 (Account)",
+		"[VENUE] Tag 18 (ExecInst) not allowed",
+	} {
+		if !slices.Contains(errs, want) {
+			t.Errorf("expected %q in %v", want, errs)
+		}
+	}
+
+	market := sohMessage("8=FIX.4.4|9=0|35=D|49=VENUE|56=CLIENT|34=1|52=20240101-00:00:00|11=1|1=ACC|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=1|99=9|")
+	if errs := validate(market); !hasRuleError(errs, "VENUE_STOP") {
+		t.Errorf("expected the profile rule to fire, got %v", errs)
+	}
+
+	news := sohMessage("8=FIX.4.4|9=0|35=B|49=VENUE|56=CLIENT|34=1|52=20240101-00:00:00|148=Hi|33=0|")
+	if errs := validate(news); !slices.Contains(errs, "[VENUE] MsgType B not allowed") {
+		t.Errorf("expected the MsgType to be rejected, got %v", errs)
+	}
+
+	other := sohMessage("8=FIX.4.4|9=0|35=D|49=CLIENT|56=OTHER|34=1|52=20240101-00:00:00|11=1|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=3|99=9|")
+	for _, e := range validate(other) {
+		if strings.HasPrefix(e, "[VENUE]") {
+			t.Errorf("did not expect the profile for another counterparty, got %q", e)
+		}
+	}
+
+	if profiles, err = NewProfileSet([]Profile{p}, "venue"); err != nil {
+		t.Fatal(err)
+	}
+	if errs := validate(other); !slices.Contains(errs, "[VENUE] Value '3' not allowed for tag 40 (OrdType)") {
+		t.Errorf("expected the forced profile to apply, got %v", errs)
+	}
+
+	if _, err := NewProfileSet([]Profile{p}, "nope"); err == nil || !strings.Contains(err.Error(), `unknown profile "nope"`) {
+		t.Errorf("expected an unknown profile error, got %v", err)
+	}
+}
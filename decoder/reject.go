@@ -0,0 +1,141 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rejectClock = time.Now // SendingTime of generated rejects; tests override
+
+// garbledCodes are findings a FIX engine treats as a garbled message: it
+// discards the message without replying.
+var garbledCodes = []string{CodeChecksum, CodeMissingMsgType, CodeBodyLength, CodeHeaderOrder}
+
+// IsGarbled reports whether findings contain a reason for a counterparty
+// to discard the message unanswered. A missing BeginString or BodyLength
+// is one too.
+func IsGarbled(findings []Finding) bool {
+	return slices.ContainsFunc(findings, func(f Finding) bool {
+		return slices.Contains(garbledCodes, f.Code) || f.Code == CodeMissingTag && (f.Tag == 8 || f.Tag == 9)
+	})
+}
+
+// BuildReject returns the reply a compliant engine would send for msg given
+// its findings, SOH-delimited with BodyLength and CheckSum computed. A
+// session Reject (35=3) answers the first session-level problem; failing
+// that, a BusinessMessageReject (35=j) answers the first application-level
+// one. It returns false when the message would be discarded as garbled or
+// accepted.
+//
+// The reply keeps the BeginString, swaps the CompIDs and carries only the
+// fields the dictionary defines for it, so a FIX.4.0 Reject has no
+// RefTagID. MsgSeqNum is 1 as the replying engine's sequence is unknown.
+func BuildReject(msg string, findings []Finding, dict *FixTagLookup) (string, bool) {
+	if IsGarbled(findings) {
+		return "", false
+	}
+
+	f, ok := replyFinding(findings)
+	if !ok {
+		return "", false
+	}
+
+	rejectType := f.RejectMsgType
+	if _, ok := dict.Messages[rejectType]; !ok {
+		rejectType = "3" // FIX.4.0 and 4.1 have no BusinessMessageReject
+	}
+
+	orig := NewMessageFromFields(ParseFix(msg), dict)
+	get := func(tag int) string { v, _ := orig.Get(tag); return v }
+
+	fields := []FieldValue{
+		{Tag: 8, Value: get(8)},
+		{Tag: 35, Value: rejectType},
+	}
+	for _, swap := range [][2]int{{49, 56}, {56, 49}, {50, 57}, {57, 50}} {
+		if v := get(swap[1]); v != "" {
+			fields = append(fields, FieldValue{Tag: swap[0], Value: v})
+		}
+	}
+	fields = append(fields,
+		FieldValue{Tag: 34, Value: "1"},
+		FieldValue{Tag: 52, Value: rejectClock().UTC().Format("20060102-15:04:05.000")},
+	)
+
+	body := []FieldValue{{Tag: 45, Value: get(34)}}
+	if rejectType == "3" {
+		if f.Tag > 0 {
+			body = append(body, FieldValue{Tag: 371, Value: strconv.Itoa(f.Tag)})
+		}
+		body = append(body, FieldValue{Tag: 372, Value: get(35)})
+		if f.RejectMsgType == "3" {
+			body = append(body, FieldValue{Tag: 373, Value: strconv.Itoa(f.RejectReason)})
+		}
+	} else {
+		body = append(body,
+			FieldValue{Tag: 372, Value: get(35)},
+			FieldValue{Tag: 379, Value: get(11)},
+			FieldValue{Tag: 380, Value: strconv.Itoa(f.RejectReason)},
+		)
+	}
+	body = append(body, FieldValue{Tag: 58, Value: rejectText(f.Text)})
+
+	def := dict.Messages[rejectType]
+	for _, fv := range body {
+		if fv.Value == "" || !slices.Contains(def.FieldOrder, fv.Tag) {
+			continue
+		}
+		if enums, ok := dict.enumMap[fv.Tag]; ok {
+			if _, valid := enums[fv.Value]; !valid {
+				continue
+			}
+		}
+		fields = append(fields, fv)
+	}
+
+	return NewMessageFromFields(fields, dict).Encode(), true
+}
+
+// replyFinding picks the finding a reply answers: session problems take
+// precedence over application ones.
+func replyFinding(findings []Finding) (Finding, bool) {
+	for _, want := range []string{"3", "j"} {
+		for _, f := range findings {
+			if f.RejectMsgType == want {
+				return f, true
+			}
+		}
+	}
+	return Finding{}, false
+}
+
+// rejectText is the finding text for Text (58), without the reason suffix
+// the reject already carries and without delimiters.
+func rejectText(text string) string {
+	text, _, _ = strings.Cut(text, " (SessionRejectReason ")
+	return strings.NewReplacer("\x01", " ", "|", " ").Replace(text)
+}
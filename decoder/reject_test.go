@@ -0,0 +1,176 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+const rejectOrder = "35=D|49=CLIENT|56=BROKER|34=7|52=20240101-00:00:00|11=ORD1|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=1|"
+
+func fixedRejectClock(t *testing.T) {
+	t.Helper()
+	orig := rejectClock
+	rejectClock = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	t.Cleanup(func() { rejectClock = orig })
+}
+
+func buildReject(t *testing.T, msg string, d *FixTagLookup, rules ...Rule) (string, bool) {
+	t.Helper()
+	reject, ok := BuildReject(msg, ValidateFindings(msg, d, DecodeOptions{Rules: rules}), d)
+	return strings.ReplaceAll(reject, "\x01", "|"), ok
+}
+
+// withChecksum replaces the CheckSum of msg with the correct one.
+func withChecksum(msg string) string {
+	msg = msg[:strings.LastIndex(msg, "\x0110=")+1]
+	return msg + fmt.Sprintf("10=%03d\x01", CalculateChecksum(msg+"10="))
+}
+
+func TestBuildRejectSessionReject(t *testing.T) {
+	d := fix44Lookup(t)
+	fixedRejectClock(t)
+
+	msg := framedFIX44(strings.Replace(rejectOrder, "54=1", "54=Z", 1))
+	reject, ok := buildReject(t, msg, d)
+	if !ok {
+		t.Fatal("expected a reject")
+	}
+
+	want := "35=3|49=BROKER|56=CLIENT|34=1|52=20240102-03:04:05.000|45=7|371=54|372=D|373=5|58=Invalid enum value 'Z' for tag 54|"
+	if !strings.HasPrefix(reject, "8=FIX.4.4|9=") || !strings.Contains(reject, want) {
+		t.Errorf("unexpected reject %s", reject)
+	}
+
+	soh := strings.ReplaceAll(reject, "|", "\x01")
+	if errs := ValidateFixMessage(soh, d); len(errs) != 0 {
+		t.Errorf("expected the reject itself to be valid, got %v", errs)
+	}
+}
+
+func TestBuildRejectPrefersSessionReject(t *testing.T) {
+	d := fix44Lookup(t)
+
+	rule, err := ParseRule("NEED_ACCOUNT = D Account required when Side == 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reject, _ := buildReject(t, framedFIX44(rejectOrder), d, rule)
+	if !strings.Contains(reject, "|35=j|") || !strings.Contains(reject, "|379=ORD1|380=5|") {
+		t.Errorf("expected a BusinessMessageReject, got %s", reject)
+	}
+
+	reject, _ = buildReject(t, framedFIX44(strings.Replace(rejectOrder, "38=100", "38=abc", 1)), d, rule)
+	if !strings.Contains(reject, "|35=3|") || !strings.Contains(reject, "|371=38|372=D|373=6|") {
+		t.Errorf("expected a session Reject, got %s", reject)
+	}
+}
+
+func TestBuildRejectNoReply(t *testing.T) {
+	d := fix44Lookup(t)
+
+	if _, ok := buildReject(t, framedFIX44(rejectOrder), d); ok {
+		t.Error("expected no reject for a valid message")
+	}
+
+	garbled := strings.Replace(framedFIX44(rejectOrder), "\x0110=", "\x0110=0", 1)
+	if _, ok := buildReject(t, garbled, d); ok {
+		t.Error("expected no reject for a garbled message")
+	}
+
+	invalid := strings.Replace(rejectOrder, "54=1", "54=Z", 1)
+	for name, msg := range map[string]string{
+		"body length":  withChecksum(strings.Replace(framedFIX44(invalid), "\x019=", "\x019=1", 1)),
+		"header order": framedFIX44(strings.Replace(invalid, "35=D|49=CLIENT|", "49=CLIENT|35=D|", 1)),
+	} {
+		if reject, ok := buildReject(t, msg, d); ok {
+			t.Errorf("%s: expected no reject for a garbled message, got %s", name, reject)
+		}
+	}
+}
+
+func TestBuildRejectFIX40(t *testing.T) {
+	useRealDecoding(t)
+
+	msg := strings.Replace(framedFIX44(strings.Replace(rejectOrder, "54=1", "54=Z", 1)), "FIX.4.4", "FIX.4.0", 1)
+	d := LoadDictionary(msg)
+	msg = NewMessageFromFields(ParseFix(msg), d).Encode()
+
+	reject, ok := buildReject(t, msg, d)
+	if !ok {
+		t.Fatal("expected a reject")
+	}
+	if !strings.HasPrefix(reject, "8=FIX.4.0|") || !strings.Contains(reject, "|35=3|") || strings.Contains(reject, "|371=") {
+		t.Errorf("expected a FIX.4.0 Reject without RefTagID, got %s", reject)
+	}
+}
+
+func TestDecodeLogLineObfuscatesReject(t *testing.T) {
+	useRealDecoding(t)
+	fixedRejectClock(t)
+
+	opts := LogOptions{
+		DecodeOptions: DecodeOptions{Validate: true, Rejects: true},
+		Obfuscator:    fix.CreateObfuscator(fix.SensitiveTagNames, true),
+	}
+	line := "IN " + framedFIX44(strings.Replace(rejectOrder, "54=1", "54=Z", 1))
+	reject := DecodeLogLine(line, opts, io.Discard).Messages[0].Reject
+
+	if reject == "" || strings.Contains(reject, "CLIENT") {
+		t.Fatalf("expected an obfuscated reply, got %q", reject)
+	}
+
+	d := fix44Lookup(t)
+	for _, code := range []string{CodeBodyLength, CodeChecksum} {
+		if f, ok := findingFor(ValidateFindings(reject, d, DecodeOptions{}), code); ok {
+			t.Errorf("expected the obfuscated reply to be framed again, got %+v", f)
+		}
+	}
+}
+
+func TestWriteDecodedMessageShowsReject(t *testing.T) {
+	useRealDecoding(t)
+	fixedRejectClock(t)
+
+	opts := LogOptions{DecodeOptions: DecodeOptions{Validate: true, Rejects: true}}
+
+	var out bytes.Buffer
+	writeDecodedMessage(decodeFixMessage(framedFIX44(strings.Replace(rejectOrder, "54=1", "54=Z", 1)), opts.DecodeOptions), &out, "\n", opts)
+	if !strings.Contains(out.String(), "== Reply: 8=FIX.4.4|") {
+		t.Errorf("expected the reply, got %q", out.String())
+	}
+
+	out.Reset()
+	writeDecodedMessage(decodeFixMessage(strings.Replace(framedFIX44(rejectOrder), "\x0110=", "\x0110=0", 1), opts.DecodeOptions), &out, "\n", opts)
+	if !strings.Contains(out.String(), "== No reply: a counterparty would discard the message as garbled") {
+		t.Errorf("expected the garbled note, got %q", out.String())
+	}
+}
@@ -0,0 +1,249 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report collects the validation findings of a run, message by message,
+// and writes them as JSON or JUnit XML.
+type Report struct {
+	FailOn FailOn
+	files  []reportFile
+}
+
+type reportFile struct {
+	name     string
+	messages []reportMessage
+}
+
+type reportMessage struct {
+	index    int
+	offset   int64
+	msgType  string
+	findings []Finding
+}
+
+// NewReport returns an empty report that fails on the findings failOn
+// selects.
+func NewReport(failOn FailOn) *Report {
+	return &Report{FailOn: failOn}
+}
+
+// AddLine records the messages of dl, a line starting lineOffset bytes
+// into file. Lines of several files may be interleaved, as when merging.
+func (r *Report) AddLine(file string, lineOffset int64, dl DecodedLine) {
+	if r == nil {
+		return
+	}
+
+	rf := r.file(file)
+
+	for i, dm := range dl.Messages {
+		msg := reportMessage{
+			index:   len(rf.messages) + 1,
+			offset:  lineOffset + int64(dl.Offsets[i]),
+			msgType: dm.MsgType,
+		}
+
+		for _, f := range dm.Findings {
+			f.File, f.Index, f.Offset, f.MsgType = file, msg.index, msg.offset, dm.MsgType
+			msg.findings = append(msg.findings, f)
+		}
+
+		rf.messages = append(rf.messages, msg)
+	}
+}
+
+// file returns the entry for name, adding it on first use.
+func (r *Report) file(name string) *reportFile {
+	for i := range r.files {
+		if r.files[i].name == name {
+			return &r.files[i]
+		}
+	}
+
+	r.files = append(r.files, reportFile{name: name})
+	return &r.files[len(r.files)-1]
+}
+
+// Messages returns the number of messages recorded.
+func (r *Report) Messages() int {
+	n := 0
+	for _, rf := range r.files {
+		n += len(rf.messages)
+	}
+	return n
+}
+
+// Findings returns every finding in input order, file by file.
+func (r *Report) Findings() []Finding {
+	var out []Finding
+	for _, rf := range r.files {
+		for _, m := range rf.messages {
+			out = append(out, m.findings...)
+		}
+	}
+	return out
+}
+
+// Failing returns the findings that fail the run.
+func (r *Report) Failing() []Finding {
+	var out []Finding
+	for _, f := range r.Findings() {
+		if r.FailOn.Fails(f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// Write writes the report in format, "json" or "junit".
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "", "json":
+		return r.WriteJSON(w)
+	case "junit":
+		return r.WriteJUnit(w)
+	default:
+		return fmt.Errorf("unknown report format %q", format)
+	}
+}
+
+type jsonFinding struct {
+	Finding
+	Fails bool `json:"fails"`
+}
+
+type jsonReport struct {
+	Messages int           `json:"messages"`
+	Failing  int           `json:"failing"`
+	Findings []jsonFinding `json:"findings"`
+}
+
+// WriteJSON writes a summary and every finding, each marked with whether
+// it fails the run.
+func (r *Report) WriteJSON(w io.Writer) error {
+	out := jsonReport{Messages: r.Messages(), Findings: []jsonFinding{}}
+
+	for _, f := range r.Findings() {
+		fails := r.FailOn.Fails(f)
+		if fails {
+			out.Failing++
+		}
+		out.Findings = append(out.Findings, jsonFinding{Finding: f, Fails: fails})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes one test suite per input and one test case per
+// message. A message fails when it has failing findings; the others are
+// listed in the test case output.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suites := junitSuites{Name: "fixdecoder"}
+
+	for _, rf := range r.files {
+		suite := junitSuite{Name: rf.name}
+
+		for _, m := range rf.messages {
+			tc := junitCase{
+				ClassName: rf.name,
+				Name:      fmt.Sprintf("message %d (MsgType %s) at offset %d", m.index, m.msgType, m.offset),
+			}
+
+			var failing, other []string
+			for _, f := range m.findings {
+				line := fmt.Sprintf("%s %s: %s", f.Code, f.Severity, f.Text)
+				if !r.FailOn.Fails(f) {
+					other = append(other, line)
+					continue
+				}
+				if tc.Failure == nil {
+					tc.Failure = &junitFailure{Message: f.Text, Type: f.Code}
+				}
+				failing = append(failing, line)
+			}
+
+			if tc.Failure != nil {
+				tc.Failure.Text = strings.Join(failing, "\n")
+				suite.Failures++
+			}
+			tc.SystemOut = strings.Join(other, "\n")
+
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
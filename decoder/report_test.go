@@ -0,0 +1,146 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+// useReport returns options that validate and collect findings into a
+// fresh report.
+func useReport(t *testing.T, failOn FailOn) (*Report, LogOptions) {
+	t.Helper()
+	useRealDecoding(t)
+
+	r := NewReport(failOn)
+	return r, LogOptions{DecodeOptions: DecodeOptions{Validate: true}, Obfuscator: fix.CreateObfuscator(nil, false), Report: r}
+}
+
+func TestStreamLogRecordsFindingOffsets(t *testing.T) {
+	r, opts := useReport(t, FailOn{"error": true})
+
+	heartbeat := "8=FIX.4.4|9=5|35=0|10=000|"
+	log := "noise\r\nIN " + heartbeat + " OUT " + heartbeat + "\n" + heartbeat
+	if err := streamLog("app.log", strings.NewReader(log), io.Discard, io.Discard, opts); err != nil {
+		t.Fatalf("streamLog failed: %v", err)
+	}
+
+	if r.Messages() != 3 {
+		t.Fatalf("expected 3 messages, got %d", r.Messages())
+	}
+
+	wantOffsets := map[int]int64{
+		1: int64(len("noise\r\nIN ")),
+		2: int64(len("noise\r\nIN " + heartbeat + " OUT ")),
+		3: int64(len("noise\r\nIN " + heartbeat + " OUT " + heartbeat + "\n")),
+	}
+
+	findings := r.Findings()
+	if len(findings) == 0 {
+		t.Fatal("expected findings for the bad checksums")
+	}
+	for _, f := range findings {
+		if f.File != "app.log" || f.MsgType != "0" || f.Offset != wantOffsets[f.Index] {
+			t.Errorf("unexpected location %+v", f)
+		}
+		if !strings.HasPrefix(log[f.Offset:], "8=FIX.4.4") {
+			t.Errorf("offset %d does not point at a message", f.Offset)
+		}
+	}
+
+	if len(r.Failing()) != len(findings) {
+		t.Errorf("expected every error to fail, got %d of %d", len(r.Failing()), len(findings))
+	}
+}
+
+func TestReportWriteJSON(t *testing.T) {
+	r, opts := useReport(t, FailOn{CodeChecksum: true})
+	r.AddLine("a.log", 10, DecodeLogLine("IN 8=FIX.4.4|9=6|35=0|10=000|", opts, io.Discard))
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, "json"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var got struct {
+		Messages int
+		Failing  int
+		Findings []struct {
+			File   string
+			Index  int
+			Offset int64
+			Code   string
+			Tag    int
+			Fails  bool
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+
+	if got.Messages != 1 || got.Failing != 1 || len(got.Findings) < 2 {
+		t.Fatalf("unexpected report %s", buf.String())
+	}
+	for _, f := range got.Findings {
+		if f.File != "a.log" || f.Index != 1 || f.Offset != 13 || f.Fails != (f.Code == CodeChecksum) {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
+
+func TestReportWriteJUnit(t *testing.T) {
+	r, opts := useReport(t, FailOn{"error": true})
+	r.AddLine("a.log", 0, DecodeLogLine("IN 8=FIX.4.4|9=5|35=0|10=000|", opts, io.Discard))
+	r.AddLine("b.log", 0, DecodeLogLine("IN "+framedFIX44("35=0|49=A|56=B|34=1|52=20240101-00:00:00|"), opts, io.Discard))
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf, "junit"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	var got junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+
+	if got.Tests != 2 || got.Failures != 1 || len(got.Suites) != 2 {
+		t.Fatalf("unexpected report %s", buf.String())
+	}
+	if tc := got.Suites[0].Cases[0]; tc.Failure == nil || !strings.Contains(tc.Failure.Text, "CHECKSUM error:") {
+		t.Errorf("expected a checksum failure, got %+v", tc)
+	}
+	if tc := got.Suites[1].Cases[0]; tc.Failure != nil || tc.Name != "message 1 (MsgType 0) at offset 3" {
+		t.Errorf("expected a passing test case, got %+v", tc)
+	}
+
+	if err := r.Write(&buf, "yaml"); err == nil {
+		t.Error("expected an unknown format error")
+	}
+}
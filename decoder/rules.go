@@ -0,0 +1,617 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// rulePacks holds the standard conditional requirements per FIX version.
+//
+//go:embed rules/*.rules
+var rulePacks embed.FS
+
+// Rule is a conditional requirement such as "Price is required when OrdType
+// is 2 or 4". Fields and values are resolved against the dictionary of the
+// message being validated; a rule naming a field the dictionary lacks is
+// skipped.
+type Rule struct {
+	ID       string
+	MsgTypes []string // empty applies to every message
+	Fields   []string // names or tags; required means at least one is present
+	Presence string   // "required" or "forbidden"
+	When     string   // the condition as written
+	cond     condition
+}
+
+// LoadRuleFile reads rules from path, one per line:
+//
+//	ID = MSGTYPES FIELD[|FIELD...] required|forbidden when CONDITION
+//
+// MSGTYPES is a comma list or "*". CONDITION uses the Orchestra Score
+// subset described by ParseRule. Blank lines and lines starting with '#'
+// are ignored.
+func LoadRuleFile(path string) ([]Rule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseRules(f)
+}
+
+func parseRules(r io.Reader) ([]Rule, error) {
+	var rules []Rule
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := ParseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("rules line %d: %w", lineNo, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, scanner.Err()
+}
+
+// ParseRule parses one rule line. The condition may combine
+//
+//	exists F, !exists F
+//	F == V, F != V, F < V, F <= V, F > V, F >= V, F in {V, ...}
+//
+// with &&, ||, ! and parentheses, where F is a field name or tag and V a
+// value, a quoted string or ^Name for the enum value whose description
+// matches Name (case and underscores ignored, so ^StopLimit is STOP_LIMIT).
+func ParseRule(line string) (Rule, error) {
+	id, spec, ok := strings.Cut(line, "=")
+	if !ok || strings.TrimSpace(id) == "" {
+		return Rule{}, fmt.Errorf("expected ID = MSGTYPES FIELDS PRESENCE when CONDITION")
+	}
+
+	spec, when, ok := strings.Cut(spec, " when ")
+	parts := strings.Fields(spec)
+	if !ok || len(parts) != 3 {
+		return Rule{}, fmt.Errorf("expected ID = MSGTYPES FIELDS PRESENCE when CONDITION")
+	}
+
+	rule := Rule{
+		ID:       strings.TrimSpace(id),
+		Fields:   strings.Split(parts[1], "|"),
+		Presence: strings.ToLower(parts[2]),
+		When:     strings.TrimSpace(when),
+	}
+
+	if parts[0] != "*" {
+		rule.MsgTypes = strings.Split(parts[0], ",")
+	}
+
+	if rule.Presence != "required" && rule.Presence != "forbidden" {
+		return Rule{}, fmt.Errorf("rule %s: presence must be required or forbidden, got %q", rule.ID, parts[2])
+	}
+
+	cond, err := parseCondition(rule.When)
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule %s: %w", rule.ID, err)
+	}
+	rule.cond = cond
+
+	return rule, nil
+}
+
+// standardRules returns the built-in pack for a dictionary version such as
+// "4.4"; every 5.0 service pack shares one.
+func standardRules(major, minor string) []Rule {
+	data, err := rulePacks.ReadFile("rules/fix" + major + minor + ".rules")
+	if err != nil {
+		return nil
+	}
+
+	rules, err := parseRules(strings.NewReader(string(data)))
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in rule pack FIX %s.%s: %v", major, minor, err))
+	}
+	return rules
+}
+
+// orchestraRules turns the presence rules on a message's field references,
+// including those of its components, into Rules for that message.
+func orchestraRules(dict FixDictionary) []Rule {
+	comps := make(map[string]Component, len(dict.Components))
+	for _, c := range dict.Components {
+		comps[c.Name] = c
+	}
+
+	var rules []Rule
+
+	var walk func(msgType string, fields []FieldRef, refs []ComponentRef, depth int)
+	walk = func(msgType string, fields []FieldRef, refs []ComponentRef, depth int) {
+		if depth > maxStructureDepth {
+			return
+		}
+
+		for _, f := range fields {
+			rules = append(rules, presenceRules(msgType, f)...)
+		}
+
+		for _, ref := range refs {
+			if c, ok := comps[ref.Name]; ok {
+				walk(msgType, c.Fields, c.Components, depth+1)
+			}
+		}
+	}
+
+	for _, m := range dict.Messages {
+		walk(m.MsgType, m.Fields, m.Components, 0)
+	}
+
+	return rules
+}
+
+// presenceRules turns the presence rules on field reference f of msgType
+// into Rules, skipping conditions outside the supported subset.
+func presenceRules(msgType string, f FieldRef) []Rule {
+	var rules []Rule
+
+	for _, pr := range f.Rules {
+		presence := strings.ToLower(pr.Presence)
+		if presence != "required" && presence != "forbidden" {
+			continue
+		}
+
+		id := pr.Name
+		if id == "" {
+			id = msgType + "." + f.Name
+		}
+
+		rule := Rule{ID: id, MsgTypes: []string{msgType}, Fields: []string{f.Name}, Presence: presence, When: strings.TrimSpace(pr.When)}
+		cond, err := parseCondition(rule.When)
+		if err != nil {
+			continue
+		}
+		rule.cond = cond
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// validateRules checks every rule that applies to msgType and reports each
+// violation prefixed with the rule ID.
+func validateRules(rules []Rule, msgType string, fieldMap map[int]string, dict *FixTagLookup) []Finding {
+	var findings []Finding
+
+	for _, r := range rules {
+		tags, violated := r.violated(msgType, fieldMap, dict)
+		switch {
+		case !violated:
+		case r.Presence == "required":
+			findings = append(findings, newFinding(CodeRule, tags[0], "[%s] Missing conditionally required tag %s when %s", r.ID, describeTags(tags, dict), r.When).
+				rejectWith("j", BusinessRejectConditionalFieldMissing))
+		default:
+			findings = append(findings, newFinding(CodeRule, tags[0], "[%s] Tag %s not allowed when %s", r.ID, describeTags(tags, dict), r.When).
+				rejectWith("j", BusinessRejectOther))
+		}
+	}
+
+	return findings
+}
+
+// violated reports whether a message of msgType with fieldMap breaks r,
+// along with the tags r names.
+func (r Rule) violated(msgType string, fieldMap map[int]string, dict *FixTagLookup) ([]int, bool) {
+	if !r.appliesTo(msgType) {
+		return nil, false
+	}
+
+	tags, ok := r.resolveFields(dict)
+	if !ok {
+		return nil, false
+	}
+
+	matched, ok := r.cond.eval(fieldMap, dict)
+	if !ok || !matched {
+		return nil, false
+	}
+
+	present := false
+	for _, tag := range tags {
+		if _, seen := fieldMap[tag]; seen {
+			present = true
+			break
+		}
+	}
+
+	return tags, present == (r.Presence == "forbidden")
+}
+
+func (r Rule) appliesTo(msgType string) bool {
+	if len(r.MsgTypes) == 0 {
+		return true
+	}
+	for _, mt := range r.MsgTypes {
+		if strings.TrimSpace(mt) == msgType {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Rule) resolveFields(dict *FixTagLookup) ([]int, bool) {
+	tags := make([]int, 0, len(r.Fields))
+	for _, f := range r.Fields {
+		tag, ok := ruleField(f, dict)
+		if !ok {
+			return nil, false
+		}
+		tags = append(tags, tag)
+	}
+	return tags, r.cond != nil
+}
+
+// ruleField maps a field name or tag to a tag the dictionary knows.
+func ruleField(name string, dict *FixTagLookup) (int, bool) {
+	name = strings.TrimSpace(name)
+	if tag, err := strconv.Atoi(name); err == nil {
+		_, ok := dict.tagToName[tag]
+		return tag, ok
+	}
+	tag, ok := dict.nameToTag[name]
+	return tag, ok
+}
+
+func describeTags(tags []int, dict *FixTagLookup) string {
+	parts := make([]string, len(tags))
+	for i, tag := range tags {
+		parts[i] = fmt.Sprintf("%d (%s)", tag, dict.GetFieldName(tag))
+	}
+	return strings.Join(parts, " or ")
+}
+
+// ---- Conditions ----
+
+// condition is a parsed rule condition. eval reports false for ok when the
+// condition names a field the dictionary lacks.
+type condition interface {
+	eval(fields map[int]string, dict *FixTagLookup) (result, ok bool)
+}
+
+type andCond struct{ left, right condition }
+
+type orCond struct{ left, right condition }
+
+type notCond struct{ inner condition }
+
+type existsCond struct{ field string }
+
+type compareCond struct {
+	field  string
+	op     string
+	values []string
+}
+
+func (c andCond) eval(fields map[int]string, dict *FixTagLookup) (bool, bool) {
+	l, ok := c.left.eval(fields, dict)
+	if !ok || !l {
+		return false, ok
+	}
+	return c.right.eval(fields, dict)
+}
+
+func (c orCond) eval(fields map[int]string, dict *FixTagLookup) (bool, bool) {
+	l, ok := c.left.eval(fields, dict)
+	if !ok || l {
+		return l, ok
+	}
+	return c.right.eval(fields, dict)
+}
+
+func (c notCond) eval(fields map[int]string, dict *FixTagLookup) (bool, bool) {
+	v, ok := c.inner.eval(fields, dict)
+	return !v, ok
+}
+
+func (c existsCond) eval(fields map[int]string, dict *FixTagLookup) (bool, bool) {
+	tag, ok := ruleField(c.field, dict)
+	if !ok {
+		return false, false
+	}
+	_, present := fields[tag]
+	return present, true
+}
+
+// eval compares the field's value; an absent field only satisfies "!=".
+func (c compareCond) eval(fields map[int]string, dict *FixTagLookup) (bool, bool) {
+	tag, ok := ruleField(c.field, dict)
+	if !ok {
+		return false, false
+	}
+
+	actual, present := fields[tag]
+	if !present {
+		return c.op == "!=", true
+	}
+
+	switch c.op {
+	case "==", "in":
+		for _, v := range c.values {
+			if valueMatches(actual, v, tag, dict) {
+				return true, true
+			}
+		}
+		return false, true
+	case "!=":
+		return !valueMatches(actual, c.values[0], tag, dict), true
+	default:
+		a, errA := strconv.ParseFloat(actual, 64)
+		b, errB := strconv.ParseFloat(c.values[0], 64)
+		if errA != nil || errB != nil {
+			return false, true
+		}
+		switch c.op {
+		case "<":
+			return a < b, true
+		case "<=":
+			return a <= b, true
+		case ">":
+			return a > b, true
+		default:
+			return a >= b, true
+		}
+	}
+}
+
+// valueMatches compares actual with a literal or, for ^Name, with the
+// description of actual's enum value.
+func valueMatches(actual, want string, tag int, dict *FixTagLookup) bool {
+	symbol, ok := strings.CutPrefix(want, "^")
+	if !ok {
+		return actual == want
+	}
+	return symbolKey(dict.enumMap[tag][actual]) == symbolKey(symbol)
+}
+
+func symbolKey(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+// conditionParser is a recursive-descent parser over the tokens of a
+// condition; && binds tighter than ||.
+type conditionParser struct {
+	tokens []string
+	pos    int
+}
+
+func parseCondition(s string) (condition, error) {
+	tokens, err := tokenizeCondition(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+
+	p := &conditionParser{tokens: tokens}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in condition", p.tokens[p.pos])
+	}
+	return c, nil
+}
+
+func (p *conditionParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *conditionParser) next() string {
+	t := p.peek()
+	if t != "" {
+		p.pos++
+	}
+	return t
+}
+
+func (p *conditionParser) parseOr() (condition, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orCond{left, right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseAnd() (condition, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andCond{left, right}
+	}
+	return left, nil
+}
+
+func (p *conditionParser) parseUnary() (condition, error) {
+	switch p.peek() {
+	case "!":
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notCond{inner}, nil
+	case "(":
+		p.next()
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing ) in condition")
+		}
+		return c, nil
+	case "exists":
+		p.next()
+		field := p.next()
+		if !isOperand(field) {
+			return nil, fmt.Errorf("exists needs a field")
+		}
+		return existsCond{field}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *conditionParser) parseComparison() (condition, error) {
+	field := p.next()
+	if !isOperand(field) {
+		return nil, fmt.Errorf("expected a field, got %q", field)
+	}
+
+	op := p.next()
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		value := p.next()
+		if !isOperand(value) {
+			return nil, fmt.Errorf("expected a value after %s", op)
+		}
+		return compareCond{field: field, op: op, values: []string{unquote(value)}}, nil
+	case "in":
+		if p.next() != "{" {
+			return nil, fmt.Errorf("expected { after in")
+		}
+		var values []string
+		for {
+			value := p.next()
+			if !isOperand(value) {
+				return nil, fmt.Errorf("expected a value in {...}")
+			}
+			values = append(values, unquote(value))
+
+			switch p.next() {
+			case ",":
+				continue
+			case "}":
+				return compareCond{field: field, op: "in", values: values}, nil
+			default:
+				return nil, fmt.Errorf("expected , or } in {...}")
+			}
+		}
+	default:
+		return nil, fmt.Errorf("expected an operator after %s, got %q", field, op)
+	}
+}
+
+func isOperand(t string) bool {
+	if t == "" {
+		return false
+	}
+	return !strings.ContainsAny(t[:1], "!=<>(){},&|")
+}
+
+func unquote(t string) string {
+	if len(t) >= 2 && t[0] == '"' && t[len(t)-1] == '"' {
+		return t[1 : len(t)-1]
+	}
+	return t
+}
+
+func tokenizeCondition(s string) ([]string, error) {
+	var tokens []string
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.HasPrefix(s[i:], "&&"), strings.HasPrefix(s[i:], "||"),
+			strings.HasPrefix(s[i:], "=="), strings.HasPrefix(s[i:], "!="),
+			strings.HasPrefix(s[i:], "<="), strings.HasPrefix(s[i:], ">="):
+			tokens = append(tokens, s[i:i+2])
+			i += 2
+		case strings.ContainsRune("!<>(){},", rune(c)):
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string in condition")
+			}
+			tokens = append(tokens, s[i:i+end+2])
+			i += end + 2
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t!=<>(){},&|\"", rune(s[j])) {
+				j++
+			}
+			if j == i {
+				return nil, fmt.Errorf("unexpected %q in condition", c)
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
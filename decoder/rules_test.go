@@ -0,0 +1,223 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func fix44Lookup(t *testing.T) *FixTagLookup {
+	t.Helper()
+	useRealDecoding(t)
+
+	d := LoadDictionary("8=FIX.4.4\x01")
+	if d == nil {
+		t.Fatal("no FIX 4.4 dictionary")
+	}
+	return d
+}
+
+// sohMessage turns a '|' delimited message into a FIX one.
+func sohMessage(s string) string {
+	return strings.ReplaceAll(s, "|", "\x01")
+}
+
+func hasRuleError(errs []string, id string) bool {
+	return slices.ContainsFunc(errs, func(e string) bool { return strings.HasPrefix(e, "["+id+"]") })
+}
+
+func TestParseRule(t *testing.T) {
+	r, err := ParseRule("GTD = D,G ExpireTime|ExpireDate required when TimeInForce == 6")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ID != "GTD" || !slices.Equal(r.MsgTypes, []string{"D", "G"}) || !slices.Equal(r.Fields, []string{"ExpireTime", "ExpireDate"}) ||
+		r.Presence != "required" || r.When != "TimeInForce == 6" {
+		t.Errorf("unexpected rule %+v", r)
+	}
+
+	if r, err := ParseRule("NO_TEXT = * 58 FORBIDDEN when exists 1"); err != nil || r.MsgTypes != nil || r.Presence != "forbidden" {
+		t.Errorf("unexpected rule %+v (%v)", r, err)
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	cases := map[string]string{
+		"no equals sign":                             "expected ID",
+		"X = D Price required":                       "expected ID",
+		"X = D Price optional when OrdType == 2":     "presence must be required or forbidden",
+		"X = D Price required when OrdType ==":       "expected a value",
+		"X = D Price required when OrdType in 2":     "expected { after in",
+		"X = D Price required when OrdType in {2,":   "expected a value in",
+		"X = D Price required when OrdType in {2 3}": "expected , or }",
+		"X = D Price required when (exists 40":       "missing )",
+		"X = D Price required when exists":           "exists needs a field",
+		"X = D Price required when OrdType 2":        "expected an operator",
+		"X = D Price required when == 2":             "expected a field",
+		`X = D Price required when Text == "a`:       "unterminated string",
+		"X = D Price required when exists 40 )":      "unexpected",
+		"X = D Price required when OrdType & 2":      "unexpected",
+	}
+
+	for line, want := range cases {
+		if _, err := ParseRule(line); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseRule(%q) = %v, want error containing %q", line, err, want)
+		}
+	}
+}
+
+func TestLoadRuleFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "venue.rules")
+	content := "# venue rules\n\nACCOUNT = D Account required when Side == 5\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadRuleFile(path)
+	if err != nil || len(rules) != 1 || rules[0].ID != "ACCOUNT" {
+		t.Fatalf("unexpected rules %+v (%v)", rules, err)
+	}
+
+	bad := filepath.Join(dir, "bad.rules")
+	if err := os.WriteFile(bad, []byte("# ok\nBROKEN\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRuleFile(bad); err == nil || !strings.Contains(err.Error(), "rules line 2") {
+		t.Errorf("expected a line number in the error, got %v", err)
+	}
+
+	if _, err := LoadRuleFile(filepath.Join(dir, "missing.rules")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	d := fix44Lookup(t)
+	fields := map[int]string{40: "4", 44: "10.5", 54: "1", 58: "hello world"}
+
+	cases := map[string]bool{
+		"exists Price":                 true,
+		"!exists StopPx":               true,
+		"OrdType == 4":                 true,
+		"40 != 4":                      false,
+		"StopPx != 1":                  true,
+		"StopPx == 1":                  false,
+		"OrdType in {2, 4}":            true,
+		"OrdType == ^StopLimit":        true,
+		"OrdType in {^Limit, ^Market}": false,
+		"Price > 10 && Price <= 10.5":  true,
+		"Price < 10 || Price >= 11":    false,
+		"Side == 2 || (Side == 1 && !exists StopPx)": true,
+		`Text == "hello world"`:                      true,
+		"Text > 1":                                   false,
+	}
+
+	for expr, want := range cases {
+		c, err := parseCondition(expr)
+		if err != nil {
+			t.Fatalf("parseCondition(%q): %v", expr, err)
+		}
+		got, ok := c.eval(fields, d)
+		if !ok || got != want {
+			t.Errorf("%q = %v (ok %v), want %v", expr, got, ok, want)
+		}
+	}
+
+	c, _ := parseCondition("NoSuchField == 1 || exists Price")
+	if _, ok := c.eval(fields, d); ok {
+		t.Error("expected an unknown field to make the condition unusable")
+	}
+}
+
+func TestStandardRulePacks(t *testing.T) {
+	for _, v := range [][2]string{{"4", "0"}, {"4", "1"}, {"4", "2"}, {"4", "3"}, {"4", "4"}, {"5", "0"}} {
+		if rules := standardRules(v[0], v[1]); len(rules) == 0 {
+			t.Errorf("expected a standard rule pack for FIX %s.%s", v[0], v[1])
+		}
+	}
+
+	if rules := standardRules("1", "1"); rules != nil {
+		t.Errorf("expected no rules for FIXT.1.1, got %d", len(rules))
+	}
+}
+
+func TestValidateFixMessageConditionalRules(t *testing.T) {
+	d := fix44Lookup(t)
+
+	limit := sohMessage("8=FIX.4.4|9=0|35=D|49=A|56=B|34=1|52=20240101-00:00:00|11=1|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=2|59=6|")
+	errs := ValidateFixMessage(limit, d)
+	if !hasRuleError(errs, "PRICE_FOR_LIMIT") || !hasRuleError(errs, "EXPIRY_FOR_GTD") {
+		t.Errorf("expected PRICE_FOR_LIMIT and EXPIRY_FOR_GTD, got %v", errs)
+	}
+	if !slices.Contains(errs, "[PRICE_FOR_LIMIT] Missing conditionally required tag 44 (Price) when OrdType in {2, 4}") {
+		t.Errorf("unexpected message in %v", errs)
+	}
+	if hasRuleError(errs, "STOPPX_FOR_STOP") {
+		t.Errorf("did not expect STOPPX_FOR_STOP, got %v", errs)
+	}
+
+	priced := sohMessage("8=FIX.4.4|9=0|35=D|49=A|56=B|34=1|52=20240101-00:00:00|11=1|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=2|44=10|59=6|432=20240102|")
+	if errs := ValidateFixMessage(priced, d); hasRuleError(errs, "PRICE_FOR_LIMIT") || hasRuleError(errs, "EXPIRY_FOR_GTD") {
+		t.Errorf("expected the rules to be satisfied, got %v", errs)
+	}
+}
+
+func TestValidateFindingsRules(t *testing.T) {
+	d := fix44Lookup(t)
+
+	rule, err := ParseRule("NO_PRICE_AT_MARKET = D Price forbidden when OrdType == ^Market")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	market := sohMessage("8=FIX.4.4|9=0|35=D|49=A|56=B|34=1|52=20240101-00:00:00|11=1|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=1|44=10|")
+	errs := findingTexts(ValidateFindings(market, d, DecodeOptions{Rules: []Rule{rule}}))
+	if !slices.Contains(errs, "[NO_PRICE_AT_MARKET] Tag 44 (Price) not allowed when OrdType == ^Market") {
+		t.Errorf("expected the custom rule to fire, got %v", errs)
+	}
+
+	if errs := ValidateFixMessage(market, d); hasRuleError(errs, "NO_PRICE_AT_MARKET") {
+		t.Errorf("expected the custom rule only when passed, got %v", errs)
+	}
+}
+
+func TestOrchestraPresenceRules(t *testing.T) {
+	useRealDecoding(t)
+
+	custom, err := NewCustomDictionary(orchestraXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := sohMessage("8=FIX.4.4|9=0|35=D|11=1|40=2|54=1|")
+	errs := ValidateFixMessage(msg, custom.Lookup(msg))
+	if !slices.Contains(errs, "[PriceForLimit] Missing conditionally required tag 44 (Price) when OrdType == ^Limit") {
+		t.Errorf("expected the Orchestra rule to fire, got %v", errs)
+	}
+}
@@ -52,8 +52,17 @@ type Value struct {
 }
 
 type FieldRef struct {
+	Name     string         `xml:"name,attr"`
+	Required string         `xml:"required,attr"`
+	Rules    []PresenceRule `xml:"rule"`
+}
+
+// PresenceRule is a conditional presence rule from an Orchestra
+// repository, e.g. presence "required" when "OrdType == ^Limit".
+type PresenceRule struct {
 	Name     string `xml:"name,attr"`
-	Required string `xml:"required,attr"`
+	Presence string `xml:"presence,attr"`
+	When     string `xml:"when"`
 }
 
 type Group struct {
@@ -62,6 +71,7 @@ type Group struct {
 	Fields     []FieldRef     `xml:"field"`
 	Groups     []Group        `xml:"group"`
 	Components []ComponentRef `xml:"component"`
+	Order      []MemberRef    `xml:"-"`
 }
 
 type Component struct {
@@ -69,6 +79,7 @@ type Component struct {
 	Fields     []FieldRef     `xml:"field"`
 	Groups     []Group        `xml:"group"`
 	Components []ComponentRef `xml:"component"`
+	Order      []MemberRef    `xml:"-"`
 }
 
 type ComponentRef struct {
@@ -83,6 +94,96 @@ type Message struct {
 	Fields     []FieldRef     `xml:"field"`
 	Groups     []Group        `xml:"group"`
 	Components []ComponentRef `xml:"component"`
+	Order      []MemberRef    `xml:"-"`
+}
+
+// MemberRef names one field, group or component of a message, component
+// or group. Order lists them as the dictionary declares them, which the
+// separate Fields, Groups and Components slices cannot show.
+type MemberRef struct {
+	Kind string // field, group or component
+	Name string
+}
+
+func (m *Message) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "name":
+			m.Name = a.Value
+		case "msgtype":
+			m.MsgType = a.Value
+		case "msgcat":
+			m.MsgCat = a.Value
+		}
+	}
+	return decodeMembers(d, &m.Fields, &m.Groups, &m.Components, &m.Order)
+}
+
+func (c *Component) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		if a.Name.Local == "name" {
+			c.Name = a.Value
+		}
+	}
+	return decodeMembers(d, &c.Fields, &c.Groups, &c.Components, &c.Order)
+}
+
+func (g *Group) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "name":
+			g.Name = a.Value
+		case "required":
+			g.Required = a.Value
+		}
+	}
+	return decodeMembers(d, &g.Fields, &g.Groups, &g.Components, &g.Order)
+}
+
+// decodeMembers reads the children of the element just started, up to its
+// end, keeping fields, groups and components and the order they come in.
+func decodeMembers(d *xml.Decoder, fields *[]FieldRef, groups *[]Group, comps *[]ComponentRef, order *[]MemberRef) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var name string
+
+			switch t.Name.Local {
+			case "field":
+				var f FieldRef
+				if err := d.DecodeElement(&f, &t); err != nil {
+					return err
+				}
+				*fields, name = append(*fields, f), f.Name
+			case "group":
+				var g Group
+				if err := d.DecodeElement(&g, &t); err != nil {
+					return err
+				}
+				*groups, name = append(*groups, g), g.Name
+			case "component":
+				var c ComponentRef
+				if err := d.DecodeElement(&c, &t); err != nil {
+					return err
+				}
+				*comps, name = append(*comps, c), c.Name
+			default:
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+
+			*order = append(*order, MemberRef{Kind: t.Name.Local, Name: name})
+		case xml.EndElement:
+			return nil
+		}
+	}
 }
 
 type FieldNode struct {
@@ -92,9 +193,11 @@ type FieldNode struct {
 
 type ComponentNode struct {
 	Name       string
+	Required   string // from the referencing message, component or group
 	Fields     []FieldNode
 	Components []ComponentNode
 	Groups     []GroupNode
+	Order      []MemberRef // declaration order of the members; empty when unknown
 }
 
 type GroupNode struct {
@@ -103,6 +206,7 @@ type GroupNode struct {
 	Fields     []FieldNode
 	Components []ComponentNode
 	Groups     []GroupNode
+	Order      []MemberRef
 }
 
 type MessageNode struct {
@@ -112,6 +216,7 @@ type MessageNode struct {
 	Fields     []FieldNode
 	Components []ComponentNode
 	Groups     []GroupNode
+	Order      []MemberRef
 }
 
 type SchemaTree struct {
@@ -181,11 +286,14 @@ func buildComponentNode(comp Component, fieldMap map[string]Field, compMap map[s
 	node := ComponentNode{
 		Name:   comp.Name,
 		Fields: buildFieldNodes(comp.Fields, fieldMap),
+		Order:  comp.Order,
 	}
 
 	for _, cref := range comp.Components {
 		if sub, ok := compMap[cref.Name]; ok {
-			node.Components = append(node.Components, buildComponentNode(sub, fieldMap, compMap))
+			child := buildComponentNode(sub, fieldMap, compMap)
+			child.Required = cref.Required
+			node.Components = append(node.Components, child)
 		}
 	}
 
@@ -201,11 +309,14 @@ func buildGroupNode(group Group, fieldMap map[string]Field, compMap map[string]C
 		Name:     group.Name,
 		Required: group.Required,
 		Fields:   buildFieldNodes(group.Fields, fieldMap),
+		Order:    group.Order,
 	}
 
 	for _, cref := range group.Components {
 		if sub, ok := compMap[cref.Name]; ok {
-			node.Components = append(node.Components, buildComponentNode(sub, fieldMap, compMap))
+			child := buildComponentNode(sub, fieldMap, compMap)
+			child.Required = cref.Required
+			node.Components = append(node.Components, child)
 		}
 	}
 
@@ -222,11 +333,14 @@ func buildMessageNode(msg Message, fieldMap map[string]Field, compMap map[string
 		MsgType: msg.MsgType,
 		MsgCat:  msg.MsgCat,
 		Fields:  buildFieldNodes(msg.Fields, fieldMap),
+		Order:   msg.Order,
 	}
 
 	for _, cref := range msg.Components {
 		if sub, ok := compMap[cref.Name]; ok {
-			mnode.Components = append(mnode.Components, buildComponentNode(sub, fieldMap, compMap))
+			child := buildComponentNode(sub, fieldMap, compMap)
+			child.Required = cref.Required
+			mnode.Components = append(mnode.Components, child)
 		}
 	}
 
@@ -23,9 +23,61 @@ code of your version.
 package decoder
 
 import (
+	"encoding/xml"
+	"reflect"
 	"testing"
 )
 
+func TestUnmarshalDictionaryKeepsMemberOrder(t *testing.T) {
+	const data = `<fix major="4" minor="4">
+ <header><field name="BeginString" required="Y"/></header>
+ <messages>
+  <message name="NewOrderSingle" msgtype="D" msgcat="app">
+   <field name="ClOrdID" required="Y"/>
+   <component name="Parties" required="N"/>
+   <field name="Account" required="N"/>
+   <group name="NoAllocs" required="N">
+    <component name="NestedParties" required="N"/>
+    <field name="AllocAccount" required="N"/>
+   </group>
+   <field name="Side" required="Y"/>
+  </message>
+ </messages>
+ <components>
+  <component name="Parties"><group name="NoPartyIDs" required="N"><field name="PartyID" required="N"/></group></component>
+ </components>
+</fix>`
+
+	var dict FixDictionary
+	if err := xml.Unmarshal([]byte(data), &dict); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := dict.Messages[0]
+	if msg.Name != "NewOrderSingle" || msg.MsgType != "D" || msg.MsgCat != "app" || len(msg.Fields) != 3 || msg.Fields[2].Required != "Y" {
+		t.Fatalf("unexpected message %+v", msg)
+	}
+
+	want := []MemberRef{{"field", "ClOrdID"}, {"component", "Parties"}, {"field", "Account"}, {"group", "NoAllocs"}, {"field", "Side"}}
+	if !reflect.DeepEqual(msg.Order, want) {
+		t.Errorf("message order: got %v, want %v", msg.Order, want)
+	}
+
+	if g := msg.Groups[0]; g.Required != "N" || !reflect.DeepEqual(g.Order, []MemberRef{{"component", "NestedParties"}, {"field", "AllocAccount"}}) {
+		t.Errorf("unexpected group %+v", g)
+	}
+	if c := dict.Components[0]; c.Name != "Parties" || !reflect.DeepEqual(c.Order, []MemberRef{{"group", "NoPartyIDs"}}) {
+		t.Errorf("unexpected component %+v", c)
+	}
+	if len(dict.Header.Fields) != 1 || dict.Header.Fields[0].Name != "BeginString" {
+		t.Errorf("unexpected header %+v", dict.Header)
+	}
+
+	if got := BuildSchema(dict).Messages["NewOrderSingle"].Order; !reflect.DeepEqual(got, want) {
+		t.Errorf("schema order: got %v, want %v", got, want)
+	}
+}
+
 func TestBuildSchemaEmptyDictionary(t *testing.T) {
 	d := FixDictionary{}
 	tree := BuildSchema(d)
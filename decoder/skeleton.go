@@ -0,0 +1,416 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SkeletonOptions controls WriteSkeleton.
+type SkeletonOptions struct {
+	Format   string // fix, json or yaml
+	Optional bool   // include optional fields, groups and components
+}
+
+type skeletonKind int
+
+const (
+	skeletonField skeletonKind = iota
+	skeletonComponent
+	skeletonGroup
+)
+
+// skeletonNode is one member of a message template. Components hold their
+// members; groups hold the members of their single instance.
+type skeletonNode struct {
+	kind     skeletonKind
+	tag      int
+	name     string
+	value    string
+	required bool
+	field    Field // the field, or the NumInGroup field of a group
+	children []skeletonNode
+}
+
+// skeletonPlaceholders are example values by FIX type; other types get the
+// field name in angle brackets.
+var skeletonPlaceholders = map[string]string{
+	"INT":          "1",
+	"LENGTH":       "1",
+	"SEQNUM":       "1",
+	"NUMINGROUP":   "1",
+	"DAYOFMONTH":   "1",
+	"QTY":          "100",
+	"PRICE":        "1.00",
+	"PRICEOFFSET":  "0.01",
+	"AMT":          "100.00",
+	"FLOAT":        "1.0",
+	"PERCENTAGE":   "0.5",
+	"CHAR":         "A",
+	"BOOLEAN":      "Y",
+	"UTCTIMESTAMP": "20250101-12:00:00.000",
+	"UTCTIMEONLY":  "12:00:00",
+	"UTCDATEONLY":  "20250101",
+	"UTCDATE":      "20250101",
+	"LOCALMKTDATE": "20250101",
+	"MONTHYEAR":    "202501",
+	"TZTIMEONLY":   "12:00:00Z",
+	"TZTIMESTAMP":  "20250101-12:00:00Z",
+	"CURRENCY":     "USD",
+	"COUNTRY":      "US",
+	"EXCHANGE":     "XNYS",
+	"LANGUAGE":     "en",
+}
+
+// placeholderValue is the first enum value of f, or an example for its type.
+func placeholderValue(f Field) string {
+	if len(f.Values) > 0 {
+		return f.Values[0].Enum
+	}
+	if v, ok := skeletonPlaceholders[strings.ToUpper(f.Type)]; ok {
+		return v
+	}
+	return "<" + f.Name + ">"
+}
+
+// beginString is the BeginString for messages of schema: FIXT.1.1 for the
+// transport dictionary and FIX 5.0 onwards.
+func beginString(schema SchemaTree) string {
+	version := strings.TrimPrefix(strings.TrimPrefix(schema.Version, "FIXT."), "FIX.")
+	if strings.HasPrefix(version, "1.") || strings.HasPrefix(version, "5.") {
+		return "FIXT.1.1"
+	}
+	return "FIX." + version
+}
+
+// applVerID is the ApplVerID (1128) for FIX 5.0 application messages, or
+// empty for earlier versions.
+func applVerID(schema SchemaTree) string {
+	if !strings.HasPrefix(strings.TrimPrefix(schema.Version, "FIX."), "5.") {
+		return ""
+	}
+
+	switch schema.ServicePack {
+	case "1":
+		return "8"
+	case "2":
+		return "9"
+	}
+	return "7"
+}
+
+// transportSchema is the FIXT.1.1 session dictionary, whose header and
+// trailer FIX 5.0 application dictionaries leave out.
+var transportSchema = sync.OnceValue(func() SchemaTree {
+	var dict FixDictionary
+	_ = xml.Unmarshal([]byte(chooseEmbeddedXML("T11")), &dict)
+	return BuildSchema(dict)
+})
+
+// headerAndTrailer returns the standard header and trailer for schema.
+func headerAndTrailer(schema SchemaTree) (ComponentNode, ComponentNode) {
+	header, trailer := schema.Components["Header"], schema.Components["Trailer"]
+
+	if len(header.Fields) == 0 && beginString(schema) == "FIXT.1.1" {
+		ts := transportSchema()
+		header, trailer = ts.Components["Header"], ts.Components["Trailer"]
+	}
+
+	return header, trailer
+}
+
+// skeletonSections builds the header, body and trailer templates of msg.
+// BodyLength and CheckSum are left out: they are computed on encoding.
+func skeletonSections(schema SchemaTree, msg MessageNode) [3][]skeletonNode {
+	header, trailer := headerAndTrailer(schema)
+
+	sections := [3][]skeletonNode{
+		skeletonMembers(schema, header.Fields, header.Components, header.Groups, header.Order),
+		skeletonMembers(schema, msg.Fields, msg.Components, msg.Groups, msg.Order),
+		skeletonMembers(schema, trailer.Fields, trailer.Components, trailer.Groups, trailer.Order),
+	}
+
+	for i, n := range sections[0] {
+		switch n.tag {
+		case 8:
+			sections[0][i].value = beginString(schema)
+		case 35:
+			sections[0][i].value = msg.MsgType
+		case 1128:
+			if v := applVerID(schema); v != "" {
+				sections[0][i].value, sections[0][i].required = v, true
+			}
+		}
+	}
+
+	for i := range sections {
+		sections[i] = dropComputed(sections[i])
+	}
+
+	return sections
+}
+
+func dropComputed(nodes []skeletonNode) []skeletonNode {
+	out := nodes[:0]
+	for _, n := range nodes {
+		if n.kind == skeletonField && (n.tag == 9 || n.tag == 10) {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// skeletonMembers builds the template of a message, component or group,
+// with its members in the order the dictionary declares them.
+func skeletonMembers(schema SchemaTree, fields []FieldNode, comps []ComponentNode, groups []GroupNode, order []MemberRef) []skeletonNode {
+	nodes := make([]skeletonNode, 0, len(fields)+len(comps)+len(groups))
+
+	for _, f := range fields {
+		nodes = append(nodes, skeletonNode{
+			kind:     skeletonField,
+			tag:      f.Field.Number,
+			name:     f.Field.Name,
+			value:    placeholderValue(f.Field),
+			required: f.Ref.Required == "Y",
+			field:    f.Field,
+		})
+	}
+
+	for _, c := range comps {
+		nodes = append(nodes, skeletonNode{
+			kind:     skeletonComponent,
+			name:     c.Name,
+			required: c.Required == "Y",
+			children: skeletonMembers(schema, c.Fields, c.Components, c.Groups, c.Order),
+		})
+	}
+
+	for _, g := range groups {
+		nodes = append(nodes, skeletonNode{
+			kind:     skeletonGroup,
+			tag:      schema.Fields[g.Name].Number,
+			name:     g.Name,
+			value:    "1",
+			required: g.Required == "Y",
+			field:    schema.Fields[g.Name],
+			children: skeletonMembers(schema, g.Fields, g.Components, g.Groups, g.Order),
+		})
+	}
+
+	return inDeclarationOrder(nodes, order)
+}
+
+var skeletonKinds = map[string]skeletonKind{"field": skeletonField, "component": skeletonComponent, "group": skeletonGroup}
+
+// inDeclarationOrder arranges nodes as order lists them. Nodes order does
+// not name, e.g. from a schema built without it, keep their place after.
+func inDeclarationOrder(nodes []skeletonNode, order []MemberRef) []skeletonNode {
+	out := make([]skeletonNode, 0, len(nodes))
+	used := make([]bool, len(nodes))
+
+	for _, ref := range order {
+		for i, n := range nodes {
+			if !used[i] && n.kind == skeletonKinds[ref.Kind] && n.name == ref.Name {
+				out = append(out, n)
+				used[i] = true
+				break
+			}
+		}
+	}
+
+	for i, n := range nodes {
+		if !used[i] {
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
+
+// WriteSkeleton writes a template of msg to w, with required fields given
+// placeholder values, components expanded and one instance of each group.
+// Optional members are included with opts.Optional; otherwise they are
+// left out, or commented out in YAML.
+func WriteSkeleton(w io.Writer, schema SchemaTree, msg MessageNode, opts SkeletonOptions) error {
+	sections := skeletonSections(schema, msg)
+
+	switch strings.ToLower(opts.Format) {
+	case "", "fix":
+		fmt.Fprintln(w, visibleSOH(encodeSkeleton(sections, opts.Optional)))
+	case "json":
+		writeSkeletonJSON(w, sections, opts.Optional)
+	case "yaml":
+		writeSkeletonYAML(w, msg, sections, opts.Optional)
+	default:
+		return fmt.Errorf("invalid skeleton format: %q (fix|json|yaml)", opts.Format)
+	}
+
+	return nil
+}
+
+func encodeSkeleton(sections [3][]skeletonNode, optional bool) string {
+	m := &FixMessage{}
+	for i, fm := range []**FieldMap{&m.Header, &m.Body, &m.Trailer} {
+		*fm = &FieldMap{Fields: skeletonFields(sections[i], optional)}
+	}
+	return m.Encode()
+}
+
+func skeletonFields(nodes []skeletonNode, optional bool) []MessageField {
+	var out []MessageField
+
+	for _, n := range nodes {
+		if !n.required && !optional {
+			continue
+		}
+
+		switch n.kind {
+		case skeletonComponent:
+			out = append(out, skeletonFields(n.children, optional)...)
+		case skeletonGroup:
+			out = append(out, MessageField{
+				FieldValue: FieldValue{Tag: n.tag, Value: n.value},
+				Groups:     []*FieldMap{{Fields: skeletonFields(n.children, optional)}},
+			})
+		default:
+			out = append(out, MessageField{FieldValue: FieldValue{Tag: n.tag, Value: n.value}})
+		}
+	}
+
+	return out
+}
+
+var skeletonSectionNames = [3]string{"Header", "Body", "Trailer"}
+
+func writeSkeletonJSON(w io.Writer, sections [3][]skeletonNode, optional bool) {
+	var sb strings.Builder
+
+	sb.WriteString("{")
+	for i, nodes := range sections {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n  " + strconv.Quote(skeletonSectionNames[i]) + ": ")
+		writeJSONObject(&sb, nodes, optional, "  ")
+	}
+	sb.WriteString("\n}\n")
+
+	fmt.Fprint(w, sb.String())
+}
+
+// writeJSONObject writes nodes as an object in schema order, with
+// components flattened and groups as one-element arrays.
+func writeJSONObject(sb *strings.Builder, nodes []skeletonNode, optional bool, indent string) {
+	var members []string
+	collectJSONMembers(&members, nodes, optional, indent+"  ")
+
+	if len(members) == 0 {
+		sb.WriteString("{}")
+		return
+	}
+
+	sb.WriteString("{\n" + strings.Join(members, ",\n") + "\n" + indent + "}")
+}
+
+func collectJSONMembers(members *[]string, nodes []skeletonNode, optional bool, indent string) {
+	for _, n := range nodes {
+		if !n.required && !optional {
+			continue
+		}
+
+		switch n.kind {
+		case skeletonComponent:
+			collectJSONMembers(members, n.children, optional, indent)
+		case skeletonGroup:
+			var sb strings.Builder
+			sb.WriteString(indent + strconv.Quote(n.name) + ": [\n" + indent + "  ")
+			writeJSONObject(&sb, n.children, optional, indent+"  ")
+			sb.WriteString("\n" + indent + "]")
+			*members = append(*members, sb.String())
+		default:
+			*members = append(*members, indent+strconv.Quote(n.name)+": "+strconv.Quote(n.value))
+		}
+	}
+}
+
+func writeSkeletonYAML(w io.Writer, msg MessageNode, sections [3][]skeletonNode, optional bool) {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# %s (MsgType %s)\n", msg.Name, msg.MsgType)
+	sb.WriteString("# BodyLength and CheckSum are computed when the message is encoded.\n")
+
+	for i, nodes := range sections {
+		sb.WriteString(skeletonSectionNames[i] + ":")
+		if len(nodes) == 0 {
+			sb.WriteString(" {}")
+		}
+		sb.WriteString("\n")
+		writeYAMLMembers(&sb, nodes, optional, "  ", false, false)
+	}
+
+	fmt.Fprint(w, sb.String())
+}
+
+// writeYAMLMembers writes nodes as YAML mappings. Optional members are
+// commented out unless optional is set. While first is set the next
+// uncommented key starts a sequence item and takes the "- " prefix; the
+// returned value says whether that is still pending.
+func writeYAMLMembers(sb *strings.Builder, nodes []skeletonNode, optional bool, indent string, commented, first bool) bool {
+	for _, n := range nodes {
+		c := commented || (!n.required && !optional)
+
+		if n.kind == skeletonComponent {
+			sb.WriteString(indent + "# " + n.name + " (component)\n")
+			first = writeYAMLMembers(sb, n.children, optional, indent, c, first)
+			continue
+		}
+
+		lead := indent
+		if c {
+			lead += "# "
+		}
+		if first && (!c || commented) {
+			lead = strings.Replace(lead, "  ", "", 1)
+			lead += "- "
+			first = false
+		}
+
+		if n.kind == skeletonGroup {
+			sb.WriteString(lead + n.name + ":\n")
+			if writeYAMLMembers(sb, n.children, optional, indent+"    ", c, true) {
+				sb.WriteString(indent + "  - {}\n")
+			}
+			continue
+		}
+
+		sb.WriteString(lead + n.name + ": " + strconv.Quote(n.value) + "\n")
+	}
+
+	return first
+}
@@ -0,0 +1,187 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+func embeddedSchema(t *testing.T, ver string) SchemaTree {
+	t.Helper()
+
+	var dict FixDictionary
+	if err := xml.Unmarshal([]byte(fix.ChooseEmbeddedXML(ver)), &dict); err != nil {
+		t.Fatal(err)
+	}
+	return BuildSchema(dict)
+}
+
+func TestWriteSkeletonFIXIsValid(t *testing.T) {
+	useRealDecoding(t)
+	schema := embeddedSchema(t, "44")
+
+	for _, optional := range []bool{false, true} {
+		var out bytes.Buffer
+		if err := WriteSkeleton(&out, schema, schema.Messages["NewOrderSingle"], SkeletonOptions{Format: "fix", Optional: optional}); err != nil {
+			t.Fatal(err)
+		}
+
+		msg := NormaliseDelimiters(strings.TrimSpace(out.String()))
+		if !strings.HasPrefix(msg, "8=FIX.4.4\x019=") || !strings.Contains(msg, "\x0135=D\x01") {
+			t.Fatalf("unexpected skeleton %q", out.String())
+		}
+
+		if errs := ValidateFixMessage(msg, LoadDictionary(msg)); len(errs) != 0 {
+			t.Errorf("optional=%v: expected a valid template, got %v", optional, errs)
+		}
+
+		if has := strings.Contains(msg, "\x01453=1\x01448="); has != optional {
+			t.Errorf("optional=%v: unexpected Parties group presence in %q", optional, msg)
+		}
+	}
+}
+
+func TestWriteSkeletonKeepsDictionaryOrder(t *testing.T) {
+	schema := embeddedSchema(t, "44")
+
+	var out bytes.Buffer
+	if err := WriteSkeleton(&out, schema, schema.Messages["NewOrderSingle"], SkeletonOptions{Format: "fix", Optional: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// FIX 4.4 declares the Parties component between ClOrdLinkID and
+	// TradeOriginationDate, and the NoAllocs group straight after AllocID.
+	for _, want := range []string{"|583=<ClOrdLinkID>|453=1|448=", "|70=<AllocID>|78=1|"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in %s", want, out.String())
+		}
+	}
+}
+
+func TestWriteSkeletonJSON(t *testing.T) {
+	schema := embeddedSchema(t, "44")
+
+	var out bytes.Buffer
+	if err := WriteSkeleton(&out, schema, schema.Messages["NewOrderSingle"], SkeletonOptions{Format: "json", Optional: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Header  map[string]any
+		Body    map[string]any
+		Trailer map[string]string
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+
+	parties, _ := doc.Body["NoPartyIDs"].([]any)
+	if doc.Header["MsgType"] != "D" || doc.Body["Side"] != "1" || doc.Body["Price"] != "1.00" || len(parties) != 1 {
+		t.Errorf("unexpected JSON skeleton %+v", doc)
+	}
+
+	if _, ok := doc.Header["BodyLength"]; ok {
+		t.Error("expected BodyLength to be left to the encoder")
+	}
+}
+
+func TestWriteSkeletonYAMLCommentsOptional(t *testing.T) {
+	schema := embeddedSchema(t, "44")
+
+	var out bytes.Buffer
+	if err := WriteSkeleton(&out, schema, schema.Messages["NewOrderSingle"], SkeletonOptions{Format: "yaml"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"# NewOrderSingle (MsgType D)\n",
+		"\n  ClOrdID: \"<ClOrdID>\"\n",
+		"\n  # Account: \"<Account>\"\n",
+		"\n  # Parties (component)\n  # NoPartyIDs:\n    # - PartyID: \"<PartyID>\"\n      # PartyIDSource: \"B\"\n",
+		"\nTrailer:\n  # SignatureLength: \"1\"\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestWriteSkeletonYAMLRequiredGroup(t *testing.T) {
+	schema := SchemaTree{
+		Fields: map[string]Field{
+			"NoLegs":    {Name: "NoLegs", Number: 555, Type: "NUMINGROUP"},
+			"LegSymbol": {Name: "LegSymbol", Number: 600, Type: "STRING"},
+			"LegSide":   {Name: "LegSide", Number: 624, Type: "CHAR"},
+		},
+	}
+	msg := MessageNode{Name: "Test", MsgType: "T", Groups: []GroupNode{{
+		Name:     "NoLegs",
+		Required: "Y",
+		Fields: []FieldNode{
+			{Ref: FieldRef{Name: "LegSymbol", Required: "N"}, Field: schema.Fields["LegSymbol"]},
+			{Ref: FieldRef{Name: "LegSide", Required: "Y"}, Field: schema.Fields["LegSide"]},
+		},
+	}}}
+
+	var out bytes.Buffer
+	if err := WriteSkeleton(&out, schema, msg, SkeletonOptions{Format: "yaml"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Body:\n  NoLegs:\n      # LegSymbol: \"<LegSymbol>\"\n    - LegSide: \"A\"\n"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("expected %q in:\n%s", want, out.String())
+	}
+}
+
+func TestWriteSkeletonInvalidFormat(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteSkeleton(&out, SchemaTree{}, MessageNode{}, SkeletonOptions{Format: "xml"}); err == nil {
+		t.Error("expected invalid format error")
+	}
+}
+
+func TestWriteSkeletonFIX50UsesTransportHeader(t *testing.T) {
+	useRealDecoding(t)
+	schema := embeddedSchema(t, "50SP2")
+
+	var out bytes.Buffer
+	if err := WriteSkeleton(&out, schema, schema.Messages["NewOrderSingle"], SkeletonOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := NormaliseDelimiters(strings.TrimSpace(out.String()))
+	if !strings.HasPrefix(msg, "8=FIXT.1.1\x01") || !strings.Contains(msg, "\x0135=D\x011128=9\x01") {
+		t.Fatalf("expected FIXT header with ApplVerID, got %q", out.String())
+	}
+
+	if errs := ValidateFixMessage(msg, LoadDictionary(msg)); len(errs) != 0 {
+		t.Errorf("expected a valid template, got %v", errs)
+	}
+}
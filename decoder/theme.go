@@ -0,0 +1,307 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Theme holds the ANSI escape sequences used for each kind of output.
+// The zero Theme (and NoTheme) produces plain text.
+type Theme struct {
+	Reset     string
+	Line      string // non-FIX text of a log line
+	Tag       string
+	FieldName string
+	Value     string
+	Enum      string
+	File      string
+	Error     string
+	Message   string // raw FIX message within a log line
+	Title     string // separators and headings
+}
+
+func sgr(params string) string {
+	if params == "" {
+		return ""
+	}
+	return "\033[" + params + "m"
+}
+
+// NoTheme disables colour.
+var NoTheme = Theme{}
+
+// DarkTheme is the default theme, tuned for 256-colour dark terminals.
+var DarkTheme = Theme{
+	Reset:     sgr("0"),
+	Line:      sgr("38;5;244"),
+	Tag:       sgr("38;5;81"),
+	FieldName: sgr("38;5;151"),
+	Value:     sgr("38;5;228"),
+	Enum:      sgr("38;5;214"),
+	File:      sgr("95"),
+	Error:     sgr("31"),
+	Message:   sgr("97"),
+	Title:     sgr("31"),
+}
+
+var builtinThemes = map[string]Theme{
+	"dark": DarkTheme,
+	"light": {
+		Reset:     sgr("0"),
+		Line:      sgr("38;5;242"),
+		Tag:       sgr("38;5;25"),
+		FieldName: sgr("38;5;28"),
+		Value:     sgr("38;5;130"),
+		Enum:      sgr("38;5;90"),
+		File:      sgr("35"),
+		Error:     sgr("31"),
+		Message:   sgr("30"),
+		Title:     sgr("31"),
+	},
+	"high-contrast": {
+		Reset:     sgr("0"),
+		Line:      sgr("37"),
+		Tag:       sgr("1;96"),
+		FieldName: sgr("1;92"),
+		Value:     sgr("1;93"),
+		Enum:      sgr("1;95"),
+		File:      sgr("1;95"),
+		Error:     sgr("1;91"),
+		Message:   sgr("1;97"),
+		Title:     sgr("1;91"),
+	},
+	"16-colour": {
+		Reset:     sgr("0"),
+		Line:      sgr("90"),
+		Tag:       sgr("36"),
+		FieldName: sgr("32"),
+		Value:     sgr("33"),
+		Enum:      sgr("35"),
+		File:      sgr("95"),
+		Error:     sgr("31"),
+		Message:   sgr("97"),
+		Title:     sgr("31"),
+	},
+	"none": NoTheme,
+}
+
+// ThemeNames returns the built-in theme names, sorted.
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// LookupTheme returns a built-in theme by name.
+func LookupTheme(name string) (Theme, bool) {
+	t, ok := builtinThemes[strings.ToLower(name)]
+	return t, ok
+}
+
+// Paint wraps s in code, followed by the theme reset. With an empty code
+// (e.g. under NoTheme) s is returned unchanged.
+func (t Theme) Paint(code, s string) string {
+	if code == "" {
+		return s
+	}
+	return code + s + t.Reset
+}
+
+var sgrParams = regexp.MustCompile(`^[0-9;]*$`)
+
+// LoadThemeFile reads a user theme. Each line is "key = SGR-params", for
+// example "tag = 38;5;81"; "base = NAME" starts from a built-in theme
+// (default dark). Blank lines and '#' comments are ignored.
+func LoadThemeFile(path string) (Theme, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	defer f.Close()
+
+	return parseTheme(f)
+}
+
+func parseTheme(r io.Reader) (Theme, error) {
+	t := DarkTheme
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return Theme{}, fmt.Errorf("theme line %d: expected key = value", lineNo)
+		}
+
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.TrimSpace(val)
+
+		if key == "base" {
+			base, found := LookupTheme(val)
+			if !found {
+				return Theme{}, fmt.Errorf("theme line %d: unknown base theme %q", lineNo, val)
+			}
+			t = base
+			continue
+		}
+
+		if !sgrParams.MatchString(val) {
+			return Theme{}, fmt.Errorf("theme line %d: invalid SGR parameters %q", lineNo, val)
+		}
+
+		slot := t.slot(key)
+		if slot == nil {
+			return Theme{}, fmt.Errorf("theme line %d: unknown key %q", lineNo, key)
+		}
+		*slot = sgr(val)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return Theme{}, err
+	}
+
+	if t.Reset == "" && t != NoTheme {
+		t.Reset = sgr("0")
+	}
+
+	return t, nil
+}
+
+func (t *Theme) slot(key string) *string {
+	switch key {
+	case "line":
+		return &t.Line
+	case "tag":
+		return &t.Tag
+	case "name":
+		return &t.FieldName
+	case "value":
+		return &t.Value
+	case "enum":
+		return &t.Enum
+	case "file":
+		return &t.File
+	case "error":
+		return &t.Error
+	case "message":
+		return &t.Message
+	case "title":
+		return &t.Title
+	default:
+		return nil
+	}
+}
+
+// ResolveTheme returns the theme named by spec: a built-in name, a path to
+// a theme file, or (when spec is empty) the user's theme file if present,
+// otherwise DarkTheme.
+func ResolveTheme(spec string) (Theme, error) {
+	if spec == "" {
+		if path := userThemePath(); path != "" {
+			if _, err := os.Stat(path); err == nil {
+				return LoadThemeFile(path)
+			}
+		}
+		return DarkTheme, nil
+	}
+
+	if t, ok := LookupTheme(spec); ok {
+		return t, nil
+	}
+
+	t, err := LoadThemeFile(spec)
+	if err != nil {
+		return Theme{}, fmt.Errorf("unknown theme %q (built-in: %s): %w", spec, strings.Join(ThemeNames(), ", "), err)
+	}
+	return t, nil
+}
+
+var userConfigDir = os.UserConfigDir
+
+func userThemePath() string {
+	dir, err := userConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "fixdecoder", "theme")
+}
+
+var getenv = os.Getenv
+
+// SelectTheme decides whether output is coloured. An explicit -colour flag
+// wins, then NO_COLOR, then FORCE_COLOR, then terminal detection.
+func SelectTheme(t Theme, flagSet, flagValue, isTerminal bool) Theme {
+	switch {
+	case flagSet && flagValue:
+		return t
+	case flagSet:
+		return NoTheme
+	case getenv("NO_COLOR") != "":
+		return NoTheme
+	case getenv("FORCE_COLOR") != "" && getenv("FORCE_COLOR") != "0":
+		return t
+	case isTerminal:
+		return t
+	default:
+		return NoTheme
+	}
+}
+
+// ThemedWriter attaches a Theme to an output so each writer can be rendered
+// in its own colours.
+type ThemedWriter struct {
+	io.Writer
+	Theme Theme
+}
+
+// WithTheme wraps w so output written through the decoder uses t.
+func WithTheme(w io.Writer, t Theme) io.Writer {
+	return ThemedWriter{Writer: w, Theme: t}
+}
+
+// themeOf returns the theme attached to w, or NoTheme for plain writers so
+// output is only coloured when the caller asks for it with WithTheme.
+func themeOf(w io.Writer) Theme {
+	if tw, ok := w.(ThemedWriter); ok {
+		return tw.Theme
+	}
+	return NoTheme
+}
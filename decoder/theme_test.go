@@ -0,0 +1,169 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLookupThemeBuiltins(t *testing.T) {
+	for _, name := range ThemeNames() {
+		if _, ok := LookupTheme(name); !ok {
+			t.Errorf("expected built-in theme %q", name)
+		}
+	}
+
+	if _, ok := LookupTheme("HIGH-CONTRAST"); !ok {
+		t.Error("expected case-insensitive lookup")
+	}
+
+	if _, ok := LookupTheme("sepia"); ok {
+		t.Error("expected unknown theme to fail")
+	}
+}
+
+func TestThemePaint(t *testing.T) {
+	if got := NoTheme.Paint(NoTheme.Tag, "35"); got != "35" {
+		t.Errorf("NoTheme painted text: %q", got)
+	}
+
+	if got := DarkTheme.Paint(DarkTheme.Tag, "35"); got != "\033[38;5;81m35\033[0m" {
+		t.Errorf("unexpected dark paint: %q", got)
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	theme, err := parseTheme(strings.NewReader("# mine\nbase = 16-colour\ntag = 1;34\nenum =\n"))
+	if err != nil {
+		t.Fatalf("parseTheme failed: %v", err)
+	}
+
+	if theme.Tag != "\033[1;34m" || theme.Enum != "" || theme.FieldName != "\033[32m" {
+		t.Errorf("unexpected theme: %+v", theme)
+	}
+
+	theme, err = parseTheme(strings.NewReader("base = none\ntag = 31\n"))
+	if err != nil || theme.Reset != "\033[0m" {
+		t.Errorf("expected reset added to theme built on none, got %+v, %v", theme, err)
+	}
+
+	for _, bad := range []string{"tag", "base = sepia", "tag = red", "colour = 31"} {
+		if _, err := parseTheme(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestResolveTheme(t *testing.T) {
+	original := userConfigDir
+	dir := t.TempDir()
+	userConfigDir = func() (string, error) { return dir, nil }
+	defer func() { userConfigDir = original }()
+
+	if theme, err := ResolveTheme(""); err != nil || theme != DarkTheme {
+		t.Errorf("expected dark default, got %+v, %v", theme, err)
+	}
+
+	if theme, err := ResolveTheme("light"); err != nil || theme == DarkTheme {
+		t.Errorf("expected light theme, got %+v, %v", theme, err)
+	}
+
+	_ = os.MkdirAll(filepath.Join(dir, "fixdecoder"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "fixdecoder", "theme"), []byte("base = none\n"), 0o644)
+
+	if theme, err := ResolveTheme(""); err != nil || theme != NoTheme {
+		t.Errorf("expected user theme file, got %+v, %v", theme, err)
+	}
+
+	if _, err := ResolveTheme("no-such-theme"); err == nil {
+		t.Error("expected error for unknown theme")
+	}
+
+	userConfigDir = func() (string, error) { return "", errors.New("no home") }
+	if theme, err := ResolveTheme(""); err != nil || theme != DarkTheme {
+		t.Errorf("expected dark default without config dir, got %+v, %v", theme, err)
+	}
+}
+
+func TestSelectTheme(t *testing.T) {
+	env := map[string]string{}
+	original := getenv
+	getenv = func(k string) string { return env[k] }
+	defer func() { getenv = original }()
+
+	cases := []struct {
+		name                           string
+		env                            map[string]string
+		flagSet, flagValue, isTerminal bool
+		want                           Theme
+	}{
+		{"terminal", nil, false, false, true, DarkTheme},
+		{"pipe", nil, false, false, false, NoTheme},
+		{"flag yes", map[string]string{"NO_COLOR": "1"}, true, true, false, DarkTheme},
+		{"flag no", nil, true, false, true, NoTheme},
+		{"NO_COLOR", map[string]string{"NO_COLOR": "1"}, false, false, true, NoTheme},
+		{"FORCE_COLOR", map[string]string{"FORCE_COLOR": "1"}, false, false, false, DarkTheme},
+		{"FORCE_COLOR=0", map[string]string{"FORCE_COLOR": "0"}, false, false, false, NoTheme},
+	}
+
+	for _, c := range cases {
+		env = c.env
+		if got := SelectTheme(DarkTheme, c.flagSet, c.flagValue, c.isTerminal); got != c.want {
+			t.Errorf("%s: got %+v", c.name, got)
+		}
+	}
+}
+
+func TestThemedWriterSelectsThemePerOutput(t *testing.T) {
+	var plain, themed bytes.Buffer
+
+	handleLogLine("no fix here", WithTheme(&plain, NoTheme), nil, "", LogOptions{})
+	handleLogLine("no fix here", WithTheme(&themed, DarkTheme), nil, "", LogOptions{})
+
+	if plain.String() != "no fix here\n" {
+		t.Errorf("expected plain output, got %q", plain.String())
+	}
+
+	if !strings.HasPrefix(themed.String(), DarkTheme.Line) {
+		t.Errorf("expected themed output, got %q", themed.String())
+	}
+}
+
+func TestPlainWriterIsNotColoured(t *testing.T) {
+	useRealDecoding(t)
+
+	var out bytes.Buffer
+	handleLogLine("IN "+framedFIX44("35=0|"), &out, nil, "\n", LogOptions{})
+
+	if !strings.Contains(out.String(), "Heartbeat") {
+		t.Fatalf("expected decoded output, got %q", out.String())
+	}
+	if strings.Contains(out.String(), "\x1b[") {
+		t.Errorf("expected no colour codes on a plain writer, got %q", out.String())
+	}
+}
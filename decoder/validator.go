@@ -24,30 +24,61 @@ package decoder
 
 import (
 	"fmt"
-	"regexp"
+	"slices"
 	"strconv"
 	"strings"
-	"time"
 )
 
+// SessionRejectReason (373) codes a counterparty would reject with.
+const (
+	RejectRequiredTagMissing      = 1
+	RejectTagNotDefinedForMsgType = 2
+	RejectValueIncorrect          = 5
+	RejectIncorrectDataFormat     = 6
+	RejectInvalidMsgType          = 11
+	RejectTagRepeated             = 13
+	RejectTagOutOfOrder           = 14
+)
+
+// BusinessRejectReason (380) codes a counterparty would reject with.
+const (
+	BusinessRejectOther                   = 0
+	BusinessRejectUnsupportedMsgType      = 3
+	BusinessRejectConditionalFieldMissing = 5
+)
+
+// ValidateFixMessage validates msg against dict and returns the text of
+// each finding.
 func ValidateFixMessage(msg string, dict *FixTagLookup) []string {
+	return findingTexts(ValidateFindings(msg, dict, DecodeOptions{}))
+}
+
+// ValidateFindings validates msg against dict and the rules and profiles
+// in opts.
+func ValidateFindings(msg string, dict *FixTagLookup, opts DecodeOptions) []Finding {
 	fields := ParseFix(msg)
 	fieldMap, seenTags := buildFieldMap(fields)
 
-	var errors []string
+	var findings []Finding
+
+	findings = append(findings, validateStructure(fields, dict)...)
+	findings = append(findings, validateBodyLength(msg, fieldMap)...)
 
-	msgTypeErrors, msgDef := validateMsgType(fieldMap, dict)
-	errors = append(errors, msgTypeErrors...)
+	msgTypeFindings, msgDef := validateMsgType(fieldMap, dict)
+	findings = append(findings, msgTypeFindings...)
 	if msgDef == nil {
-		return errors // can't continue without a known MsgType
+		return findings // can't continue without a known MsgType
 	}
 
-	errors = append(errors, validateRequiredFields(msgDef.Required, seenTags, dict)...)
-	errors = append(errors, validateFieldEnumsAndTypes(fields, dict)...)
-	errors = append(errors, validateFieldOrdering(fields, msgDef.FieldOrder)...)
-	errors = append(errors, validateChecksumField(msg, fieldMap)...)
+	findings = append(findings, validateRequiredFields(msgDef.Required, seenTags, dict)...)
+	findings = append(findings, validateRules(dict.rules, msgDef.MsgType, fieldMap, dict)...)
+	findings = append(findings, validateRules(opts.Rules, msgDef.MsgType, fieldMap, dict)...)
+	findings = append(findings, validateProfile(opts.Profiles, fields, fieldMap, dict)...)
+	findings = append(findings, validateFieldEnumsAndTypes(fields, dict)...)
+	findings = append(findings, validateFieldOrdering(fields, msgDef.FieldOrder)...)
+	findings = append(findings, validateChecksumField(msg, fieldMap)...)
 
-	return errors
+	return findings
 }
 
 func buildFieldMap(fields []FieldValue) (map[int]string, map[int]bool) {
@@ -60,30 +91,37 @@ func buildFieldMap(fields []FieldValue) (map[int]string, map[int]bool) {
 	return fieldMap, seenTags
 }
 
-func validateMsgType(fieldMap map[int]string, dict *FixTagLookup) ([]string, *MessageDef) {
+// validateMsgType looks up the message's MsgType. User-defined MsgTypes
+// (those starting with U) that the dictionary lacks are only reported as
+// information.
+func validateMsgType(fieldMap map[int]string, dict *FixTagLookup) ([]Finding, *MessageDef) {
 	msgType, ok := fieldMap[35]
 	if !ok {
-		return []string{"Missing required tag 35 (MsgType)"}, nil
+		return []Finding{newFinding(CodeMissingMsgType, 35, "Missing required tag 35 (MsgType)")}, nil
 	}
 	msgDef, ok := dict.Messages[msgType]
 	if !ok {
-		return []string{fmt.Sprintf("Unknown MsgType: %s", msgType)}, nil
+		f := newFinding(CodeUnknownMsgType, 35, "Unknown MsgType: %s", msgType).rejectWith("3", RejectInvalidMsgType)
+		if strings.HasPrefix(msgType, "U") {
+			f.Severity = SeverityInfo
+		}
+		return []Finding{f}, nil
 	}
 	return nil, &msgDef
 }
 
-func validateRequiredFields(required []int, seenTags map[int]bool, dict *FixTagLookup) []string {
-	var errors []string
+func validateRequiredFields(required []int, seenTags map[int]bool, dict *FixTagLookup) []Finding {
+	var findings []Finding
 	for _, tag := range required {
 		if !seenTags[tag] {
-			errors = append(errors, fmt.Sprintf("Missing required tag %d (%s)", tag, dict.GetFieldName(tag)))
+			findings = append(findings, newFinding(CodeMissingTag, tag, "Missing required tag %d (%s)", tag, dict.GetFieldName(tag)).rejectWith("3", RejectRequiredTagMissing))
 		}
 	}
-	return errors
+	return findings
 }
 
-func validateFieldEnumsAndTypes(fields []FieldValue, dict *FixTagLookup) []string {
-	var errors []string
+func validateFieldEnumsAndTypes(fields []FieldValue, dict *FixTagLookup) []Finding {
+	var findings []Finding
 	for _, fv := range fields {
 		tag := fv.Tag
 		val := fv.Value
@@ -91,46 +129,150 @@ func validateFieldEnumsAndTypes(fields []FieldValue, dict *FixTagLookup) []strin
 		// Enums
 		if enumMap, found := dict.enumMap[tag]; found {
 			if _, valid := enumMap[val]; !valid {
-				errors = append(errors, fmt.Sprintf("Invalid enum value '%s' for tag %d", val, tag))
+				findings = append(findings, newFinding(CodeInvalidEnum, tag, "Invalid enum value '%s' for tag %d", val, tag).rejectWith("3", RejectValueIncorrect))
 			}
 		}
 
 		// Types
 		typ := dict.GetFieldType(tag)
 		if typ != "" && !IsValidType(val, typ) {
-			errors = append(errors, fmt.Sprintf("Invalid type for tag %d: expected %s, got '%s'", tag, typ, val))
+			findings = append(findings, newFinding(CodeInvalidType, tag, "Invalid type for tag %d: expected %s, got '%s'", tag, typ, val).rejectWith("3", RejectIncorrectDataFormat))
 		}
 	}
-	return errors
+	return findings
 }
 
-func validateFieldOrdering(fields []FieldValue, expectedOrder []int) []string {
+// validateFieldOrdering warns about body fields out of dictionary order.
+// FIX only requires order within the header and repeating groups.
+func validateFieldOrdering(fields []FieldValue, expectedOrder []int) []Finding {
 	orderIndex := make(map[int]int)
 	for i, tag := range expectedOrder {
 		orderIndex[tag] = i
 	}
 
-	var errors []string
+	var findings []Finding
 	lastIdx := -1
 	for _, fv := range fields {
 		if idx, ok := orderIndex[fv.Tag]; ok {
 			if idx < lastIdx {
-				errors = append(errors, fmt.Sprintf("Tag %d out of order", fv.Tag))
+				f := newFinding(CodeFieldOrder, fv.Tag, "Tag %d out of order", fv.Tag)
+				f.Severity = SeverityWarning
+				findings = append(findings, f)
 			}
 			lastIdx = idx
 		}
 	}
-	return errors
+	return findings
 }
 
-func validateChecksumField(msg string, fieldMap map[int]string) []string {
+func validateChecksumField(msg string, fieldMap map[int]string) []Finding {
 	checkVal, ok := fieldMap[10]
 	if !ok {
-		return []string{"Missing required checksum tag 10"}
+		return []Finding{newFinding(CodeChecksum, 10, "Missing required checksum tag 10")}
 	}
 	expected := fmt.Sprintf("%03d", CalculateChecksum(msg))
 	if checkVal != expected {
-		return []string{fmt.Sprintf("Checksum mismatch: got %s, expected %s", checkVal, expected)}
+		return []Finding{newFinding(CodeChecksum, 10, "Checksum mismatch: got %s, expected %s", checkVal, expected)}
+	}
+	return nil
+}
+
+// validateStructure checks that 8, 9 and 35 open the message and 10 closes
+// it, that header and trailer fields stay in their sections, and that no
+// tag repeats outside a repeating group. A counterparty discards a
+// message with 8, 9 or 10 missing or 8, 9, 35 or 10 out of place as
+// garbled, so those findings carry no reject reason.
+func validateStructure(fields []FieldValue, dict *FixTagLookup) []Finding {
+	var findings []Finding
+
+	for i, tag := range []int{8, 9, 35} {
+		pos := slices.IndexFunc(fields, func(fv FieldValue) bool { return fv.Tag == tag })
+		switch {
+		case pos < 0 && tag != 35: // a missing MsgType is reported on its own
+			findings = append(findings, newFinding(CodeMissingTag, tag, "Missing required tag %d (%s)", tag, dict.GetFieldName(tag)))
+		case pos >= 0 && pos != i:
+			findings = append(findings, newFinding(CodeHeaderOrder, tag, "Tag %d (%s) must be field %d of the header", tag, dict.GetFieldName(tag), i+1))
+		}
+	}
+
+	if pos := slices.IndexFunc(fields, func(fv FieldValue) bool { return fv.Tag == 10 }); pos >= 0 && pos != len(fields)-1 {
+		findings = append(findings, newFinding(CodeHeaderOrder, 10, "Tag 10 (CheckSum) must be the last field"))
+	}
+
+	inBody, inTrailer := false, false
+	for _, fv := range fields {
+		switch {
+		case dict.IsHeaderField(fv.Tag):
+			if inBody || inTrailer {
+				findings = append(findings, rejectFinding(CodeMisplacedTag, RejectTagOutOfOrder, fv.Tag, "Header tag %d (%s) found after the header", fv.Tag, dict.GetFieldName(fv.Tag)))
+			}
+		case dict.IsTrailerField(fv.Tag):
+			inTrailer = true
+		default:
+			if inTrailer {
+				findings = append(findings, rejectFinding(CodeMisplacedTag, RejectTagOutOfOrder, fv.Tag, "Tag %d (%s) found in the trailer", fv.Tag, dict.GetFieldName(fv.Tag)))
+			}
+			inBody = true
+		}
+	}
+
+	findings = append(findings, repeatedTags(fields, dict)...)
+
+	return findings
+}
+
+// repeatedTags reports tags that appear more than once although the
+// message's repeating groups do not contain them. Without a MsgType, any
+// group member may repeat.
+func repeatedTags(fields []FieldValue, dict *FixTagLookup) []Finding {
+	var findings []Finding
+
+	repeatable, known := dict.repeatable[fieldValue(fields, 35)]
+	seen := make(map[int]int, len(fields))
+
+	for _, fv := range fields {
+		seen[fv.Tag]++
+		if seen[fv.Tag] != 2 || repeatable[fv.Tag] {
+			continue
+		}
+		if _, owned := dict.groupOwners[fv.Tag]; !known && owned {
+			continue
+		}
+		findings = append(findings, rejectFinding(CodeRepeatedTag, RejectTagRepeated, fv.Tag, "Tag %d (%s) appears more than once", fv.Tag, dict.GetFieldName(fv.Tag)))
+	}
+
+	return findings
+}
+
+func fieldValue(fields []FieldValue, tag int) string {
+	for _, fv := range fields {
+		if fv.Tag == tag {
+			return fv.Value
+		}
+	}
+	return ""
+}
+
+// validateBodyLength compares BodyLength (9) with the number of bytes from
+// the field after it up to and including the delimiter before CheckSum.
+// A mismatch makes the message garbled, so it carries no reject reason.
+func validateBodyLength(msg string, fieldMap map[int]string) []Finding {
+	const soh = "\x01"
+
+	declared, ok := fieldMap[9]
+	start := strings.Index(msg, soh+"9=")
+	end := strings.Index(msg, soh+"10=")
+	if !ok || start < 0 || end < 0 {
+		return nil
+	}
+
+	bodyStart := start + 1 + strings.Index(msg[start+1:], soh) + 1
+	if bodyStart > end+1 {
+		return nil
+	}
+
+	if actual := end + 1 - bodyStart; declared != strconv.Itoa(actual) {
+		return []Finding{newFinding(CodeBodyLength, 9, "BodyLength mismatch: got %s, expected %d", declared, actual)}
 	}
 	return nil
 }
@@ -150,43 +292,3 @@ func CalculateChecksum(msg string) int {
 	}
 	return sum % 256
 }
-
-func IsValidType(val string, typ string) bool {
-	switch strings.ToUpper(typ) {
-	case "INT", "LENGTH", "NUMINGROUP", "SEQNUM", "DAYOFMONTH":
-		_, err := strconv.Atoi(val)
-		return err == nil
-	case "FLOAT", "QTY", "PRICE", "PRICEOFFSET", "AMT", "PERCENTAGE":
-		_, err := strconv.ParseFloat(val, 64)
-		return err == nil
-	case "BOOLEAN":
-		return val == "Y" || val == "N"
-	case "CHAR":
-		return len(val) == 1
-	case "STRING", "DATA", "CURRENCY", "EXCHANGE", "COUNTRY", "MULTIPLEVALUESTRING", "MULTIPLESTRINGVALUE":
-		return true
-	case "UTCTIMESTAMP":
-		layouts := []string{"20060102-15:04:05", "20060102-15:04:05.000"}
-		for _, layout := range layouts {
-			if _, err := time.Parse(layout, val); err == nil {
-				return true
-			}
-		}
-		return false
-	case "UTCDATEONLY":
-		_, err := time.Parse("20060102", val)
-		return err == nil
-	case "UTCTIMEONLY":
-		layouts := []string{"15:04", "15:04:05", "15:04:05.000"}
-		for _, layout := range layouts {
-			if _, err := time.Parse(layout, val); err == nil {
-				return true
-			}
-		}
-		return false
-	case "MONTHYEAR":
-		return regexp.MustCompile(`^\d{6}([0-9]{2}|(-[0-9]{1,2})|(-?w[1-5]))?$`).MatchString(val)
-	default:
-		return true // assume valid for unknown/custom types
-	}
-}
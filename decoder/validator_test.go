@@ -25,6 +25,7 @@ package decoder
 import (
 	"fmt"
 	"slices"
+	"strings"
 	"testing"
 )
 
@@ -146,7 +147,7 @@ func setupTestDictionary() *FixTagLookup {
 func TestValidateFixMessageValidMessage(t *testing.T) {
 	dict := setupTestDictionary()
 
-	base := "8=FIX.4.4\x019=23\x0135=A\x0111=ORDER123\x0154=1\x01"
+	base := "8=FIX.4.4\x019=22\x0135=A\x0111=ORDER123\x0154=1\x01"
 	checksum := fmt.Sprintf("%03d", CalculateChecksum(base+"10=")) // Pass in fragment including SOH before 10=
 	msg := base + "10=" + checksum + "\x01"
 
@@ -215,7 +216,7 @@ func TestValidateFieldEnumsAndTypesInvalidEnum(t *testing.T) {
 	errors := validateFieldEnumsAndTypes(fields, dict)
 	expected := "Invalid enum value 'X' for tag 54"
 
-	if len(errors) == 0 || errors[0] != expected {
+	if len(errors) == 0 || errors[0].Text != expected {
 		t.Errorf("Expected error %q, got: %v", expected, errors)
 	}
 }
@@ -250,7 +251,7 @@ func TestValidateChecksumFieldMissingTag10(t *testing.T) {
 	errors := validateChecksumField(msg, fieldMap)
 
 	expected := "Missing required checksum tag 10"
-	if len(errors) != 1 || errors[0] != expected {
+	if len(errors) != 1 || errors[0].Text != expected {
 		t.Errorf("Expected error %q, got: %v", expected, errors)
 	}
 }
@@ -268,7 +269,7 @@ func TestValidateChecksumFieldMismatch(t *testing.T) {
 	}
 	expected := fmt.Sprintf("%03d", CalculateChecksum(msg))
 	expectedMsg := fmt.Sprintf("Checksum mismatch: got 000, expected %s", expected)
-	if errs[0] != expectedMsg {
+	if errs[0].Text != expectedMsg {
 		t.Errorf("Unexpected error message:\nGot:  %s\nWant: %s", errs[0], expectedMsg)
 	}
 }
@@ -276,7 +277,7 @@ func TestValidateChecksumFieldMismatch(t *testing.T) {
 func TestValidateFixMessageMissingMsgType(t *testing.T) {
 	dict := setupTestDictionary()
 
-	msg := "8=FIX.4.4\x019=23\x0111=ORDER123\x0154=1\x0110=229\x01"
+	msg := "8=FIX.4.4\x019=17\x0111=ORDER123\x0154=1\x0110=229\x01"
 	errors := ValidateFixMessage(msg, dict)
 	expected := "Missing required tag 35 (MsgType)"
 
@@ -294,7 +295,7 @@ func TestValidateMsgTypeMissingTag35(t *testing.T) {
 
 	errors, def := validateMsgType(fieldMap, dict)
 
-	if len(errors) != 1 || errors[0] != "Missing required tag 35 (MsgType)" {
+	if len(errors) != 1 || errors[0].Text != "Missing required tag 35 (MsgType)" {
 		t.Errorf("Expected missing tag 35 error, got: %v", errors)
 	}
 
@@ -333,8 +334,8 @@ func TestIsValidTypeUTCDATEONLY(t *testing.T) {
 }
 
 func TestIsValidTypeUTCTIMEONLY(t *testing.T) {
-	valid := []string{"15:04", "15:04:05", "15:04:05.000"}
-	invalid := []string{"3:04PM", "15:04:60", "invalid"}
+	valid := []string{"15:04:05", "15:04:05.000"}
+	invalid := []string{"15:04", "3:04PM", "15:04:60", "invalid"}
 
 	for _, v := range valid {
 		if !IsValidType(v, "UTCTIMEONLY") {
@@ -363,10 +364,68 @@ func TestValidateMsgTypeUnknownType(t *testing.T) {
 	if len(errs) != 1 {
 		t.Errorf("Expected 1 error, got %d", len(errs))
 	}
-	if errs[0] != "Unknown MsgType: Z" {
+	if errs[0].Text != "Unknown MsgType: Z" {
 		t.Errorf("Unexpected error message: %s", errs[0])
 	}
 	if def != nil {
 		t.Errorf("Expected nil MessageDef, got %+v", def)
 	}
 }
+
+// framedFIX44 wraps '|' delimited body fields in a FIX 4.4 header and
+// trailer with the correct BodyLength and CheckSum.
+func framedFIX44(body string) string {
+	body = strings.ReplaceAll(body, "|", "\x01")
+	msg := fmt.Sprintf("8=FIX.4.4\x019=%d\x01%s", len(body), body)
+	return msg + fmt.Sprintf("10=%03d\x01", CalculateChecksum(msg+"10="))
+}
+
+func TestValidateFixMessageStructure(t *testing.T) {
+	d := fix44Lookup(t)
+
+	const order = "35=D|49=A|56=B|34=1|52=20240101-00:00:00|11=1|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=1|"
+
+	if errs := ValidateFixMessage(framedFIX44(order), d); len(errs) != 0 {
+		t.Fatalf("expected a valid message, got %v", errs)
+	}
+
+	cases := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{"body length", strings.Replace(framedFIX44(order), "9=", "9=1", 1), "BodyLength mismatch: got 1"},
+		{"header order", strings.Replace(framedFIX44(order), "\x0135=D\x0149=A", "\x0149=A\x0135=D", 1), "Tag 35 (MsgType) must be field 3 of the header"},
+		{"missing begin string", strings.TrimPrefix(framedFIX44(order), "8=FIX.4.4\x01"), "Missing required tag 8 (BeginString)"},
+		{"checksum not last", framedFIX44(order) + "58=late\x01", "Tag 10 (CheckSum) must be the last field"},
+		{"header in body", framedFIX44(order + "115=ON|"), "Header tag 115 (OnBehalfOfCompID) found after the header (SessionRejectReason 14)"},
+		{"body in trailer", framedFIX44(order + "93=3|89=abc|58=x|"), "Tag 58 (Text) found in the trailer (SessionRejectReason 14)"},
+		{"repeated tag", framedFIX44(order + "55=MSFT|"), "Tag 55 (Symbol) appears more than once (SessionRejectReason 13)"},
+	}
+
+	for _, tc := range cases {
+		if errs := ValidateFixMessage(tc.msg, d); !slices.ContainsFunc(errs, func(e string) bool { return strings.HasPrefix(e, tc.want) }) {
+			t.Errorf("%s: expected %q, got %v", tc.name, tc.want, errs)
+		}
+	}
+}
+
+func TestValidateFixMessageGroupTagsMayRepeat(t *testing.T) {
+	d := fix44Lookup(t)
+
+	msg := framedFIX44("35=D|49=A|56=B|34=1|52=20240101-00:00:00|11=1|453=2|448=P1|447=D|452=1|448=P2|447=D|452=3|21=1|55=IBM|54=1|60=20240101-00:00:00|38=100|40=1|")
+	for _, e := range ValidateFixMessage(msg, d) {
+		if strings.Contains(e, "appears more than once") {
+			t.Errorf("did not expect group members to be reported, got %q", e)
+		}
+	}
+}
+
+func TestRepeatedTagsWithoutMsgType(t *testing.T) {
+	d := &FixTagLookup{groupOwners: map[int]int{448: 453}}
+
+	errs := repeatedTags([]FieldValue{{448, "A"}, {448, "B"}, {58, "x"}, {58, "y"}}, d)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Text, "Tag 58") {
+		t.Errorf("expected only the non-group tag, got %v", errs)
+	}
+}
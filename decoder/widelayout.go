@@ -0,0 +1,146 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// wideValueWidth caps the value column of the wide layout unless values are
+// expanded.
+const wideValueWidth = 24
+
+type wideCell struct {
+	tag, name, value, desc string
+}
+
+func (c wideCell) text() string {
+	if c.desc == "" {
+		return c.value
+	}
+	return c.value + " (" + c.desc + ")"
+}
+
+// tagWidth is the width needed for the largest tag, at least four digits.
+func tagWidth(fields []DecodedField) int {
+	width := 4
+	for _, f := range fields {
+		if n := len(strconv.Itoa(f.Tag)); n > width {
+			width = n
+		}
+	}
+	return width
+}
+
+// wideCells flattens fields in wire order, indenting group members by depth.
+func wideCells(out []wideCell, fields []DecodedField, depth int) []wideCell {
+	for _, f := range fields {
+		out = append(out, wideCell{
+			tag:   strconv.Itoa(f.Tag),
+			name:  strings.Repeat(" ", 2*depth) + f.Name,
+			value: f.Value,
+			desc:  f.Description,
+		})
+
+		for _, g := range f.Groups {
+			out = wideCells(out, g, depth+1)
+		}
+	}
+	return out
+}
+
+// renderWideFields packs fields into as many aligned columns as fit in
+// width, reading top to bottom then left to right.
+func renderWideFields(fields []DecodedField, width int, expand bool, t Theme) string {
+	cells := wideCells(nil, fields, 0)
+	if len(cells) == 0 {
+		return ""
+	}
+
+	tagW, nameW, valueW := 4, 0, 0
+	for _, c := range cells {
+		tagW = max(tagW, len(c.tag))
+		nameW = max(nameW, utf8.RuneCountInString(c.name))
+		valueW = max(valueW, utf8.RuneCountInString(c.text()))
+	}
+
+	if !expand && valueW > wideValueWidth {
+		valueW = wideValueWidth
+	}
+
+	cellW := tagW + 1 + nameW + 1 + valueW
+	cols := max((width-4+2)/(cellW+2), 1)
+	rows := (len(cells) + cols - 1) / cols
+
+	var sb strings.Builder
+
+	for row := range rows {
+		sb.WriteString("    ")
+
+		for col := range cols {
+			i := col*rows + row
+			if i >= len(cells) {
+				break
+			}
+
+			if col > 0 {
+				sb.WriteString("  ")
+			}
+
+			last := col == cols-1 || (col+1)*rows+row >= len(cells)
+			writeWideCell(&sb, cells[i], tagW, nameW, valueW, last, t)
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func writeWideCell(sb *strings.Builder, c wideCell, tagW, nameW, valueW int, last bool, t Theme) {
+	sb.WriteString(strings.Repeat(" ", tagW-len(c.tag)))
+	sb.WriteString(t.Paint(t.Tag, c.tag))
+	sb.WriteString(" ")
+	sb.WriteString(t.Paint(t.FieldName, c.name))
+	sb.WriteString(strings.Repeat(" ", nameW-utf8.RuneCountInString(c.name)+1))
+
+	text := c.text()
+	n := utf8.RuneCountInString(text)
+
+	switch {
+	case n > valueW:
+		text = string([]rune(text)[:valueW-1]) + "…"
+		n = valueW
+		sb.WriteString(t.Paint(t.Value, text))
+	case c.desc != "":
+		sb.WriteString(t.Paint(t.Value, c.value) + " (" + t.Paint(t.Enum, c.desc) + ")")
+	default:
+		sb.WriteString(t.Paint(t.Value, text))
+	}
+
+	if !last {
+		sb.WriteString(strings.Repeat(" ", valueW-n))
+	}
+}
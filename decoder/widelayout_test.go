@@ -0,0 +1,91 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package decoder
+
+import (
+	"strings"
+	"testing"
+)
+
+var wideFields = []DecodedField{
+	{Tag: 35, Name: "MsgType", Value: "D", Description: "NewOrderSingle"},
+	{Tag: 58, Name: "Text", Value: strings.Repeat("x", 40)},
+	{Tag: 20001, Name: "CustomTag", Value: "V"},
+	{Tag: 453, Name: "NoPartyIDs", Value: "1", Groups: [][]DecodedField{
+		{{Tag: 448, Name: "PartyID", Value: "P1"}},
+	}},
+}
+
+func TestRenderWideFieldsPacksColumns(t *testing.T) {
+	out := renderWideFields(wideFields, 200, false, NoTheme)
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+
+	if len(lines) != 2 {
+		t.Fatalf("expected fields packed into two rows, got:\n%s", out)
+	}
+
+	if !strings.HasPrefix(lines[0], "       35 MsgType    D (NewOrderSingle)") {
+		t.Errorf("expected tags right-aligned to five digits, got %q", lines[0])
+	}
+
+	if !strings.Contains(out, "20001 CustomTag") || !strings.Contains(out, "  448   PartyID") {
+		t.Errorf("expected five-digit tag and indented group member, got:\n%s", out)
+	}
+
+	if !strings.Contains(out, strings.Repeat("x", wideValueWidth-1)+"…") || strings.Contains(out, strings.Repeat("x", wideValueWidth)) {
+		t.Errorf("expected long value truncated, got:\n%s", out)
+	}
+}
+
+func TestRenderWideFieldsExpandAndNarrow(t *testing.T) {
+	out := renderWideFields(wideFields, 40, true, NoTheme)
+
+	if strings.Count(out, "\n") != 5 {
+		t.Errorf("expected one field per row on a narrow terminal, got:\n%s", out)
+	}
+
+	if !strings.Contains(out, strings.Repeat("x", 40)) {
+		t.Errorf("expected full value when expanded, got:\n%s", out)
+	}
+
+	if renderWideFields(nil, 80, false, NoTheme) != "" {
+		t.Error("expected no output for no fields")
+	}
+}
+
+func TestRenderDecodedFieldsWidensTagColumn(t *testing.T) {
+	out := renderDecodedFields([]DecodedField{{Tag: 35, Name: "MsgType", Value: "0"}, {Tag: 20001, Name: "CustomTag", Value: "V"}}, NoTheme)
+
+	if !strings.Contains(out, "       35 (MsgType): 0\n") || !strings.Contains(out, "    20001 (CustomTag): V\n") {
+		t.Errorf("expected tags aligned to five digits, got:\n%s", out)
+	}
+}
+
+func TestWriteDecodedMessageWideLayout(t *testing.T) {
+	var sb strings.Builder
+	writeDecodedMessage(DecodedMessage{Fields: wideFields}, &sb, "--\n", LogOptions{Wide: true})
+
+	if !strings.Contains(sb.String(), "CustomTag") || strings.Contains(sb.String(), "(CustomTag)") {
+		t.Errorf("expected wide layout output, got:\n%s", sb.String())
+	}
+}
@@ -31,7 +31,10 @@ import (
 	"sync"
 )
 
-const soh = "\x01"
+const (
+	soh  = "\x01"
+	pipe = "|"
+)
 
 // Obfuscator replaces values of sensitive FIX tags with stable aliases.
 // It is safe for concurrent use.
@@ -41,6 +44,7 @@ type Obfuscator struct {
 	mu       sync.Mutex        // protects aliasMap and counter
 	aliasMap map[string]string // "tag=value" -> alias
 	counter  map[int]int       // per-tag, for zero-padded suffixes
+	scrubber *Scrubber         // optional scrubber for the non-FIX text of a line
 }
 
 // CreateObfuscator constructs an Obfuscator using the given tag map.
@@ -66,10 +70,27 @@ func (o *Obfuscator) Enabled(line string, stderr io.Writer) string {
 	return o.ObfuscateLine(line, stderr)
 }
 
-// ObfuscateLine rewrites a single SOH-delimited FIX line, replacing values for sensitive tags.
+// SetScrubber attaches a Scrubber used by ScrubText for the parts of a log
+// line that are not FIX messages. A nil scrubber disables scrubbing.
+func (o *Obfuscator) SetScrubber(s *Scrubber) {
+	o.scrubber = s
+}
+
+// ScrubText runs the attached Scrubber (if any) over free text. Scrubbing is
+// independent of the enabled flag so logs can be scrubbed without -secret.
+func (o *Obfuscator) ScrubText(text string, stderr io.Writer) string {
+	if o == nil || o.scrubber == nil {
+		return text
+	}
+	return o.scrubber.Scrub(text, stderr)
+}
+
+// ObfuscateLine rewrites a single FIX message, replacing values for sensitive tags.
+// The delimiter is SOH unless the message is pipe-delimited (see delimiterOf).
 // On first occurrence of any tag=value pair, it logs to stderr (if provided).
 func (o *Obfuscator) ObfuscateLine(line string, stderr io.Writer) string {
-	fields := strings.Split(line, soh)
+	delim := delimiterOf(line)
+	fields := strings.Split(line, delim)
 
 	for i, f := range fields {
 		tagStr, val, ok := splitOnce(f)
@@ -106,11 +127,20 @@ func (o *Obfuscator) ObfuscateLine(line string, stderr io.Writer) string {
 		fields[i] = tagStr + "=" + alias
 	}
 
-	return strings.Join(fields, soh)
+	return strings.Join(fields, delim)
 }
 
 // ---- small helpers (keep complexity low) ----
 
+// delimiterOf returns the field delimiter of a FIX message: SOH when present,
+// otherwise '|' for the common human-readable form, defaulting to SOH.
+func delimiterOf(msg string) string {
+	if !strings.Contains(msg, soh) && strings.Contains(msg, pipe) {
+		return pipe
+	}
+	return soh
+}
+
 func splitOnce(s string) (left, right string, ok bool) {
 	// Accept empty left or right and split on first occurrence of '=' or SOH.
 	// This allows handling fragments that may still include SOH.
@@ -177,3 +177,25 @@ func TestEnabledReturnsUnchangedWhenDisabled(t *testing.T) {
 		t.Fatalf("Enabled() altered line when disabled:\n got: %q\nwant: %q", out, in)
 	}
 }
+
+func TestObfuscatorHandlesPipeDelimitedMessages(t *testing.T) {
+	o := CreateObfuscator(map[int]string{49: "SenderCompID"}, true)
+	in := "8=FIX.4.4|49=ABC|56=DEF|10=000|"
+	out := o.ObfuscateLine(in, io.Discard)
+
+	if out != "8=FIX.4.4|49=SenderCompID0001|56=DEF|10=000|" {
+		t.Fatalf("unexpected pipe-delimited result: %q", out)
+	}
+}
+
+func TestScrubTextUsesAttachedScrubber(t *testing.T) {
+	o := CreateObfuscator(nil, false)
+	if got := o.ScrubText("10.0.0.1", nil); got != "10.0.0.1" {
+		t.Fatalf("expected no scrubbing without scrubber, got %q", got)
+	}
+
+	o.SetScrubber(CreateScrubber(DefaultScrubRules()))
+	if got := o.ScrubText("10.0.0.1", nil); got != "IP0001" {
+		t.Fatalf("expected scrubbing even when obfuscation disabled, got %q", got)
+	}
+}
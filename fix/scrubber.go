@@ -0,0 +1,153 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package fix
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ScrubRule names a pattern whose matches are replaced with stable aliases.
+type ScrubRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Scrubber replaces pattern matches in free (non-FIX) log text with stable
+// aliases such as EMAIL0001. It is safe for concurrent use.
+type Scrubber struct {
+	rules    []ScrubRule
+	mu       sync.Mutex        // protects aliasMap and counter
+	aliasMap map[string]string // "NAME=match" -> alias
+	counter  map[string]int    // per-rule, for zero-padded suffixes
+}
+
+// hostLabel is one DNS label followed by its dot.
+const hostLabel = `[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?\.`
+
+// hostPattern matches names whose last label is a well-known top-level
+// domain, so that file names such as main.go and package or class names
+// such as org.apache.mina.core.session are left alone.
+var hostPattern = regexp.MustCompile(`\b(?:` + hostLabel + `)+(?i:com|net|org|edu|gov|mil|int|io|co|uk|us|eu|de|fr|ch|nl|ie|jp|hk|sg|au|ca|info|biz|local|internal|lan|corp|intra)\b`)
+
+// DefaultScrubRules returns the built-in rules for emails, IPv4 addresses and
+// host names. Order matters: emails are scrubbed before their domains.
+func DefaultScrubRules() []ScrubRule {
+	return []ScrubRule{
+		{Name: "EMAIL", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+		{Name: "IP", Pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`)},
+		{Name: "HOST", Pattern: hostPattern},
+	}
+}
+
+// LoadScrubRules reads NAME=REGEX lines from path. Blank lines and lines
+// starting with '#' are ignored.
+func LoadScrubRules(path string) ([]ScrubRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseScrubRules(f)
+}
+
+func parseScrubRules(r io.Reader) ([]ScrubRule, error) {
+	var rules []ScrubRule
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, expr, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("scrub rules line %d: expected NAME=REGEX", lineNo)
+		}
+
+		re, err := regexp.Compile(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, fmt.Errorf("scrub rules line %d: %w", lineNo, err)
+		}
+
+		rules = append(rules, ScrubRule{Name: strings.TrimSpace(name), Pattern: re})
+	}
+
+	return rules, scanner.Err()
+}
+
+// CreateScrubber constructs a Scrubber applying rules in order.
+func CreateScrubber(rules []ScrubRule) *Scrubber {
+	return &Scrubber{
+		rules:    append([]ScrubRule(nil), rules...),
+		aliasMap: make(map[string]string),
+		counter:  make(map[string]int),
+	}
+}
+
+// Scrub replaces every rule match in text with its alias. On first use of a
+// value it logs the mapping to stderr (if provided). A nil Scrubber returns
+// text unchanged.
+func (s *Scrubber) Scrub(text string, stderr io.Writer) string {
+	if s == nil {
+		return text
+	}
+
+	for _, rule := range s.rules {
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			return s.alias(rule.Name, match, stderr)
+		})
+	}
+
+	return text
+}
+
+func (s *Scrubber) alias(name, match string, stderr io.Writer) string {
+	key := name + "=" + match
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alias, exists := s.aliasMap[key]
+	if !exists {
+		s.counter[name]++
+		alias = fmt.Sprintf("%s%04d", name, s.counter[name])
+		s.aliasMap[key] = alias
+
+		if stderr != nil {
+			fmt.Fprintf(stderr, "first use: %s value [%s] → [%s]\n", name, match, alias)
+		}
+	}
+
+	return alias
+}
@@ -0,0 +1,129 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package fix
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScrubberDefaultRules(t *testing.T) {
+	s := CreateScrubber(DefaultScrubRules())
+
+	in := "conn from 10.1.2.3 host gw1.example.com user ops@example.com"
+	var stderr capture
+	out := s.Scrub(in, &stderr)
+
+	for _, want := range []string{"IP0001", "HOST0001", "EMAIL0001"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in scrubbed output, got %q", want, out)
+		}
+	}
+
+	for _, leaked := range []string{"10.1.2.3", "gw1.example.com", "ops@example.com"} {
+		if strings.Contains(out, leaked) {
+			t.Fatalf("expected %q to be scrubbed, got %q", leaked, out)
+		}
+	}
+
+	if stderr.Len() == 0 {
+		t.Fatal("expected first-use events to be logged")
+	}
+}
+
+func TestScrubberDefaultHostRule(t *testing.T) {
+	s := CreateScrubber(DefaultScrubRules())
+
+	for _, in := range []string{
+		"main.go", "java.lang.String", "see prettifier.go:42", "v1.2", "config.Load",
+		"org.apache.mina.core.session", "at com.acme.oms.OrderRouter.route",
+	} {
+		if out := s.Scrub(in, io.Discard); out != in {
+			t.Errorf("expected %q unchanged, got %q", in, out)
+		}
+	}
+
+	for _, in := range []string{"example.com", "FIX-GW.Broker.CO.UK", "db01.prod.acme.internal", "ldn-gw2.corp"} {
+		if out := s.Scrub(in, io.Discard); !strings.HasPrefix(out, "HOST") {
+			t.Errorf("expected %q to be scrubbed, got %q", in, out)
+		}
+	}
+}
+
+func TestScrubberStableAliases(t *testing.T) {
+	s := CreateScrubber(DefaultScrubRules())
+
+	first := s.Scrub("a 10.0.0.1 b 10.0.0.2", io.Discard)
+	second := s.Scrub("c 10.0.0.2", io.Discard)
+
+	if first != "a IP0001 b IP0002" {
+		t.Fatalf("unexpected first result %q", first)
+	}
+	if second != "c IP0002" {
+		t.Fatalf("expected alias reuse, got %q", second)
+	}
+}
+
+func TestScrubberNilReturnsUnchanged(t *testing.T) {
+	var s *Scrubber
+	if got := s.Scrub("10.0.0.1", nil); got != "10.0.0.1" {
+		t.Fatalf("nil scrubber changed text: %q", got)
+	}
+}
+
+func TestLoadScrubRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	content := "# comment\n\nDESK=desk-[0-9]+\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := LoadScrubRules(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 1 || rules[0].Name != "DESK" {
+		t.Fatalf("unexpected rules: %+v", rules)
+	}
+
+	if got := CreateScrubber(rules).Scrub("from desk-42", nil); got != "from DESK0001" {
+		t.Fatalf("custom rule not applied: %q", got)
+	}
+}
+
+func TestLoadScrubRulesErrors(t *testing.T) {
+	if _, err := LoadScrubRules(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+
+	if _, err := parseScrubRules(strings.NewReader("novalue\n")); err == nil {
+		t.Fatal("expected error for line without '='")
+	}
+
+	if _, err := parseScrubRules(strings.NewReader("BAD=([\n")); err == nil {
+		t.Fatal("expected error for invalid regex")
+	}
+}
@@ -0,0 +1,342 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+// Package server exposes the decoder over a local HTTP API with a small
+// embedded web page for pasting messages.
+package server
+
+import (
+	"embed"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stephenlclarke/fixdecoder/decoder"
+	"github.com/stephenlclarke/fixdecoder/fix"
+)
+
+//go:embed static
+var static embed.FS
+
+// maxBodyBytes bounds the size of a decode request.
+const maxBodyBytes = 4 << 20
+
+var errUnknownVersion = errors.New("unknown FIX version")
+
+// Server serves the decode API and the embedded UI. It is safe for
+// concurrent use.
+type Server struct {
+	mux *http.ServeMux
+
+	mu      sync.Mutex
+	schemas map[string]decoder.SchemaTree
+}
+
+// New returns a Server with all routes registered.
+func New() *Server {
+	s := &Server{mux: http.NewServeMux(), schemas: make(map[string]decoder.SchemaTree)}
+
+	assets, _ := fs.Sub(static, "static")
+	s.mux.Handle("GET /", http.FileServerFS(assets))
+
+	s.mux.HandleFunc("POST /api/decode", s.handleDecode)
+	s.mux.HandleFunc("GET /api/versions", s.handleVersions)
+	s.mux.HandleFunc("GET /api/{version}/fields", s.handleFields)
+	s.mux.HandleFunc("GET /api/{version}/fields/{id}", s.handleField)
+	s.mux.HandleFunc("GET /api/{version}/messages", s.handleMessages)
+	s.mux.HandleFunc("GET /api/{version}/messages/{id}", s.handleMessage)
+	s.mux.HandleFunc("GET /api/{version}/components", s.handleComponents)
+	s.mux.HandleFunc("GET /api/{version}/components/{id}", s.handleComponent)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// schema returns the cached SchemaTree for an embedded FIX version.
+func (s *Server) schema(version string) (decoder.SchemaTree, error) {
+	if !slices.Contains(strings.Split(fix.SupportedFixVersions(), ","), version) {
+		return decoder.SchemaTree{}, fmt.Errorf("%w %q (supported: %s)", errUnknownVersion, version, fix.SupportedFixVersions())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if schema, ok := s.schemas[version]; ok {
+		return schema, nil
+	}
+
+	var dict decoder.FixDictionary
+	if err := xml.Unmarshal([]byte(fix.ChooseEmbeddedXML(version)), &dict); err != nil {
+		return decoder.SchemaTree{}, fmt.Errorf("failed to parse embedded FIX XML: %w", err)
+	}
+
+	schema := decoder.BuildSchema(dict)
+	s.schemas[version] = schema
+
+	return schema, nil
+}
+
+type decodeRequest struct {
+	Text     string `json:"text"`
+	Validate *bool  `json:"validate"`
+}
+
+type decodeResponse struct {
+	Messages []decoder.DecodedMessage `json:"messages"`
+}
+
+// handleDecode accepts a JSON {"text": ..., "validate": ...} body or the raw
+// text itself; validation is on unless turned off.
+func (s *Server) handleDecode(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+
+	req := decodeRequest{Text: string(body)}
+
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "application/json" {
+		req = decodeRequest{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON: %w", err))
+			return
+		}
+	}
+
+	validate := r.URL.Query().Get("validate") != "false"
+	if req.Validate != nil {
+		validate = *req.Validate
+	}
+
+	writeJSON(w, http.StatusOK, decodeResponse{Messages: decoder.DecodeText(req.Text, decoder.DecodeOptions{Validate: validate})})
+}
+
+func (s *Server) handleVersions(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{"versions": strings.Split(fix.SupportedFixVersions(), ",")})
+}
+
+type fieldSummary struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+}
+
+type enumValue struct {
+	Enum        string `json:"enum"`
+	Description string `json:"description"`
+}
+
+type fieldDetail struct {
+	fieldSummary
+	Values []enumValue `json:"values,omitempty"`
+}
+
+type messageSummary struct {
+	Name    string `json:"name"`
+	MsgType string `json:"msgType"`
+	MsgCat  string `json:"msgCat"`
+}
+
+type member struct {
+	Kind     string   `json:"kind"` // field, component or group
+	Name     string   `json:"name"`
+	Number   int      `json:"number,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Required bool     `json:"required"`
+	Members  []member `json:"members,omitempty"`
+}
+
+type messageDetail struct {
+	messageSummary
+	Members []member `json:"members"`
+}
+
+type componentDetail struct {
+	Name    string   `json:"name"`
+	Members []member `json:"members"`
+}
+
+// withSchema resolves the {version} path value or writes a 404.
+func (s *Server) withSchema(w http.ResponseWriter, r *http.Request) (decoder.SchemaTree, bool) {
+	schema, err := s.schema(r.PathValue("version"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, errUnknownVersion) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return schema, false
+	}
+	return schema, true
+}
+
+func (s *Server) handleFields(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.withSchema(w, r)
+	if !ok {
+		return
+	}
+
+	fields := make([]fieldSummary, 0, len(schema.Fields))
+	for _, f := range schema.Fields {
+		fields = append(fields, fieldSummary{Number: f.Number, Name: f.Name, Type: f.Type})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Number < fields[j].Number })
+
+	writeJSON(w, http.StatusOK, fields)
+}
+
+// handleField looks a field up by tag number or name.
+func (s *Server) handleField(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.withSchema(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	tag, err := strconv.Atoi(id)
+
+	for _, f := range schema.Fields {
+		if (err == nil && f.Number == tag) || strings.EqualFold(f.Name, id) {
+			detail := fieldDetail{fieldSummary: fieldSummary{Number: f.Number, Name: f.Name, Type: f.Type}}
+			for _, v := range f.Values {
+				detail.Values = append(detail.Values, enumValue(v))
+			}
+			writeJSON(w, http.StatusOK, detail)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Errorf("field not found: %s", id))
+}
+
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.withSchema(w, r)
+	if !ok {
+		return
+	}
+
+	msgs := make([]messageSummary, 0, len(schema.Messages))
+	for _, m := range schema.Messages {
+		msgs = append(msgs, messageSummary{Name: m.Name, MsgType: m.MsgType, MsgCat: m.MsgCat})
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].Name < msgs[j].Name })
+
+	writeJSON(w, http.StatusOK, msgs)
+}
+
+// handleMessage looks a message up by name or MsgType.
+func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.withSchema(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+
+	for _, m := range schema.Messages {
+		if m.MsgType == id || strings.EqualFold(m.Name, id) {
+			writeJSON(w, http.StatusOK, messageDetail{
+				messageSummary: messageSummary{Name: m.Name, MsgType: m.MsgType, MsgCat: m.MsgCat},
+				Members:        members(m.Fields, m.Components, m.Groups),
+			})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Errorf("message not found: %s", id))
+}
+
+func (s *Server) handleComponents(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.withSchema(w, r)
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(schema.Components))
+	for name := range schema.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *Server) handleComponent(w http.ResponseWriter, r *http.Request) {
+	schema, ok := s.withSchema(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+
+	for name, c := range schema.Components {
+		if strings.EqualFold(name, id) {
+			writeJSON(w, http.StatusOK, componentDetail{Name: name, Members: members(c.Fields, c.Components, c.Groups)})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, fmt.Errorf("component not found: %s", id))
+}
+
+// members flattens schema nodes into the API's member tree.
+func members(fields []decoder.FieldNode, comps []decoder.ComponentNode, groups []decoder.GroupNode) []member {
+	out := make([]member, 0, len(fields)+len(comps)+len(groups))
+
+	for _, f := range fields {
+		out = append(out, member{Kind: "field", Name: f.Field.Name, Number: f.Field.Number, Type: f.Field.Type, Required: f.Ref.Required == "Y"})
+	}
+
+	for _, c := range comps {
+		out = append(out, member{Kind: "component", Name: c.Name, Required: c.Required == "Y", Members: members(c.Fields, c.Components, c.Groups)})
+	}
+
+	for _, g := range groups {
+		out = append(out, member{Kind: "group", Name: g.Name, Required: g.Required == "Y", Members: members(g.Fields, g.Components, g.Groups)})
+	}
+
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,148 @@
+/*
+fixdecoder — FIX protocol decoder tools
+Copyright (C) 2025 Steve Clarke <stephenlclarke@mac.com>
+
+This program is free software: you can redistribute it and/or modify
+it under the terms of the GNU Affero General Public License as published by
+the Free Software Foundation, either version 3 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU Affero General Public License for more details.
+
+You should have received a copy of the GNU Affero General Public License
+along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+In accordance with section 13 of the AGPL, if you modify this program,
+your modified version must prominently offer all users interacting with it
+remotely through a computer network an opportunity to receive the source
+code of your version.
+*/
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func request(t *testing.T, s *Server, method, path, contentType, body string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	var decoded map[string]any
+	if strings.HasPrefix(strings.TrimSpace(rec.Body.String()), "{") {
+		if err := json.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+			t.Fatalf("invalid JSON from %s %s: %v", method, path, err)
+		}
+	}
+
+	return rec, decoded
+}
+
+func TestDecodePlainText(t *testing.T) {
+	rec, body := request(t, New(), http.MethodPost, "/api/decode", "text/plain", "IN 8=FIX.4.4|9=5|35=0|10=000|\n")
+
+	msgs, _ := body["messages"].([]any)
+	if rec.Code != http.StatusOK || len(msgs) != 1 {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+
+	msg := msgs[0].(map[string]any)
+	if msg["msgName"] != "Heartbeat" || msg["errors"] == nil {
+		t.Errorf("expected decoded heartbeat with checksum error, got %v", msg)
+	}
+}
+
+func TestDecodeJSONWithoutValidation(t *testing.T) {
+	rec, body := request(t, New(), http.MethodPost, "/api/decode", "application/json",
+		`{"text":"8=FIX.4.4|9=5|35=D|54=1|453=1|448=P1|10=000|","validate":false}`)
+
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"description":"BUY"`) || !strings.Contains(rec.Body.String(), `"groups":[[{"tag":448`) {
+		t.Fatalf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+
+	if msg := body["messages"].([]any)[0].(map[string]any); msg["errors"] != nil {
+		t.Errorf("expected no validation errors, got %v", msg["errors"])
+	}
+
+	rec, _ = request(t, New(), http.MethodPost, "/api/decode", "application/json", `{"text":`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected bad request for invalid JSON, got %d", rec.Code)
+	}
+}
+
+func TestDictionaryEndpoints(t *testing.T) {
+	s := New()
+
+	cases := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/api/versions", 200, `"50SP2"`},
+		{"/api/44/fields", 200, `{"number":35,"name":"MsgType","type":"STRING"}`},
+		{"/api/44/fields/54", 200, `{"enum":"1","description":"BUY"}`},
+		{"/api/44/fields/side", 200, `"number":54`},
+		{"/api/44/fields/99999", 404, "field not found"},
+		{"/api/44/messages", 200, `{"name":"Heartbeat","msgType":"0","msgCat":"admin"}`},
+		{"/api/44/messages/D", 200, `"kind":"group","name":"NoPartyIDs"`},
+		{"/api/44/messages/D", 200, `{"kind":"component","name":"Instrument","required":true,`},
+		{"/api/44/messages/D", 200, `{"kind":"component","name":"Parties","required":false,`},
+		{"/api/44/messages/nope", 404, "message not found"},
+		{"/api/44/components", 200, `"Header"`},
+		{"/api/44/components/instrument", 200, `"name":"Instrument"`},
+		{"/api/44/components/nope", 404, "component not found"},
+		{"/api/99/fields", 404, "unknown FIX version"},
+	}
+
+	for _, c := range cases {
+		rec, _ := request(t, s, http.MethodGet, c.path, "", "")
+		if rec.Code != c.status || !strings.Contains(rec.Body.String(), c.want) {
+			t.Errorf("GET %s: got %d %.200s, want %d containing %s", c.path, rec.Code, rec.Body.String(), c.status, c.want)
+		}
+	}
+}
+
+func TestIndexPage(t *testing.T) {
+	rec, _ := request(t, New(), http.MethodGet, "/", "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "api/decode") {
+		t.Fatalf("expected embedded UI, got %d", rec.Code)
+	}
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	s := New()
+	var wg sync.WaitGroup
+
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := "/api/44/messages/D"
+			if i%2 == 0 {
+				path = "/api/50SP2/fields/35"
+			}
+			if rec, _ := request(t, s, http.MethodGet, path, "", ""); rec.Code != http.StatusOK {
+				t.Errorf("GET %s: %d", path, rec.Code)
+			}
+			if rec, _ := request(t, s, http.MethodPost, "/api/decode", "", "8=FIX.4.4|35=0|10=000|"); rec.Code != http.StatusOK {
+				t.Errorf("POST decode: %d", rec.Code)
+			}
+		}()
+	}
+
+	wg.Wait()
+}